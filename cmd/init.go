@@ -3,6 +3,7 @@ package cmd
 import (
 	"HelpStudent/cmd/config"
 	"HelpStudent/cmd/create"
+	"HelpStudent/cmd/migrate"
 	"HelpStudent/cmd/server"
 	"github.com/spf13/cobra"
 	"os"
@@ -19,6 +20,7 @@ func init() {
 	rootCmd.AddCommand(server.StartCmd)
 	rootCmd.AddCommand(config.StartCmd)
 	rootCmd.AddCommand(create.StartCmd)
+	rootCmd.AddCommand(migrate.StartCmd)
 }
 
 func Execute() {
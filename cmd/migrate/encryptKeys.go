@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/store/pg"
+	"HelpStudent/core/stringx"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/service"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	configYml string
+	StartCmd  = &cobra.Command{
+		Use:     "encrypt-keys",
+		Short:   "Encrypt FastGPT app API keys with the current master key",
+		Example: "app encrypt-keys -c config/config.yaml",
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := load()
+			if err != nil {
+				println(stringx.Red("Encrypt keys failed: " + err.Error()))
+				os.Exit(1)
+			}
+			println(stringx.Green(fmt.Sprintf("Encrypted %d app API keys", n)))
+		},
+	}
+)
+
+func init() {
+	StartCmd.PersistentFlags().StringVarP(&configYml, "config", "c", "config/config.yaml", "Encrypt keys with provided configuration file")
+}
+
+func load() (int, error) {
+	config.LoadConfig(configYml)
+	if logx.SystemLogger == nil {
+		logx.SystemLogger = logx.Setup()
+	}
+
+	db := pg.MustNewPGOrm(config.GetConfig().MainPostgres).GetOrm()
+	if err := dao.Fastgpt.Init(db); err != nil {
+		return 0, err
+	}
+	return service.MigrateAPIKeys(context.Background())
+}
@@ -8,13 +8,16 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"HelpStudent/config"
+	"HelpStudent/core/fileServer"
 	"HelpStudent/core/healthz"
 	"HelpStudent/core/kernel"
 	"HelpStudent/core/logx"
+	"HelpStudent/core/metricx"
 	"HelpStudent/core/store/pg"
 	"HelpStudent/core/stringx"
 	"HelpStudent/core/tracex"
@@ -78,6 +81,12 @@ func setUp() {
 		logx.ServiceLogger.SetLevel(zap.DebugLevel)
 	}
 
+	// 注册指标上报，各模块通过 otel.Meter 创建的指标经此导出
+	if err := metricx.StartAgent(config.GetConfig().Metrics); err != nil {
+		logx.SystemLogger.Errorw("failed to start metrics agent", zap.Field{Key: "error", Type: zapcore.StringType, String: err.Error()})
+		os.Exit(1)
+	}
+
 	// 初始化 flamego
 	flamego.SetEnv(flamego.EnvType(config.GetConfig().MODE))
 	engine.Fg = flamego.New()
@@ -97,6 +106,10 @@ func setUp() {
 // 存储介质连接
 func loadStore() {
 	engine.MainPG = pg.MustNewPGOrm(config.GetConfig().MainPostgres)
+	if err := fileServer.InitFileServers(config.GetConfig().FileServers); err != nil {
+		logx.SystemLogger.Errorw("failed to init file servers", zap.Field{Key: "error", Type: zapcore.StringType, String: err.Error()})
+		os.Exit(1)
+	}
 }
 
 // 加载应用，包含多个生命周期
@@ -147,6 +160,18 @@ func loadApp() {
 
 }
 
+// 停止应用，各模块在 Stop 中自行调用 wg.Done
+func stopApps(ctx context.Context) {
+	wg := &sync.WaitGroup{}
+	for _, app := range appInitialize.GetApps() {
+		wg.Add(1)
+		if _err := app.Stop(wg, ctx); _err != nil {
+			logx.SystemLogger.Errorw("failed to stop app", zap.Field{Key: "error", Type: zapcore.StringType, String: _err.Error()})
+		}
+	}
+	wg.Wait()
+}
+
 // 启动服务
 func run() {
 	port := config.GetConfig().Port
@@ -199,8 +224,6 @@ func run() {
 			println(stringx.Yellow("Sls client close failed: " + err.Error()))
 		}
 	}
-	logx.SystemLogger.Stop()
-	logx.ServiceLogger.Stop()
 
 	ctx, cancel := context.WithTimeout(engine.Ctx, 5*time.Second)
 	defer engine.Cancel()
@@ -210,5 +233,12 @@ func run() {
 		println(stringx.Yellow("Server forced to shutdown: " + err.Error()))
 	}
 
+	// 停止各模块，等待后台任务退出
+	stopApps(ctx)
+	metricx.StopAgent(ctx)
+
+	logx.SystemLogger.Stop()
+	logx.ServiceLogger.Stop()
+
 	println(stringx.Green("Server exiting Correctly"))
 }
@@ -64,3 +64,8 @@ func GenConfig(configYml string, force bool) error {
 func GetConfig() *GlobalConfig {
 	return serveConfig
 }
+
+// SetConfig 直接替换当前配置，不读取文件也不监听变更，用于测试
+func SetConfig(c *GlobalConfig) {
+	serveConfig = c
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"HelpStudent/core/fileServer"
+	"HelpStudent/core/metricx"
 	"HelpStudent/core/store/pg"
+	"time"
 )
 
 type GlobalConfig struct {
@@ -16,12 +19,94 @@ type GlobalConfig struct {
 		Secret string `yaml:"Secret"`
 		Issuer string `yaml:"Issuer"`
 	} `yaml:"Auth"`
-	OAuth   []OAuth `yaml:"OAuth"`
-	FastGPT FastGPT `yaml:"FastGPT"`
+	OAuth       []OAuth             `yaml:"OAuth"`
+	FastGPT     FastGPT             `yaml:"FastGPT"`
+	FileServers []fileServer.Config `yaml:"FileServers"`
+	Jobs        Jobs                `yaml:"Jobs"`
+	Metrics     metricx.Config      `yaml:"Metrics"` // OTLP 指标上报，Endpoint 为空时不上报
+}
+
+// Jobs 后台任务队列配置，为 0 时使用默认值
+type Jobs struct {
+	Workers      int           `yaml:"Workers"`      // 同时执行的任务数，默认 4
+	PollInterval time.Duration `yaml:"PollInterval"` // 没有新任务通知时轮询数据库的间隔，默认 2s
+	StaleAfter   time.Duration `yaml:"StaleAfter"`   // 运行中的任务超过该时间没有心跳视为中断并重新执行，默认 2m
+	MaxAttempts  int           `yaml:"MaxAttempts"`  // 默认最大尝试次数，默认 3
 }
 
 type FastGPT struct {
-	BaseURL string `yaml:"BaseURL"`
+	BaseURL          string            `yaml:"BaseURL"`
+	RequestTimeout   time.Duration     `yaml:"RequestTimeout"`   // 非流式请求超时，默认 30s
+	ConnectTimeout   time.Duration     `yaml:"ConnectTimeout"`   // 建立连接超时，默认 5s
+	FirstByteTimeout time.Duration     `yaml:"FirstByteTimeout"` // 流式请求等待响应头超时，默认 60s
+	MaxRetries       int               `yaml:"MaxRetries"`       // 幂等 GET 请求的最大重试次数
+	RetryBackoff     time.Duration     `yaml:"RetryBackoff"`     // 重试退避基数，默认 200ms
+	Quota            Quota             `yaml:"Quota"`
+	ModelAliases     map[string]string `yaml:"ModelAliases"` // OpenAI 兼容接口的模型别名 -> AppName，不区分大小写
+	KeyEncryption    KeyEncryption     `yaml:"KeyEncryption"`
+	Backends         []Backend         `yaml:"Backends"`        // 额外的 FastGPT 实例，应用未指定后端时使用上面的 BaseURL
+	ForwardFeedback  bool              `yaml:"ForwardFeedback"` // 是否将回答评价同步到 FastGPT
+	Ingest           Ingest            `yaml:"Ingest"`
+	Image            Image             `yaml:"Image"`
+	Upload           Upload            `yaml:"Upload"`
+}
+
+// Upload 聊天附件配置，应用可以单独设置大小和类型，为 0 时使用默认值
+type Upload struct {
+	FileServer string        `yaml:"FileServer"` // 保存附件的 FileServers Key
+	MaxSize    int64         `yaml:"MaxSize"`    // 单个附件大小上限（MB），默认 10
+	URLTTL     time.Duration `yaml:"URLTTL"`     // 附件链接有效期，默认 10m，FastGPT 需要在有效期内下载
+	AllowTypes []string      `yaml:"AllowTypes"` // 允许的类型，默认 png、jpeg、gif、webp 图片和 PDF
+}
+
+// Image 图片代理配置，为 0 时使用默认值
+type Image struct {
+	SignKey     string        `yaml:"SignKey"`     // 图片链接签名密钥，为空时使用 Auth.Secret
+	URLTTL      time.Duration `yaml:"URLTTL"`      // 签名链接有效期，默认 1h
+	MaxSize     int64         `yaml:"MaxSize"`     // 单张图片大小上限（MB），默认 10
+	MemoryCache int64         `yaml:"MemoryCache"` // 内存缓存大小上限（MB），默认 64，负数表示不缓存
+	CacheDir    string        `yaml:"CacheDir"`    // 磁盘缓存目录，为空时不使用磁盘缓存
+	DiskTTL     time.Duration `yaml:"DiskTTL"`     // 磁盘缓存有效期，默认 7 天
+	AllowTypes  []string      `yaml:"AllowTypes"`  // 允许返回的图片类型，默认 png、jpeg、gif、webp
+}
+
+// Ingest 课程文件导入知识库的配置，为 0 时使用默认值
+type Ingest struct {
+	FileServer   string `yaml:"FileServer"`   // 保存原始文件的 FileServers Key
+	MaxFileSize  int64  `yaml:"MaxFileSize"`  // 上传文件大小上限（MB），默认 50
+	ChunkSize    int    `yaml:"ChunkSize"`    // 默认分块字符数，默认 800
+	ChunkOverlap int    `yaml:"ChunkOverlap"` // 默认相邻分块重叠字符数，默认 100，负数表示不重叠
+	BatchSize    int    `yaml:"BatchSize"`    // 每次 pushData 的分块数，默认 50
+}
+
+// Backend 一个具名的 FastGPT 实例，超时为 0 时使用 FastGPT 中的全局配置
+type Backend struct {
+	Name             string            `yaml:"Name"`
+	BaseURL          string            `yaml:"BaseURL"`
+	RequestTimeout   time.Duration     `yaml:"RequestTimeout"`
+	ConnectTimeout   time.Duration     `yaml:"ConnectTimeout"`
+	FirstByteTimeout time.Duration     `yaml:"FirstByteTimeout"`
+	Headers          map[string]string `yaml:"Headers"` // 附加到每个请求的请求头
+}
+
+// KeyEncryption 应用 API Key 的加密配置，必须配置 CurrentVersion 对应的主密钥，否则服务无法启动
+// 轮换时新增版本并修改 CurrentVersion，旧版本保留到迁移完成
+type KeyEncryption struct {
+	CurrentVersion int         `yaml:"CurrentVersion"` // 新数据使用的主密钥版本
+	MasterKeys     []MasterKey `yaml:"MasterKeys"`
+}
+
+// MasterKey 主密钥
+type MasterKey struct {
+	Version int    `yaml:"Version"`
+	Key     string `yaml:"Key"` // base64 编码的 32 字节密钥，可用 openssl rand -base64 32 生成
+}
+
+// Quota 默认的单用户聊天配额，0 表示不限制
+type Quota struct {
+	RequestsPerMinute int   `yaml:"RequestsPerMinute"`
+	MessagesPerDay    int   `yaml:"MessagesPerDay"`
+	TokensPerMonth    int64 `yaml:"TokensPerMonth"`
 }
 
 type OAuth struct {
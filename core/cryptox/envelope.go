@@ -0,0 +1,192 @@
+package cryptox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// envelopePrefix 未绑定附加数据的信封，仅用于读取旧数据
+	envelopePrefix = "enc"
+	// boundPrefix 绑定了附加数据的信封，附加数据不一致时无法解密
+	boundPrefix = "encb"
+	dataKeySize = 32
+)
+
+var (
+	// ErrNoMasterKey 没有配置当前版本的主密钥
+	ErrNoMasterKey = errors.New("cryptox: no master key configured")
+	// ErrUnknownVersion 密文使用的主密钥版本未加载
+	ErrUnknownVersion = errors.New("cryptox: unknown master key version")
+	// ErrMalformed 不是合法的信封格式
+	ErrMalformed = errors.New("cryptox: malformed envelope")
+)
+
+// Keyring 按版本保存的主密钥，用于信封加密
+// 每个值使用随机数据密钥加密，数据密钥再由当前主密钥加密，轮换主密钥时只需重新加密数据密钥
+type Keyring struct {
+	current int
+	keys    map[int][]byte
+}
+
+// NewKeyring 根据 base64 编码的 32 字节主密钥创建密钥环，keys 以版本号为键
+func NewKeyring(current int, keys map[int]string) (*Keyring, error) {
+	k := &Keyring{current: current, keys: make(map[int][]byte, len(keys))}
+	for version, encoded := range keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("cryptox: decode master key v%d: %w", version, err)
+		}
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("cryptox: master key v%d must be %d bytes", version, dataKeySize)
+		}
+		k.keys[version] = key
+	}
+	if len(k.keys) > 0 {
+		if _, ok := k.keys[current]; !ok {
+			return nil, fmt.Errorf("cryptox: current master key v%d not found", current)
+		}
+	}
+	return k, nil
+}
+
+// CurrentVersion 返回加密新数据使用的主密钥版本
+func (k *Keyring) CurrentVersion() int {
+	return k.current
+}
+
+// Validate 检查当前版本的主密钥是否已配置，用于启动时提前发现配置错误
+func (k *Keyring) Validate() error {
+	if _, ok := k.keys[k.current]; !ok {
+		return ErrNoMasterKey
+	}
+	return nil
+}
+
+// Encrypt 加密 plain，associated 作为 GCM 附加数据参与认证但不保存在密文中，
+// 解密时必须提供相同的值，例如数据所在行的主键，防止密文被复制到其他行使用
+// 结果格式为 encb:v<版本>:<加密后的数据密钥>:<密文>
+func (k *Keyring) Encrypt(plain string, associated []byte) (string, error) {
+	master, ok := k.keys[k.current]
+	if !ok {
+		return "", ErrNoMasterKey
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(master, dataKey, associated)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, []byte(plain), associated)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		boundPrefix,
+		"v" + strconv.Itoa(k.current),
+		base64.RawStdEncoding.EncodeToString(wrapped),
+		base64.RawStdEncoding.EncodeToString(ciphertext),
+	}, ":"), nil
+}
+
+// Decrypt 解密 Encrypt 生成的信封，associated 必须与加密时一致
+// 旧格式的信封没有绑定附加数据，忽略 associated，调用方应通过 IsBound 判断后尽快重新加密
+func (k *Keyring) Decrypt(value string, associated []byte) (string, error) {
+	bound, version, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	if !bound {
+		associated = nil
+	}
+	master, ok := k.keys[version]
+	if !ok {
+		return "", ErrUnknownVersion
+	}
+	dataKey, err := open(master, wrapped, associated)
+	if err != nil {
+		return "", err
+	}
+	plain, err := open(dataKey, ciphertext, associated)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// IsEncrypted 判断 value 是否为信封格式（包括旧格式）
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix+":v") || strings.HasPrefix(value, boundPrefix+":v")
+}
+
+// IsBound 判断信封是否绑定了附加数据
+func IsBound(value string) bool {
+	return strings.HasPrefix(value, boundPrefix+":v")
+}
+
+// Version 返回信封使用的主密钥版本
+func Version(value string) (int, error) {
+	_, version, _, _, err := parse(value)
+	return version, err
+}
+
+func parse(value string) (bool, int, []byte, []byte, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 4 || (parts[0] != envelopePrefix && parts[0] != boundPrefix) || !strings.HasPrefix(parts[1], "v") {
+		return false, 0, nil, nil, ErrMalformed
+	}
+	bound := parts[0] == boundPrefix
+	version, err := strconv.Atoi(parts[1][1:])
+	if err != nil {
+		return false, 0, nil, nil, ErrMalformed
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, 0, nil, nil, ErrMalformed
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, 0, nil, nil, ErrMalformed
+	}
+	return bound, version, wrapped, ciphertext, nil
+}
+
+func seal(key, plain, associated []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, associated), nil
+}
+
+func open(key, sealed, associated []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, associated)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
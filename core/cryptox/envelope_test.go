@@ -0,0 +1,162 @@
+package cryptox
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var aad = []byte("01HZXAPPID0000000000000000")
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), dataKeySize)))
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	k, err := NewKeyring(1, map[int]string{1: testKey('a')})
+	if err != nil {
+		t.Fatalf("NewKeyring failed: %v", err)
+	}
+
+	sealed, err := k.Encrypt("fastgpt-secret", aad)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Errorf("IsEncrypted returned false for %s", sealed)
+	}
+	if strings.Contains(sealed, "fastgpt-secret") {
+		t.Error("envelope contains plaintext")
+	}
+
+	plain, err := k.Decrypt(sealed, aad)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if plain != "fastgpt-secret" {
+		t.Errorf("Decrypt returned wrong value: got %s", plain)
+	}
+
+	// 相同明文每次加密结果不同
+	again, _ := k.Encrypt("fastgpt-secret", aad)
+	if again == sealed {
+		t.Error("Encrypt should use a fresh data key and nonce")
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, _ := NewKeyring(1, map[int]string{1: testKey('a')})
+	sealed, _ := old.Encrypt("fastgpt-secret", aad)
+
+	k, err := NewKeyring(2, map[int]string{1: testKey('a'), 2: testKey('b')})
+	if err != nil {
+		t.Fatalf("NewKeyring failed: %v", err)
+	}
+	plain, err := k.Decrypt(sealed, aad)
+	if err != nil || plain != "fastgpt-secret" {
+		t.Fatalf("Decrypt with old version failed: %v", err)
+	}
+
+	resealed, _ := k.Encrypt(plain, aad)
+	if v, _ := Version(resealed); v != 2 {
+		t.Errorf("Version returned %d, want 2", v)
+	}
+
+	// 只加载新版本时无法解密旧数据
+	onlyNew, _ := NewKeyring(2, map[int]string{2: testKey('b')})
+	if _, err := onlyNew.Decrypt(sealed, aad); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Decrypt should fail with ErrUnknownVersion, got %v", err)
+	}
+}
+
+func TestKeyring_Invalid(t *testing.T) {
+	if _, err := NewKeyring(1, map[int]string{1: "c2hvcnQ="}); err == nil {
+		t.Error("NewKeyring should reject short keys")
+	}
+	if _, err := NewKeyring(2, map[int]string{1: testKey('a')}); err == nil {
+		t.Error("NewKeyring should reject missing current version")
+	}
+
+	empty, _ := NewKeyring(0, nil)
+	if _, err := empty.Encrypt("x", aad); !errors.Is(err, ErrNoMasterKey) {
+		t.Errorf("Encrypt should fail with ErrNoMasterKey, got %v", err)
+	}
+
+	k, _ := NewKeyring(1, map[int]string{1: testKey('a')})
+	if _, err := k.Decrypt("fastgpt-plain", aad); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Decrypt should fail with ErrMalformed, got %v", err)
+	}
+	sealed, _ := k.Encrypt("x", aad)
+	if _, err := k.Decrypt(sealed[:len(sealed)-2]+"AA", aad); err == nil {
+		t.Error("Decrypt should detect tampering")
+	}
+}
+
+func TestKeyring_AssociatedData(t *testing.T) {
+	k, _ := NewKeyring(1, map[int]string{1: testKey('a')})
+	sealed, err := k.Encrypt("fastgpt-secret", []byte("app-1"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !IsBound(sealed) {
+		t.Error("IsBound returned false for new envelope")
+	}
+
+	tests := []struct {
+		name       string
+		associated []byte
+		wantErr    bool
+	}{
+		{name: "same row", associated: []byte("app-1"), wantErr: false},
+		{name: "copied to another row", associated: []byte("app-2"), wantErr: true},
+		{name: "missing associated data", associated: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, err := k.Decrypt(sealed, tt.associated)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && plain != "fastgpt-secret" {
+				t.Errorf("Decrypt returned wrong value: got %s", plain)
+			}
+		})
+	}
+}
+
+func TestKeyring_LegacyEnvelope(t *testing.T) {
+	k, _ := NewKeyring(1, map[int]string{1: testKey('a')})
+
+	// 旧格式：没有附加数据
+	dataKey := []byte(strings.Repeat("k", dataKeySize))
+	wrapped, _ := seal(k.keys[1], dataKey, nil)
+	ciphertext, _ := seal(dataKey, []byte("fastgpt-legacy"), nil)
+	legacy := strings.Join([]string{
+		envelopePrefix, "v1",
+		base64.RawStdEncoding.EncodeToString(wrapped),
+		base64.RawStdEncoding.EncodeToString(ciphertext),
+	}, ":")
+
+	if !IsEncrypted(legacy) || IsBound(legacy) {
+		t.Fatalf("legacy envelope detection wrong: IsEncrypted=%v IsBound=%v", IsEncrypted(legacy), IsBound(legacy))
+	}
+	plain, err := k.Decrypt(legacy, []byte("app-1"))
+	if err != nil || plain != "fastgpt-legacy" {
+		t.Fatalf("Decrypt legacy failed: %v", err)
+	}
+	if v, _ := Version(legacy); v != 1 {
+		t.Errorf("Version returned %d, want 1", v)
+	}
+}
+
+func TestKeyring_Validate(t *testing.T) {
+	empty, _ := NewKeyring(1, nil)
+	if err := empty.Validate(); !errors.Is(err, ErrNoMasterKey) {
+		t.Errorf("Validate should fail with ErrNoMasterKey, got %v", err)
+	}
+	k, _ := NewKeyring(1, map[int]string{1: testKey('a')})
+	if err := k.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+}
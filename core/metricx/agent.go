@@ -0,0 +1,98 @@
+package metricx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"HelpStudent/core/logx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
+)
+
+const (
+	kindOtlpGrpc = "otlpgrpc"
+	kindOtlpHttp = "otlphttp"
+
+	defaultInterval = 30 * time.Second
+)
+
+var (
+	lock sync.Mutex
+	mp   *sdkmetric.MeterProvider
+)
+
+// StartAgent registers a global MeterProvider that periodically exports to the configured endpoint.
+// Instruments created from otel.Meter before StartAgent are delegated to the new provider.
+func StartAgent(c Config) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if mp != nil {
+		return nil
+	}
+	if len(c.Endpoint) == 0 {
+		logx.SystemLogger.Info("[otel] metrics endpoint not configured, metrics are disabled")
+		return nil
+	}
+
+	exp, err := createExporter(c)
+	if err != nil {
+		return err
+	}
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	mp = sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp, sdkmetric.WithInterval(interval))),
+		sdkmetric.WithResource(resource.NewSchemaless(semconv.ServiceNameKey.String(c.Name))),
+	)
+	otel.SetMeterProvider(mp)
+	return nil
+}
+
+// StopAgent flushes pending metrics and shuts down the MeterProvider.
+func StopAgent(ctx context.Context) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if mp == nil {
+		return
+	}
+	if err := mp.Shutdown(ctx); err != nil {
+		logx.SystemLogger.Errorf("[otel] shutdown meter provider: %v", err)
+	}
+	mp = nil
+}
+
+func createExporter(c Config) (sdkmetric.Exporter, error) {
+	headers := map[string]string{"Authentication": c.Auth}
+	switch c.Proto {
+	case kindOtlpGrpc:
+		return otlpmetricgrpc.New(
+			context.Background(),
+			otlpmetricgrpc.WithInsecure(),
+			otlpmetricgrpc.WithHeaders(headers),
+			otlpmetricgrpc.WithEndpoint(c.Endpoint),
+		)
+	case kindOtlpHttp:
+		opts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithInsecure(),
+			otlpmetrichttp.WithHeaders(headers),
+			otlpmetrichttp.WithEndpoint(c.Endpoint),
+		}
+		if c.UrlPath != "" {
+			opts = append(opts, otlpmetrichttp.WithURLPath(c.UrlPath))
+		}
+		return otlpmetrichttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("unknown exporter: %s", c.Proto)
+	}
+}
@@ -0,0 +1,13 @@
+package metricx
+
+import "time"
+
+// A Config is an opentelemetry metrics config.
+type Config struct {
+	Name     string        `yaml:"Name"`
+	Endpoint string        `yaml:"Endpoint"`
+	Auth     string        `yaml:"Auth"`
+	Proto    string        `yaml:"Proto"`
+	UrlPath  string        `yaml:"UrlPath"`
+	Interval time.Duration `yaml:"Interval"` // export interval, default 30s
+}
@@ -20,6 +20,14 @@ type Options struct {
 	PingInterval time.Duration
 }
 
+// Notifier is mapped for the next handler to detect when the client connection
+// is gone, so that it can stop producing events and release upstream resources.
+type Notifier interface {
+	// Closed returns a channel that is closed once the connection handler exits,
+	// either because the client disconnected or the stream ended.
+	Closed() <-chan struct{}
+}
+
 type connection struct {
 	Options
 
@@ -27,6 +35,14 @@ type connection struct {
 	// gets mapped for the next handler to use with the right type and is
 	// asynchronous unless the SendChannelBuffer is set to 0.
 	sender reflect.Value
+
+	// done is closed when the connection handler exits.
+	done chan struct{}
+}
+
+// Closed implements Notifier.
+func (c *connection) Closed() <-chan struct{} {
+	return c.done
 }
 
 // Bind returns a middleware handler that uses the given bound object as the
@@ -42,8 +58,10 @@ func Bind(obj interface{}, opts ...Options) flamego.Handler {
 			Options: newOptions(opts),
 			// Create a chan of the given type as a reflect.Value.
 			sender: reflect.MakeChan(reflect.ChanOf(reflect.BothDir, reflect.PtrTo(reflect.TypeOf(obj))), 0),
+			done:   make(chan struct{}),
 		}
 		c.Set(reflect.ChanOf(reflect.SendDir, sse.sender.Type().Elem()), sse.sender)
+		c.MapTo(sse, (*Notifier)(nil))
 
 		go sse.handle(log, c)
 	}
@@ -60,6 +78,8 @@ func newOptions(opts []Options) Options {
 }
 
 func (c *connection) handle(log *log.Logger, ctx flamego.Context) {
+	// 通知处理函数连接已结束，避免其阻塞在发送上或继续读取上游
+	defer close(c.done)
 	// 捕获可能的 panic，防止连接断开后写入导致崩溃
 	defer func() {
 		if r := recover(); r != nil {
@@ -1,6 +1,9 @@
 package syncx
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 type (
 	// SingleFlight lets the concurrent calls with the same key to share the call result.
@@ -12,12 +15,13 @@ type (
 	SingleFlight interface {
 		Do(key string, fn func() (any, error)) (any, error)
 		DoEx(key string, fn func() (any, error)) (any, bool, error)
+		DoCtx(ctx context.Context, key string, fn func() (any, error)) (any, bool, error)
 	}
 
 	call struct {
-		wg  sync.WaitGroup
-		val any
-		err error
+		done chan struct{}
+		val  any
+		err  error
 	}
 
 	flightGroup struct {
@@ -53,19 +57,42 @@ func (g *flightGroup) DoEx(key string, fn func() (any, error)) (val any, fresh b
 	return c.val, true, c.err
 }
 
+// DoCtx is like DoEx, but a caller waiting for another call returns ctx.Err()
+// as soon as ctx is done. The running call is not affected.
+func (g *flightGroup) DoCtx(ctx context.Context, key string, fn func() (any, error)) (val any, fresh bool, err error) {
+	c, ok := g.joinCall(key)
+	if ok {
+		select {
+		case <-c.done:
+			return c.val, false, c.err
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+
+	g.makeCall(c, key, fn)
+	return c.val, true, c.err
+}
+
 func (g *flightGroup) createCall(key string) (c *call, done bool) {
+	c, done = g.joinCall(key)
+	if done {
+		<-c.done
+	}
+	return c, done
+}
+
+// joinCall returns the running call with key if there is one,
+// otherwise registers a new call for the caller to make.
+func (g *flightGroup) joinCall(key string) (c *call, ok bool) {
 	g.lock.Lock()
+	defer g.lock.Unlock()
 	if c, ok := g.calls[key]; ok {
-		g.lock.Unlock()
-		c.wg.Wait()
 		return c, true
 	}
 
-	c = new(call)
-	c.wg.Add(1)
+	c = &call{done: make(chan struct{})}
 	g.calls[key] = c
-	g.lock.Unlock()
-
 	return c, false
 }
 
@@ -74,7 +101,7 @@ func (g *flightGroup) makeCall(c *call, key string, fn func() (any, error)) {
 		g.lock.Lock()
 		delete(g.calls, key)
 		g.lock.Unlock()
-		c.wg.Done()
+		close(c.done)
 	}()
 
 	c.val, c.err = fn()
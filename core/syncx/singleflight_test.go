@@ -0,0 +1,74 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleFlight_DoCtx(t *testing.T) {
+	g := NewSingleFlight()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls atomic.Int32
+
+	leader := make(chan error, 1)
+	go func() {
+		_, fresh, err := g.DoCtx(context.Background(), "k", func() (any, error) {
+			calls.Add(1)
+			close(started)
+			<-release
+			return "v", nil
+		})
+		if !fresh {
+			err = errors.New("leader not fresh")
+		}
+		leader <- err
+	}()
+	<-started
+
+	// 等待的调用在自身 ctx 结束时返回，不影响正在执行的调用
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, fresh, err := g.DoCtx(ctx, "k", nil); fresh || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("canceled waiter: got fresh=%v err=%v", fresh, err)
+	}
+
+	waiter := make(chan any, 1)
+	go func() {
+		v, _, _ := g.DoCtx(context.Background(), "k", nil)
+		waiter <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if err := <-leader; err != nil {
+		t.Fatalf("leader: %v", err)
+	}
+	if v := <-waiter; v != "v" {
+		t.Errorf("waiter: got %v, want v", v)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls: got %d, want 1", calls.Load())
+	}
+
+	// 调用结束后相同 key 重新执行
+	v, fresh, err := g.DoCtx(context.Background(), "k", func() (any, error) { return "again", nil })
+	if v != "again" || !fresh || err != nil {
+		t.Errorf("after done: got %v %v %v", v, fresh, err)
+	}
+}
+
+func TestSingleFlight_DoEx(t *testing.T) {
+	g := NewSingleFlight()
+	v, fresh, err := g.DoEx("k", func() (any, error) { return 1, nil })
+	if v != 1 || !fresh || err != nil {
+		t.Errorf("got %v %v %v", v, fresh, err)
+	}
+	want := errors.New("boom")
+	if _, err := g.Do("k", func() (any, error) { return nil, want }); !errors.Is(err, want) {
+		t.Errorf("Do err: got %v, want %v", err, want)
+	}
+}
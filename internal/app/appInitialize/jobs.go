@@ -0,0 +1,7 @@
+package appInitialize
+
+import "HelpStudent/internal/app/jobs"
+
+func init() {
+	apps = append(apps, &jobs.Jobs{Name: "Jobs module"})
+}
@@ -0,0 +1,57 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKey struct {
+	*gorm.DB
+}
+
+var APIKey = &apiKey{}
+
+func (u *apiKey) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.PersonalAPIKey{})
+}
+
+// CreateKey 保存新的个人 API Key
+func (u *apiKey) CreateKey(ctx context.Context, key *model.PersonalAPIKey) error {
+	return u.WithContext(ctx).Create(key).Error
+}
+
+// GetKeyByHash 根据哈希获取 API Key，不存在时返回 nil
+func (u *apiKey) GetKeyByHash(ctx context.Context, hash string) (*model.PersonalAPIKey, error) {
+	var key model.PersonalAPIKey
+	err := u.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListKeys 获取用户的全部 API Key
+func (u *apiKey) ListKeys(ctx context.Context, staffId string) ([]model.PersonalAPIKey, error) {
+	var keys []model.PersonalAPIKey
+	err := u.WithContext(ctx).Where("staff_id = ?", staffId).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// DeleteKey 删除用户自己的 API Key
+func (u *apiKey) DeleteKey(ctx context.Context, id, staffId string) (int64, error) {
+	result := u.WithContext(ctx).Where("id = ? AND staff_id = ?", id, staffId).Delete(&model.PersonalAPIKey{})
+	return result.RowsAffected, result.Error
+}
+
+// TouchKey 更新最后使用时间
+func (u *apiKey) TouchKey(ctx context.Context, id string, at time.Time) error {
+	return u.WithContext(ctx).Model(&model.PersonalAPIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
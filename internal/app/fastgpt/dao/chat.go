@@ -0,0 +1,190 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type chat struct {
+	*gorm.DB
+}
+
+var Chat = &chat{}
+
+func (u *chat) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.ChatSession{}, &model.ChatMessage{})
+}
+
+// sessionConflict 与 idx_chat_session_active 对应的冲突条件，部分索引需要带上 WHERE 才能被 PostgreSQL 匹配
+var sessionConflict = clause.OnConflict{
+	Columns:     []clause.Column{{Name: "user_id"}, {Name: "app_id"}, {Name: "chat_id"}},
+	TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+	DoNothing:   true,
+}
+
+// RecordExchange 记录一轮问答，会话不存在时自动创建
+func (u *chat) RecordExchange(ctx context.Context, session *model.ChatSession, messages ...*model.ChatMessage) error {
+	return u.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		session.LastMessageAt = now
+		if err := tx.Clauses(sessionConflict).Create(session).Error; err != nil {
+			return err
+		}
+
+		// 无论是否新建，都以数据库中的记录为准
+		var existing model.ChatSession
+		if err := tx.Where("user_id = ? AND app_id = ? AND chat_id = ?", session.UserId, session.AppId, session.ChatId).
+			First(&existing).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&existing).Update("last_message_at", now).Error; err != nil {
+			return err
+		}
+		*session = existing
+
+		for _, m := range messages {
+			m.SessionId = existing.ID
+			m.UserId = existing.UserId
+			m.AppId = existing.AppId
+			m.ChatId = existing.ChatId
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		return tx.Create(&messages).Error
+	})
+}
+
+// ImportSessions 导入 FastGPT 中已有的会话，已存在的跳过，返回新导入的数量
+func (u *chat) ImportSessions(ctx context.Context, sessions []*model.ChatSession) (int64, error) {
+	if len(sessions) == 0 {
+		return 0, nil
+	}
+	result := u.WithContext(ctx).Clauses(sessionConflict).Create(&sessions)
+	return result.RowsAffected, result.Error
+}
+
+// GetSession 获取用户在某个应用下的会话
+func (u *chat) GetSession(ctx context.Context, userId, appId, chatId string) (*model.ChatSession, error) {
+	var session model.ChatSession
+	err := u.WithContext(ctx).Where("user_id = ? AND app_id = ? AND chat_id = ?", userId, appId, chatId).
+		First(&session).Error
+	return &session, err
+}
+
+// GetMessages 获取会话下的全部消息，按时间顺序
+func (u *chat) GetMessages(ctx context.Context, sessionId string) ([]model.ChatMessage, error) {
+	var messages []model.ChatMessage
+	err := u.WithContext(ctx).Where("session_id = ?", sessionId).Order("created_at ASC, id ASC").Find(&messages).Error
+	return messages, err
+}
+
+// ListAppSessions 获取应用下全部用户的会话，按最后消息时间倒序
+func (u *chat) ListAppSessions(ctx context.Context, appId string) ([]model.ChatSession, error) {
+	var sessions []model.ChatSession
+	err := u.WithContext(ctx).Where("app_id = ?", appId).Order("last_message_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// SessionFilter 会话列表的筛选条件
+type SessionFilter struct {
+	AppId    string
+	Archived bool
+}
+
+// ListUserSessions 分页获取用户的会话，置顶的在前，其余按最后消息时间倒序
+func (u *chat) ListUserSessions(ctx context.Context, userId string, filter SessionFilter, offset, limit int) ([]model.ChatSession, int64, error) {
+	var sessions []model.ChatSession
+	var total int64
+
+	query := u.WithContext(ctx).Model(&model.ChatSession{}).
+		Where("user_id = ? AND archived = ?", userId, filter.Archived)
+	if filter.AppId != "" {
+		query = query.Where("app_id = ?", filter.AppId)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("pinned DESC, last_message_at DESC").Offset(offset).Limit(limit).Find(&sessions).Error
+	return sessions, total, err
+}
+
+// GetUserSession 根据主键获取用户自己的会话
+func (u *chat) GetUserSession(ctx context.Context, userId, id string) (*model.ChatSession, error) {
+	var session model.ChatSession
+	err := u.WithContext(ctx).Where("id = ? AND user_id = ?", id, userId).First(&session).Error
+	return &session, err
+}
+
+// UpdateSession 更新会话
+func (u *chat) UpdateSession(ctx context.Context, id string, updates map[string]interface{}) error {
+	return u.WithContext(ctx).Model(&model.ChatSession{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// UpdateSessionByChat 根据 FastGPT 会话 ID 更新用户的会话，用于同步直接转发的修改
+func (u *chat) UpdateSessionByChat(ctx context.Context, userId, appId, chatId string, updates map[string]interface{}) error {
+	return u.WithContext(ctx).Model(&model.ChatSession{}).
+		Where("user_id = ? AND app_id = ? AND chat_id = ?", userId, appId, chatId).
+		Updates(updates).Error
+}
+
+// DeleteSession 删除会话及其消息
+func (u *chat) DeleteSession(ctx context.Context, session *model.ChatSession) error {
+	return u.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", session.ID).Delete(&model.ChatMessage{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(session).Error
+	})
+}
+
+// DeleteSessionByChat 根据 FastGPT 会话 ID 删除用户的会话，会话不存在时不报错
+func (u *chat) DeleteSessionByChat(ctx context.Context, userId, appId, chatId string) error {
+	session, err := u.GetSession(ctx, userId, appId, chatId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return u.DeleteSession(ctx, session)
+}
+
+// SearchMessages 在用户自己未删除的会话中按关键字搜索消息，按时间倒序
+func (u *chat) SearchMessages(ctx context.Context, userId, appId, keyword string, offset, limit int) ([]model.ChatMessage, int64, error) {
+	var messages []model.ChatMessage
+	var total int64
+
+	sessions := u.WithContext(ctx).Model(&model.ChatSession{}).Select("id").Where("user_id = ?", userId)
+	query := u.WithContext(ctx).Model(&model.ChatMessage{}).
+		Where("user_id = ? AND content ILIKE ? AND session_id IN (?)", userId, "%"+escapeLike(keyword)+"%", sessions)
+	if appId != "" {
+		query = query.Where("app_id = ?", appId)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&messages).Error
+	return messages, total, err
+}
+
+// GetSessionsByIds 批量获取会话
+func (u *chat) GetSessionsByIds(ctx context.Context, ids []string) ([]model.ChatSession, error) {
+	var sessions []model.ChatSession
+	err := u.WithContext(ctx).Where("id IN ?", ids).Find(&sessions).Error
+	return sessions, err
+}
+
+// escapeLike 转义 LIKE 中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,141 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type eval struct {
+	*gorm.DB
+}
+
+var Eval = &eval{}
+
+func (u *eval) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.EvalSet{}, &model.EvalCase{}, &model.EvalRun{})
+}
+
+// CreateSet 创建评测集
+func (u *eval) CreateSet(ctx context.Context, set *model.EvalSet) error {
+	return u.WithContext(ctx).Create(set).Error
+}
+
+// UpdateSet 更新评测集
+func (u *eval) UpdateSet(ctx context.Context, id string, updates map[string]interface{}) (int64, error) {
+	result := u.WithContext(ctx).Model(&model.EvalSet{}).Where("id = ?", id).Updates(updates)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteSet 删除评测集及其问题，历史运行记录保留
+func (u *eval) DeleteSet(ctx context.Context, id string) (int64, error) {
+	var affected int64
+	err := u.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&model.EvalSet{})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		return tx.Where("set_id = ?", id).Delete(&model.EvalCase{}).Error
+	})
+	return affected, err
+}
+
+// GetSet 获取评测集
+func (u *eval) GetSet(ctx context.Context, id string) (*model.EvalSet, error) {
+	var set model.EvalSet
+	err := u.WithContext(ctx).Where("id = ?", id).First(&set).Error
+	return &set, err
+}
+
+// ListSets 获取评测集，appId、datasetId 为空时不过滤
+func (u *eval) ListSets(ctx context.Context, appId, datasetId string) ([]model.EvalSet, error) {
+	var sets []model.EvalSet
+	query := u.WithContext(ctx)
+	if appId != "" {
+		query = query.Where("app_id = ?", appId)
+	}
+	if datasetId != "" {
+		query = query.Where("dataset_id = ?", datasetId)
+	}
+	err := query.Order("created_at DESC").Find(&sets).Error
+	return sets, err
+}
+
+// CountCases 统计各评测集的问题数
+func (u *eval) CountCases(ctx context.Context, setIds []string) (map[string]int64, error) {
+	var rows []struct {
+		SetId string
+		Count int64
+	}
+	err := u.WithContext(ctx).Model(&model.EvalCase{}).
+		Select("set_id, COUNT(*) AS count").
+		Where("set_id IN ?", setIds).
+		Group("set_id").
+		Scan(&rows).Error
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.SetId] = row.Count
+	}
+	return counts, err
+}
+
+// AddCases 批量添加评测问题
+func (u *eval) AddCases(ctx context.Context, cases []model.EvalCase) error {
+	if len(cases) == 0 {
+		return nil
+	}
+	return u.WithContext(ctx).Create(&cases).Error
+}
+
+// UpdateCase 更新评测问题
+func (u *eval) UpdateCase(ctx context.Context, setId, id string, updates map[string]interface{}) (int64, error) {
+	result := u.WithContext(ctx).Model(&model.EvalCase{}).Where("id = ? AND set_id = ?", id, setId).Updates(updates)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteCases 删除评测问题
+func (u *eval) DeleteCases(ctx context.Context, setId string, ids []string) (int64, error) {
+	result := u.WithContext(ctx).Where("set_id = ? AND id IN ?", setId, ids).Delete(&model.EvalCase{})
+	return result.RowsAffected, result.Error
+}
+
+// GetCases 获取评测集的全部问题
+func (u *eval) GetCases(ctx context.Context, setId string) ([]model.EvalCase, error) {
+	var cases []model.EvalCase
+	err := u.WithContext(ctx).Where("set_id = ?", setId).Order("created_at ASC, id ASC").Find(&cases).Error
+	return cases, err
+}
+
+// CreateRun 创建评测运行
+func (u *eval) CreateRun(ctx context.Context, run *model.EvalRun) error {
+	return u.WithContext(ctx).Create(run).Error
+}
+
+// UpdateRun 更新评测运行的状态或结果
+func (u *eval) UpdateRun(ctx context.Context, id string, updates map[string]interface{}) error {
+	return u.WithContext(ctx).Model(&model.EvalRun{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetRun 获取评测运行
+func (u *eval) GetRun(ctx context.Context, id string) (*model.EvalRun, error) {
+	var run model.EvalRun
+	err := u.WithContext(ctx).Where("id = ?", id).First(&run).Error
+	return &run, err
+}
+
+// ListRuns 分页获取评测集的运行记录，列表不返回每个问题的结果
+func (u *eval) ListRuns(ctx context.Context, setId string, offset, limit int) ([]model.EvalRun, int64, error) {
+	var runs []model.EvalRun
+	var total int64
+
+	query := u.WithContext(ctx).Model(&model.EvalRun{}).Where("set_id = ?", setId)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Omit("results").Order("created_at DESC").Offset(offset).Limit(limit).Find(&runs).Error
+	return runs, total, err
+}
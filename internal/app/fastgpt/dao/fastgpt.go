@@ -17,7 +17,7 @@ var FastgptApp *fastgpt
 func (u *fastgpt) Init(db *gorm.DB) (err error) {
 	u.DB = db
 	FastgptApp = u
-	return db.AutoMigrate(&model.FastgptApp{})
+	return db.AutoMigrate(&model.FastgptApp{}, &model.AppKeyAudit{})
 }
 
 // CreateApp 创建应用
@@ -25,6 +25,22 @@ func (u *fastgpt) CreateApp(app *model.FastgptApp) error {
 	return u.Create(app).Error
 }
 
+// CreateAppWithKey 创建应用并保存加密后的 API Key
+// 主键在插入时才生成，而密文需要绑定主键，因此在同一事务中先插入再回填 API Key
+func (u *fastgpt) CreateAppWithKey(app *model.FastgptApp, seal func(id string) (string, error)) error {
+	return u.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(app).Error; err != nil {
+			return err
+		}
+		apiKey, err := seal(app.ID)
+		if err != nil {
+			return err
+		}
+		app.APIKey = apiKey
+		return tx.Model(app).UpdateColumn("api_key", apiKey).Error
+	})
+}
+
 // GetAppByID 根据 AppID 获取应用
 func (u *fastgpt) GetAppByID(appID string) (*model.FastgptApp, error) {
 	var app model.FastgptApp
@@ -51,6 +67,19 @@ func (u *fastgpt) GetAppByShareID(shareID string) (*model.FastgptApp, error) {
 	return &app, nil
 }
 
+// GetAppByName 根据 AppName 获取应用
+func (u *fastgpt) GetAppByName(appName string) (*model.FastgptApp, error) {
+	var app model.FastgptApp
+	err := u.Where("app_name = ?", appName).First(&app).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("应用不存在或已禁用")
+		}
+		return nil, err
+	}
+	return &app, nil
+}
+
 // GetAppByPrimaryID 根据主键ID获取应用
 func (u *fastgpt) GetAppByPrimaryID(ctx context.Context, id string) (*model.FastgptApp, error) {
 	var app model.FastgptApp
@@ -115,3 +144,20 @@ func (u *fastgpt) SubjectsExist(subjectNames []string) ([]string, error) {
 
 	return notExist, nil
 }
+
+// ListAPIKeys 获取全部应用（含已删除）的主键和 API Key，用于加密迁移
+func (u *fastgpt) ListAPIKeys(ctx context.Context) ([]model.FastgptApp, error) {
+	var apps []model.FastgptApp
+	err := u.WithContext(ctx).Unscoped().Select("id", "api_key").Order("id ASC").Find(&apps).Error
+	return apps, err
+}
+
+// UpdateAPIKey 更新应用的 API Key（含已删除的应用）
+func (u *fastgpt) UpdateAPIKey(ctx context.Context, id, apiKey string) error {
+	return u.WithContext(ctx).Unscoped().Model(&model.FastgptApp{}).Where("id = ?", id).UpdateColumn("api_key", apiKey).Error
+}
+
+// CreateKeyAudit 记录 API Key 审计日志
+func (u *fastgpt) CreateKeyAudit(ctx context.Context, audit *model.AppKeyAudit) error {
+	return u.WithContext(ctx).Create(audit).Error
+}
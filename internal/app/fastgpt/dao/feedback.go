@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type feedback struct {
+	*gorm.DB
+}
+
+var Feedback = &feedback{}
+
+func (u *feedback) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.ChatFeedback{})
+}
+
+// FeedbackFilter 反馈列表筛选条件，空值表示不筛选
+type FeedbackFilter struct {
+	AppId     string
+	Rating    string
+	StaffId   string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// SaveFeedback 创建或覆盖用户对某条回答的评价
+func (u *feedback) SaveFeedback(ctx context.Context, f *model.ChatFeedback) error {
+	return u.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "staff_id"}, {Name: "app_id"}, {Name: "chat_id"}, {Name: "data_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rating", "reason", "forwarded", "updated_at"}),
+	}).Create(f).Error
+}
+
+// MarkForwarded 标记反馈已同步到 FastGPT
+func (u *feedback) MarkForwarded(ctx context.Context, staffId, appId, chatId, dataId string) error {
+	return u.WithContext(ctx).Model(&model.ChatFeedback{}).
+		Where("staff_id = ? AND app_id = ? AND chat_id = ? AND data_id = ?", staffId, appId, chatId, dataId).
+		UpdateColumn("forwarded", true).Error
+}
+
+// ListFeedback 分页获取反馈，limit 小于 0 时返回全部
+func (u *feedback) ListFeedback(ctx context.Context, filter FeedbackFilter, offset, limit int) ([]model.ChatFeedback, int64, error) {
+	var list []model.ChatFeedback
+	var total int64
+
+	query := u.WithContext(ctx).Model(&model.ChatFeedback{})
+	if filter.AppId != "" {
+		query = query.Where("app_id = ?", filter.AppId)
+	}
+	if filter.Rating != "" {
+		query = query.Where("rating = ?", filter.Rating)
+	}
+	if filter.StaffId != "" {
+		query = query.Where("staff_id = ?", filter.StaffId)
+	}
+	if filter.StartTime != nil {
+		query = query.Where("updated_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		query = query.Where("updated_at < ?", *filter.EndTime)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("updated_at DESC").Offset(offset).Limit(limit).Find(&list).Error
+	return list, total, err
+}
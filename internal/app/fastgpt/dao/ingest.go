@@ -0,0 +1,54 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type ingest struct {
+	*gorm.DB
+}
+
+var Ingest = &ingest{}
+
+func (u *ingest) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.IngestJob{})
+}
+
+// CreateJob 创建导入任务
+func (u *ingest) CreateJob(ctx context.Context, job *model.IngestJob) error {
+	return u.WithContext(ctx).Create(job).Error
+}
+
+// UpdateJob 更新导入任务的状态或进度
+func (u *ingest) UpdateJob(ctx context.Context, id string, updates map[string]interface{}) error {
+	return u.WithContext(ctx).Model(&model.IngestJob{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetJob 获取导入任务
+func (u *ingest) GetJob(ctx context.Context, id string) (*model.IngestJob, error) {
+	var job model.IngestJob
+	err := u.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	return &job, err
+}
+
+// ListJobs 分页获取导入任务，appId 为空时返回全部
+func (u *ingest) ListJobs(ctx context.Context, appId string, offset, limit int) ([]model.IngestJob, int64, error) {
+	var jobs []model.IngestJob
+	var total int64
+
+	query := u.WithContext(ctx).Model(&model.IngestJob{})
+	if appId != "" {
+		query = query.Where("app_id = ?", appId)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 列表不需要提取出的全文
+	err := query.Omit("content").Order("created_at DESC").Offset(offset).Limit(limit).Find(&jobs).Error
+	return jobs, total, err
+}
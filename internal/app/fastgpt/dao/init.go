@@ -14,5 +14,50 @@ func InitPG(db *gorm.DB) error {
 		return err
 	}
 
+	err = Chat.Init(db)
+	if err != nil {
+		return err
+	}
+
+	err = Quota.Init(db)
+	if err != nil {
+		return err
+	}
+
+	err = APIKey.Init(db)
+	if err != nil {
+		return err
+	}
+
+	err = Feedback.Init(db)
+	if err != nil {
+		return err
+	}
+
+	err = Moderation.Init(db)
+	if err != nil {
+		return err
+	}
+
+	err = Ingest.Init(db)
+	if err != nil {
+		return err
+	}
+
+	err = Eval.Init(db)
+	if err != nil {
+		return err
+	}
+
+	err = Prompt.Init(db)
+	if err != nil {
+		return err
+	}
+
+	err = Upload.Init(db)
+	if err != nil {
+		return err
+	}
+
 	return err
 }
@@ -0,0 +1,138 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type moderation struct {
+	*gorm.DB
+}
+
+var Moderation = &moderation{}
+
+func (u *moderation) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.ModerationList{}, &model.ModerationWord{}, &model.ModerationEvent{})
+}
+
+// CreateList 创建词表
+func (u *moderation) CreateList(ctx context.Context, list *model.ModerationList) error {
+	return u.WithContext(ctx).Create(list).Error
+}
+
+// UpdateList 更新词表
+func (u *moderation) UpdateList(ctx context.Context, id string, updates map[string]interface{}) (int64, error) {
+	result := u.WithContext(ctx).Model(&model.ModerationList{}).Where("id = ?", id).Updates(updates)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteList 删除词表及其敏感词
+func (u *moderation) DeleteList(ctx context.Context, id string) (int64, error) {
+	var affected int64
+	err := u.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&model.ModerationList{})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		return tx.Where("list_id = ?", id).Delete(&model.ModerationWord{}).Error
+	})
+	return affected, err
+}
+
+// GetList 获取词表
+func (u *moderation) GetList(ctx context.Context, id string) (*model.ModerationList, error) {
+	var list model.ModerationList
+	err := u.WithContext(ctx).Where("id = ?", id).First(&list).Error
+	return &list, err
+}
+
+// ListLists 获取全部词表
+func (u *moderation) ListLists(ctx context.Context) ([]model.ModerationList, error) {
+	var lists []model.ModerationList
+	err := u.WithContext(ctx).Order("created_at ASC").Find(&lists).Error
+	return lists, err
+}
+
+// AddWords 向词表添加敏感词，已存在的忽略
+func (u *moderation) AddWords(ctx context.Context, listId string, words []string) error {
+	if len(words) == 0 {
+		return nil
+	}
+	rows := make([]model.ModerationWord, 0, len(words))
+	for _, w := range words {
+		rows = append(rows, model.ModerationWord{ListId: listId, Word: w})
+	}
+	return u.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// DeleteWords 从词表删除敏感词
+func (u *moderation) DeleteWords(ctx context.Context, listId string, words []string) error {
+	return u.WithContext(ctx).Where("list_id = ? AND word IN ?", listId, words).Delete(&model.ModerationWord{}).Error
+}
+
+// GetWords 获取词表中的全部敏感词
+func (u *moderation) GetWords(ctx context.Context, listId string) ([]string, error) {
+	var words []string
+	err := u.WithContext(ctx).Model(&model.ModerationWord{}).Where("list_id = ?", listId).Order("word ASC").Pluck("word", &words).Error
+	return words, err
+}
+
+// CreateEvent 记录命中事件
+func (u *moderation) CreateEvent(ctx context.Context, event *model.ModerationEvent) error {
+	return u.WithContext(ctx).Create(event).Error
+}
+
+// ModerationEventFilter 命中记录筛选条件，空值表示不筛选
+type ModerationEventFilter struct {
+	AppId     string
+	StaffId   string
+	Action    string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// ListEvents 分页获取命中记录
+func (u *moderation) ListEvents(ctx context.Context, filter ModerationEventFilter, offset, limit int) ([]model.ModerationEvent, int64, error) {
+	var events []model.ModerationEvent
+	var total int64
+
+	query := u.WithContext(ctx).Model(&model.ModerationEvent{})
+	if filter.AppId != "" {
+		query = query.Where("app_id = ?", filter.AppId)
+	}
+	if filter.StaffId != "" {
+		query = query.Where("staff_id = ?", filter.StaffId)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.StartTime != nil {
+		query = query.Where("created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		query = query.Where("created_at < ?", *filter.EndTime)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&events).Error
+	return events, total, err
+}
+
+// CheckListNameExists 检查词表名称是否已存在，excludeId 用于更新时排除自身
+func (u *moderation) CheckListNameExists(ctx context.Context, name, excludeId string) (bool, error) {
+	var count int64
+	query := u.WithContext(ctx).Model(&model.ModerationList{}).Where("name = ?", name)
+	if excludeId != "" {
+		query = query.Where("id <> ?", excludeId)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
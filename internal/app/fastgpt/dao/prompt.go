@@ -0,0 +1,58 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type prompt struct {
+	*gorm.DB
+}
+
+var Prompt = &prompt{}
+
+func (u *prompt) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.PromptTemplate{})
+}
+
+// CreateVersion 以当前最大版本号加一保存模板
+func (u *prompt) CreateVersion(ctx context.Context, tpl *model.PromptTemplate) error {
+	return u.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest model.PromptTemplate
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("app_id = ?", tpl.AppId).
+			Order("version DESC").
+			First(&latest).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		tpl.Version = latest.Version + 1
+		return tx.Create(tpl).Error
+	})
+}
+
+// GetActive 获取应用当前生效的模板
+func (u *prompt) GetActive(ctx context.Context, appId string) (*model.PromptTemplate, error) {
+	var tpl model.PromptTemplate
+	err := u.WithContext(ctx).Where("app_id = ?", appId).Order("version DESC").First(&tpl).Error
+	return &tpl, err
+}
+
+// GetVersion 获取指定版本
+func (u *prompt) GetVersion(ctx context.Context, appId string, version int) (*model.PromptTemplate, error) {
+	var tpl model.PromptTemplate
+	err := u.WithContext(ctx).Where("app_id = ? AND version = ?", appId, version).First(&tpl).Error
+	return &tpl, err
+}
+
+// ListVersions 获取应用的全部版本，新版本在前
+func (u *prompt) ListVersions(ctx context.Context, appId string) ([]model.PromptTemplate, error) {
+	var tpls []model.PromptTemplate
+	err := u.WithContext(ctx).Where("app_id = ?", appId).Order("version DESC").Find(&tpls).Error
+	return tpls, err
+}
@@ -0,0 +1,129 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type quota struct {
+	*gorm.DB
+}
+
+var Quota = &quota{}
+
+func (u *quota) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.ChatQuota{}, &model.ChatUsage{}, &model.ChatDailyCounter{})
+}
+
+// GetQuota 获取配额配置，不存在时返回 nil
+func (u *quota) GetQuota(ctx context.Context, scope, targetId string) (*model.ChatQuota, error) {
+	var q model.ChatQuota
+	err := u.WithContext(ctx).Where("scope = ? AND target_id = ?", scope, targetId).First(&q).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// SaveQuota 创建或覆盖配额配置
+func (u *quota) SaveQuota(ctx context.Context, q *model.ChatQuota) error {
+	return u.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}, {Name: "target_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"requests_per_minute", "messages_per_day", "tokens_per_month", "created_by", "updated_at"}),
+	}).Create(q).Error
+}
+
+// DeleteQuota 删除配额配置
+func (u *quota) DeleteQuota(ctx context.Context, scope, targetId string) (int64, error) {
+	result := u.WithContext(ctx).Where("scope = ? AND target_id = ?", scope, targetId).Delete(&model.ChatQuota{})
+	return result.RowsAffected, result.Error
+}
+
+// ListQuotas 分页获取配额配置
+func (u *quota) ListQuotas(ctx context.Context, scope string, offset, limit int) ([]model.ChatQuota, int64, error) {
+	var quotas []model.ChatQuota
+	var total int64
+
+	query := u.WithContext(ctx).Model(&model.ChatQuota{})
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(offset).Limit(limit).Order("created_at ASC").Find(&quotas).Error
+	return quotas, total, err
+}
+
+// AddUsage 累加用户在应用下的月度用量
+func (u *quota) AddUsage(ctx context.Context, userId, appId, period string, messages, tokens int64) error {
+	usage := &model.ChatUsage{
+		UserId:   userId,
+		AppId:    appId,
+		Period:   period,
+		Messages: messages,
+		Tokens:   tokens,
+	}
+	return u.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "app_id"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"messages":   gorm.Expr("chat_usages.messages + ?", messages),
+			"tokens":     gorm.Expr("chat_usages.tokens + ?", tokens),
+			"updated_at": time.Now(),
+		}),
+	}).Create(usage).Error
+}
+
+// SumUserTokens 统计用户某月在所有应用下的 token 用量
+func (u *quota) SumUserTokens(ctx context.Context, userId, period string) (int64, error) {
+	var total int64
+	err := u.WithContext(ctx).Model(&model.ChatUsage{}).
+		Where("user_id = ? AND period = ?", userId, period).
+		Select("COALESCE(SUM(tokens), 0)").Scan(&total).Error
+	return total, err
+}
+
+// SumAppTokens 统计应用某月所有用户的 token 用量
+func (u *quota) SumAppTokens(ctx context.Context, appId, period string) (int64, error) {
+	var total int64
+	err := u.WithContext(ctx).Model(&model.ChatUsage{}).
+		Where("app_id = ? AND period = ?", appId, period).
+		Select("COALESCE(SUM(tokens), 0)").Scan(&total).Error
+	return total, err
+}
+
+// TakeDailyCounter 在未达到上限时将当日计数加一，返回是否占用成功
+// 插入和累加在同一条语句中完成，并发请求不会超出上限
+func (u *quota) TakeDailyCounter(ctx context.Context, scope, targetId, day string, limit int64) (bool, error) {
+	counter := &model.ChatDailyCounter{
+		Scope:    scope,
+		TargetId: targetId,
+		Day:      day,
+		Count:    1,
+	}
+	result := u.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope"}, {Name: "target_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("chat_daily_counters.count + 1"),
+			"updated_at": time.Now(),
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{gorm.Expr("chat_daily_counters.count < ?", limit)}},
+	}).Create(counter)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReleaseDailyCounter 归还一次当日计数
+func (u *quota) ReleaseDailyCounter(ctx context.Context, scope, targetId, day string) error {
+	return u.WithContext(ctx).Model(&model.ChatDailyCounter{}).
+		Where("scope = ? AND target_id = ? AND day = ? AND count > 0", scope, targetId, day).
+		Update("count", gorm.Expr("count - 1")).Error
+}
@@ -0,0 +1,31 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type upload struct {
+	*gorm.DB
+}
+
+var Upload = &upload{}
+
+func (u *upload) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.ChatUpload{})
+}
+
+// CreateUpload 记录上传的附件
+func (u *upload) CreateUpload(ctx context.Context, up *model.ChatUpload) error {
+	return u.WithContext(ctx).Create(up).Error
+}
+
+// GetUpload 根据主键获取附件
+func (u *upload) GetUpload(ctx context.Context, id string) (*model.ChatUpload, error) {
+	var up model.ChatUpload
+	err := u.WithContext(ctx).Where("id = ?", id).First(&up).Error
+	return &up, err
+}
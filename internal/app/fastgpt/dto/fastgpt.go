@@ -1,5 +1,7 @@
 package dto
 
+import "encoding/json"
+
 // ChatCompletionRequest Chat 请求
 type ChatCompletionRequest struct {
 	FastgptAppId string                 `json:"fastgptAppId" binding:"Required"` // 用于获取 API Key，不转发给 FastGPT
@@ -105,6 +107,60 @@ type DataItem struct {
 	Indexes []map[string]interface{} `json:"indexes"`
 }
 
+// CollectionListRequest 集合列表请求
+// FastGPT API 不需要 appId，使用 fastgptAppId 获取 API Key
+type CollectionListRequest struct {
+	FastgptAppId string  `json:"fastgptAppId" binding:"Required"`
+	DatasetId    string  `json:"datasetId" binding:"Required"`
+	ParentId     *string `json:"parentId"`
+	SearchText   string  `json:"searchText"`
+	Offset       int     `json:"offset"`
+	PageSize     int     `json:"pageSize"`
+}
+
+// UpdateCollectionRequest 更新集合请求，可重命名、移动或禁用集合
+// FastGPT API 不需要 appId，使用 fastgptAppId 获取 API Key
+type UpdateCollectionRequest struct {
+	FastgptAppId string   `json:"fastgptAppId" binding:"Required"`
+	ID           string   `json:"id" binding:"Required"`
+	ParentId     *string  `json:"parentId,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Forbid       *bool    `json:"forbid,omitempty"`
+}
+
+// RetrainCollectionRequest 按新的分块参数重新训练集合
+// FastGPT API 不需要 appId，使用 fastgptAppId 获取 API Key
+type RetrainCollectionRequest struct {
+	FastgptAppId     string `json:"fastgptAppId" binding:"Required"`
+	CollectionId     string `json:"collectionId" binding:"Required"`
+	TrainingType     string `json:"trainingType"`
+	ChunkSettingMode string `json:"chunkSettingMode,omitempty"`
+	ChunkSize        int    `json:"chunkSize,omitempty"`
+	ChunkSplitter    string `json:"chunkSplitter,omitempty"`
+	QaPrompt         string `json:"qaPrompt,omitempty"`
+}
+
+// DataListRequest 集合内数据列表请求
+// FastGPT API 不需要 appId，使用 fastgptAppId 获取 API Key
+type DataListRequest struct {
+	FastgptAppId string `json:"fastgptAppId" binding:"Required"`
+	CollectionId string `json:"collectionId" binding:"Required"`
+	SearchText   string `json:"searchText"`
+	Offset       int    `json:"offset"`
+	PageSize     int    `json:"pageSize"`
+}
+
+// UpdateDataRequest 更新单条数据请求
+// FastGPT API 不需要 appId，使用 fastgptAppId 获取 API Key
+type UpdateDataRequest struct {
+	FastgptAppId string                   `json:"fastgptAppId" binding:"Required"`
+	DataId       string                   `json:"dataId" binding:"Required"`
+	Q            string                   `json:"q,omitempty"`
+	A            string                   `json:"a,omitempty"`
+	Indexes      []map[string]interface{} `json:"indexes,omitempty"`
+}
+
 // SearchTestRequest 搜索测试请求
 // FastGPT API 不需要 appId，使用 fastgptAppId 获取 API Key
 type SearchTestRequest struct {
@@ -124,18 +180,28 @@ type CreateAppRequest struct {
 	AppId       string `json:"appId" binding:"Required"`
 	ShareId     string `json:"shareId"`
 	APIKey      string `json:"apiKey" binding:"Required"`
+	Backend     string `json:"backend"`  // FastGPT 后端名称，为空使用默认后端
+	CacheTTL    int    `json:"cacheTtl"` // 相同问题的回答缓存秒数，0 表示不缓存
 	Description string `json:"description"`
+
+	UploadMaxSize int      `json:"uploadMaxSize"` // 单个附件大小上限（MB），0 使用全局配置，-1 禁止上传
+	UploadTypes   []string `json:"uploadTypes"`   // 允许上传的附件类型，为空使用全局配置
 }
 
 // UpdateAppRequest 更新应用请求
 type UpdateAppRequest struct {
-	ID          string `json:"id" binding:"Required"`
-	AppName     string `json:"appName"`
-	AppId       string `json:"appId"`
-	ShareId     string `json:"shareId"`
-	APIKey      string `json:"apiKey"`
-	Description string `json:"description"`
-	Status      *int   `json:"status"`
+	ID          string  `json:"id" binding:"Required"`
+	AppName     string  `json:"appName"`
+	AppId       string  `json:"appId"`
+	ShareId     string  `json:"shareId"`
+	APIKey      string  `json:"apiKey"`
+	Backend     *string `json:"backend"`  // 传空字符串切回默认后端
+	CacheTTL    *int    `json:"cacheTtl"` // 传 0 关闭缓存
+	Description string  `json:"description"`
+	Status      *int    `json:"status"`
+
+	UploadMaxSize *int      `json:"uploadMaxSize"`
+	UploadTypes   *[]string `json:"uploadTypes"` // 传空数组使用全局配置
 }
 
 // DeleteAppRequest 删除应用请求
@@ -143,6 +209,11 @@ type DeleteAppRequest struct {
 	ID string `json:"id" binding:"Required"`
 }
 
+// ClearAppCacheRequest 清除应用回答缓存请求
+type ClearAppCacheRequest struct {
+	ID string `json:"id" binding:"Required"`
+}
+
 // GetAppListRequest 获取应用列表请求
 type GetAppListRequest struct {
 	Offset int `json:"offset"`
@@ -155,11 +226,26 @@ type AppItem struct {
 	AppName     string `json:"appName"`
 	AppId       string `json:"appId"`
 	ShareId     string `json:"shareId"`
-	APIKey      string `json:"apiKey"`
+	APIKey      string `json:"apiKey"` // 脱敏后的 API Key
+	Backend     string `json:"backend"`
+	CacheTTL    int    `json:"cacheTtl"`
 	Description string `json:"description"`
 	CreatedBy   string `json:"createdBy"`
 	CreatedAt   string `json:"createdAt"`
 	UpdatedAt   string `json:"updatedAt"`
+
+	UploadMaxSize int      `json:"uploadMaxSize"`
+	UploadTypes   []string `json:"uploadTypes"`
+}
+
+// RevealAppKeyRequest 查看应用 API Key 明文请求
+type RevealAppKeyRequest struct {
+	ID string `json:"id" binding:"Required"`
+}
+
+// RevealAppKeyResponse 查看应用 API Key 明文响应
+type RevealAppKeyResponse struct {
+	APIKey string `json:"apiKey"`
 }
 
 // AppListResponse 应用列表响应
@@ -199,3 +285,615 @@ type OutLinkInitRequest struct {
 	ShareId    string `form:"shareId" binding:"Required"`
 	OutLinkUid string `form:"outLinkUid"`
 }
+
+// === 聊天配额相关 DTO ===
+
+// SetQuotaRequest 设置配额请求，各项为 0 表示不限制
+type SetQuotaRequest struct {
+	Scope             string `json:"scope" binding:"Required"`    // user 或 app
+	TargetId          string `json:"targetId" binding:"Required"` // user 时为学号，app 时为应用ID
+	RequestsPerMinute int    `json:"requestsPerMinute"`
+	MessagesPerDay    int    `json:"messagesPerDay"`
+	TokensPerMonth    int64  `json:"tokensPerMonth"`
+}
+
+// DeleteQuotaRequest 删除配额请求
+type DeleteQuotaRequest struct {
+	Scope    string `json:"scope" binding:"Required"`
+	TargetId string `json:"targetId" binding:"Required"`
+}
+
+// GetQuotaListRequest 获取配额列表请求
+type GetQuotaListRequest struct {
+	Scope  string `json:"scope"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+// QuotaItem 配额列表项
+type QuotaItem struct {
+	Scope             string `json:"scope"`
+	TargetId          string `json:"targetId"`
+	RequestsPerMinute int    `json:"requestsPerMinute"`
+	MessagesPerDay    int    `json:"messagesPerDay"`
+	TokensPerMonth    int64  `json:"tokensPerMonth"`
+	CreatedBy         string `json:"createdBy"`
+	UpdatedAt         string `json:"updatedAt"`
+}
+
+// QuotaListResponse 配额列表响应
+type QuotaListResponse struct {
+	Quotas []QuotaItem `json:"quotas"`
+	Total  int64       `json:"total"`
+}
+
+// === OpenAI 兼容接口相关 DTO ===
+
+// OpenAIChatCompletionRequest OpenAI 兼容的对话请求，model 为 AppName 或别名
+// temperature 等 OpenAI 参数由 FastGPT 应用配置决定，这里忽略
+type OpenAIChatCompletionRequest struct {
+	Model     string                 `json:"model" binding:"Required"`
+	Messages  []Message              `json:"messages" binding:"Required"`
+	Stream    bool                   `json:"stream"`
+	ChatId    string                 `json:"chatId"`
+	Variables map[string]interface{} `json:"variables"`
+	User      string                 `json:"user"`
+}
+
+// OpenAIUsage OpenAI token 用量
+type OpenAIUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// OpenAIChoice OpenAI 非流式对话结果
+type OpenAIChoice struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// OpenAIChatCompletion OpenAI 非流式对话响应
+type OpenAIChatCompletion struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []OpenAIChoice `json:"choices"`
+	Usage   OpenAIUsage    `json:"usage"`
+}
+
+// OpenAIDelta OpenAI 流式增量
+type OpenAIDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+}
+
+// OpenAIChunkChoice OpenAI 流式对话结果
+type OpenAIChunkChoice struct {
+	Index        int         `json:"index"`
+	Delta        OpenAIDelta `json:"delta"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+// OpenAIChatCompletionChunk OpenAI 流式对话数据块
+type OpenAIChatCompletionChunk struct {
+	ID      string              `json:"id"`
+	Object  string              `json:"object"`
+	Created int64               `json:"created"`
+	Model   string              `json:"model"`
+	Choices []OpenAIChunkChoice `json:"choices"`
+}
+
+// OpenAIModel OpenAI 模型信息
+type OpenAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// OpenAIModelList OpenAI 模型列表
+type OpenAIModelList struct {
+	Object string        `json:"object"`
+	Data   []OpenAIModel `json:"data"`
+}
+
+// === 个人 API Key 相关 DTO ===
+
+// CreateAPIKeyRequest 创建个人 API Key 请求
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateAPIKeyResponse 创建个人 API Key 响应，Key 只返回这一次
+type CreateAPIKeyResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// DeleteAPIKeyRequest 删除个人 API Key 请求
+type DeleteAPIKeyRequest struct {
+	ID string `json:"id" binding:"Required"`
+}
+
+// APIKeyItem 个人 API Key 列表项
+type APIKeyItem struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	KeyPrefix  string `json:"keyPrefix"`
+	LastUsedAt string `json:"lastUsedAt"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+// === 回答评价相关 DTO ===
+
+// SubmitFeedbackRequest 提交回答评价请求
+type SubmitFeedbackRequest struct {
+	FastgptAppId string `json:"fastgptAppId" binding:"Required"`
+	ChatId       string `json:"chatId" binding:"Required"`
+	DataId       string `json:"dataId" binding:"Required"` // FastGPT 消息ID
+	Rating       string `json:"rating" binding:"Required"` // like 或 dislike
+	Reason       string `json:"reason"`
+	ShareId      string `json:"shareId"`
+	OutLinkUid   string `json:"outLinkUid"`
+}
+
+// GetFeedbackListRequest 获取评价列表请求，日期格式为 2006-01-02
+type GetFeedbackListRequest struct {
+	FastgptAppId string `json:"fastgptAppId"`
+	Rating       string `json:"rating"`
+	StaffId      string `json:"staffId"`
+	StartDate    string `json:"startDate"`
+	EndDate      string `json:"endDate"`
+	Offset       int    `json:"offset"`
+	Limit        int    `json:"limit"`
+}
+
+// FeedbackItem 评价列表项
+type FeedbackItem struct {
+	ID        string `json:"id"`
+	AppId     string `json:"appId"`
+	AppName   string `json:"appName"`
+	StaffId   string `json:"staffId"`
+	ChatId    string `json:"chatId"`
+	DataId    string `json:"dataId"`
+	Rating    string `json:"rating"`
+	Reason    string `json:"reason"`
+	Forwarded bool   `json:"forwarded"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// FeedbackListResponse 评价列表响应
+type FeedbackListResponse struct {
+	Feedbacks []FeedbackItem `json:"feedbacks"`
+	Total     int64          `json:"total"`
+}
+
+// CreateModerationListRequest 创建敏感词表请求
+type CreateModerationListRequest struct {
+	Name    string   `json:"name" binding:"Required"`
+	Action  string   `json:"action" binding:"Required"` // block、mask 或 log
+	Scope   string   `json:"scope"`                     // prompt、answer 或 both，默认 both
+	Enabled *bool    `json:"enabled"`                   // 默认启用
+	Words   []string `json:"words"`
+}
+
+// UpdateModerationListRequest 更新敏感词表请求，为 nil 的字段不修改
+type UpdateModerationListRequest struct {
+	ID      string  `json:"id" binding:"Required"`
+	Name    *string `json:"name"`
+	Action  *string `json:"action"`
+	Scope   *string `json:"scope"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// ModerationListIdRequest 按 ID 操作词表的请求
+type ModerationListIdRequest struct {
+	ID string `json:"id" binding:"Required"`
+}
+
+// ModerationWordsRequest 添加或删除敏感词请求
+type ModerationWordsRequest struct {
+	ListId string   `json:"listId" binding:"Required"`
+	Words  []string `json:"words" binding:"Required"`
+}
+
+// ModerationListItem 敏感词表列表项
+type ModerationListItem struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Action    string `json:"action"`
+	Scope     string `json:"scope"`
+	Enabled   bool   `json:"enabled"`
+	CreatedBy string `json:"createdBy"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// ModerationWordsResponse 词表中的敏感词
+type ModerationWordsResponse struct {
+	ListId string   `json:"listId"`
+	Words  []string `json:"words"`
+}
+
+// GetModerationEventsRequest 获取命中记录请求，日期格式为 2006-01-02
+type GetModerationEventsRequest struct {
+	FastgptAppId string `json:"fastgptAppId"`
+	StaffId      string `json:"staffId"`
+	Action       string `json:"action"`
+	StartDate    string `json:"startDate"`
+	EndDate      string `json:"endDate"`
+	Offset       int    `json:"offset"`
+	Limit        int    `json:"limit"`
+}
+
+// ModerationEventItem 命中记录列表项
+type ModerationEventItem struct {
+	ID        string `json:"id"`
+	StaffId   string `json:"staffId"`
+	AppId     string `json:"appId"`
+	ListName  string `json:"listName"`
+	Action    string `json:"action"`
+	Direction string `json:"direction"`
+	Terms     string `json:"terms"`
+	Excerpt   string `json:"excerpt"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// ModerationEventListResponse 命中记录列表响应
+type ModerationEventListResponse struct {
+	Events []ModerationEventItem `json:"events"`
+	Total  int64                 `json:"total"`
+}
+
+// GetIngestJobListRequest 获取文件导入任务列表请求
+type GetIngestJobListRequest struct {
+	FastgptAppId string `json:"fastgptAppId"`
+	Offset       int    `json:"offset"`
+	Limit        int    `json:"limit"`
+}
+
+// IngestJobItem 文件导入任务，Progress 为 0-100 的百分比
+type IngestJobItem struct {
+	ID           string  `json:"id"`
+	AppId        string  `json:"appId"`
+	DatasetId    string  `json:"datasetId"`
+	CollectionId string  `json:"collectionId"`
+	FileName     string  `json:"fileName"`
+	FileType     string  `json:"fileType"`
+	FileSize     int64   `json:"fileSize"`
+	ChunkSize    int     `json:"chunkSize"`
+	ChunkOverlap int     `json:"chunkOverlap"`
+	JobId        string  `json:"jobId"`
+	Status       string  `json:"status"`
+	TotalChunks  int     `json:"totalChunks"`
+	PushedChunks int     `json:"pushedChunks"`
+	Progress     float64 `json:"progress"`
+	Error        string  `json:"error"`
+	CreatedBy    string  `json:"createdBy"`
+	CreatedAt    string  `json:"createdAt"`
+	FinishedAt   string  `json:"finishedAt"`
+}
+
+// IngestJobListResponse 文件导入任务列表响应
+type IngestJobListResponse struct {
+	Jobs  []IngestJobItem `json:"jobs"`
+	Total int64           `json:"total"`
+}
+
+// QAPreviewItem 问答导入预览，Line 为表格中的行号
+type QAPreviewItem struct {
+	Line    int      `json:"line"`
+	Q       string   `json:"q"`
+	A       string   `json:"a"`
+	Indexes []string `json:"indexes,omitempty"`
+}
+
+// ImportQAResponse 问答导入响应，DryRun 时只校验和预览，不推送
+type ImportQAResponse struct {
+	Total        int             `json:"total"`        // 总记录数（不含空行）
+	SuccessCount int             `json:"successCount"` // 成功数
+	FailCount    int             `json:"failCount"`    // 失败数
+	Errors       []string        `json:"errors"`       // 错误详情
+	Preview      []QAPreviewItem `json:"preview"`      // 前 N 条有效数据
+	DryRun       bool            `json:"dryRun"`
+}
+
+// AsyncJobResponse 提交后台任务的响应，通过 /jobs/detail 查看进度
+type AsyncJobResponse struct {
+	JobId string `json:"jobId"`
+}
+
+// CreateEvalSetRequest 创建检索评测集请求
+type CreateEvalSetRequest struct {
+	FastgptAppId string `json:"fastgptAppId" binding:"Required"`
+	DatasetId    string `json:"datasetId" binding:"Required"`
+	Name         string `json:"name" binding:"Required"`
+	Description  string `json:"description"`
+}
+
+// UpdateEvalSetRequest 更新评测集请求，字段为空表示不修改
+type UpdateEvalSetRequest struct {
+	ID          string  `json:"id" binding:"Required"`
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// EvalSetIdRequest 通过ID操作评测集
+type EvalSetIdRequest struct {
+	ID string `json:"id" binding:"Required"`
+}
+
+// GetEvalSetsRequest 获取评测集列表请求，条件为空时不过滤
+type GetEvalSetsRequest struct {
+	FastgptAppId string `json:"fastgptAppId"`
+	DatasetId    string `json:"datasetId"`
+}
+
+// EvalSetItem 评测集
+type EvalSetItem struct {
+	ID          string `json:"id"`
+	AppId       string `json:"appId"`
+	DatasetId   string `json:"datasetId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Cases       int64  `json:"cases"`
+	CreatedBy   string `json:"createdBy"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// EvalCaseInput 评测问题，期望的集合ID和数据ID至少填写一项
+type EvalCaseInput struct {
+	Question              string   `json:"question" binding:"Required"`
+	ExpectedCollectionIds []string `json:"expectedCollectionIds"`
+	ExpectedDataIds       []string `json:"expectedDataIds"`
+}
+
+// AddEvalCasesRequest 批量添加评测问题请求
+type AddEvalCasesRequest struct {
+	SetId string          `json:"setId" binding:"Required"`
+	Cases []EvalCaseInput `json:"cases" binding:"Required"`
+}
+
+// UpdateEvalCaseRequest 更新评测问题请求，字段为空表示不修改
+type UpdateEvalCaseRequest struct {
+	SetId                 string    `json:"setId" binding:"Required"`
+	ID                    string    `json:"id" binding:"Required"`
+	Question              *string   `json:"question"`
+	ExpectedCollectionIds *[]string `json:"expectedCollectionIds"`
+	ExpectedDataIds       *[]string `json:"expectedDataIds"`
+}
+
+// DeleteEvalCasesRequest 删除评测问题请求
+type DeleteEvalCasesRequest struct {
+	SetId string   `json:"setId" binding:"Required"`
+	Ids   []string `json:"ids" binding:"Required"`
+}
+
+// EvalCaseItem 评测问题
+type EvalCaseItem struct {
+	ID                    string   `json:"id"`
+	Question              string   `json:"question"`
+	ExpectedCollectionIds []string `json:"expectedCollectionIds"`
+	ExpectedDataIds       []string `json:"expectedDataIds"`
+}
+
+// EvalCaseListResponse 评测问题列表响应
+type EvalCaseListResponse struct {
+	Cases []EvalCaseItem `json:"cases"`
+}
+
+// CreateEvalRunRequest 运行评测请求，Limit 为 FastGPT 的引用上限（token），K 为计算 recall@k 的条数
+type CreateEvalRunRequest struct {
+	SetId      string  `json:"setId" binding:"Required"`
+	SearchMode string  `json:"searchMode"`
+	Similarity float64 `json:"similarity"`
+	Limit      int     `json:"limit"`
+	K          int     `json:"k"`
+}
+
+// GetEvalRunsRequest 获取评测运行列表请求
+type GetEvalRunsRequest struct {
+	SetId  string `json:"setId" binding:"Required"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+// EvalRunItem 评测运行，Results 仅在详情中返回
+type EvalRunItem struct {
+	ID         string          `json:"id"`
+	SetId      string          `json:"setId"`
+	JobId      string          `json:"jobId"`
+	SearchMode string          `json:"searchMode"`
+	Similarity float64         `json:"similarity"`
+	Limit      int             `json:"limit"`
+	K          int             `json:"k"`
+	Status     string          `json:"status"`
+	Cases      int             `json:"cases"`
+	Recall     float64         `json:"recall"`
+	MRR        float64         `json:"mrr"`
+	Error      string          `json:"error"`
+	Results    json.RawMessage `json:"results,omitempty"`
+	CreatedBy  string          `json:"createdBy"`
+	CreatedAt  string          `json:"createdAt"`
+	FinishedAt string          `json:"finishedAt"`
+}
+
+// EvalRunListResponse 评测运行列表响应
+type EvalRunListResponse struct {
+	Runs  []EvalRunItem `json:"runs"`
+	Total int64         `json:"total"`
+}
+
+// CompareEvalRunsRequest 对比两次评测运行请求
+type CompareEvalRunsRequest struct {
+	BaseId   string `json:"baseId" binding:"Required"`
+	TargetId string `json:"targetId" binding:"Required"`
+}
+
+// EvalCaseDiffItem 问题在两次运行中的差异，Lost 为基准运行召回而目标运行未召回的ID
+type EvalCaseDiffItem struct {
+	CaseId       string   `json:"caseId"`
+	Question     string   `json:"question"`
+	BaseRecall   float64  `json:"baseRecall"`
+	TargetRecall float64  `json:"targetRecall"`
+	BaseRR       float64  `json:"baseReciprocalRank"`
+	TargetRR     float64  `json:"targetReciprocalRank"`
+	Lost         []string `json:"lost"`
+	Gained       []string `json:"gained"`
+}
+
+// CompareEvalRunsResponse 对比结果，Delta 为目标运行减去基准运行
+type CompareEvalRunsResponse struct {
+	Base        EvalRunItem        `json:"base"`
+	Target      EvalRunItem        `json:"target"`
+	RecallDelta float64            `json:"recallDelta"`
+	MRRDelta    float64            `json:"mrrDelta"`
+	Diffs       []EvalCaseDiffItem `json:"diffs"`
+}
+
+// PromptAppRequest 通过应用查询提示词模板
+type PromptAppRequest struct {
+	FastgptAppId string `json:"fastgptAppId" binding:"Required"`
+}
+
+// SavePromptTemplateRequest 保存提示词模板请求，每次保存生成新版本，Content 为空表示停用
+// Mode 为 system 时作为 system 消息放在对话最前面，为 variables 时写入 VariableKey 对应的对话变量
+type SavePromptTemplateRequest struct {
+	FastgptAppId string `json:"fastgptAppId" binding:"Required"`
+	Mode         string `json:"mode" binding:"Required"`
+	VariableKey  string `json:"variableKey"`
+	Content      string `json:"content"`
+	Note         string `json:"note"`
+}
+
+// RollbackPromptTemplateRequest 回滚到指定版本，会以该版本的内容生成新版本
+type RollbackPromptTemplateRequest struct {
+	FastgptAppId string `json:"fastgptAppId" binding:"Required"`
+	Version      int    `json:"version" binding:"Required"`
+}
+
+// PreviewPromptTemplateRequest 用当前用户的信息预览模板，Content 为空时预览生效的版本
+type PreviewPromptTemplateRequest struct {
+	FastgptAppId string `json:"fastgptAppId" binding:"Required"`
+	Content      string `json:"content"`
+}
+
+// PromptTemplateItem 提示词模板版本
+type PromptTemplateItem struct {
+	Version     int    `json:"version"`
+	Mode        string `json:"mode"`
+	VariableKey string `json:"variableKey"`
+	Content     string `json:"content"`
+	Note        string `json:"note"`
+	CreatedBy   string `json:"createdBy"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// PromptTemplateResponse 应用的提示词模板，Active 为 nil 表示没有生效的模板
+type PromptTemplateResponse struct {
+	Active       *PromptTemplateItem  `json:"active"`
+	Versions     []PromptTemplateItem `json:"versions"`
+	Placeholders []string             `json:"placeholders"`
+}
+
+// PreviewPromptTemplateResponse 模板预览结果
+type PreviewPromptTemplateResponse struct {
+	Text string `json:"text"`
+}
+
+// SignImagesRequest 为回答或引用中的图片生成签名链接，imageIds 为 /api/system/img/ 后的部分
+type SignImagesRequest struct {
+	FastgptAppId string   `json:"fastgptAppId" binding:"Required"`
+	ImageIds     []string `json:"imageIds" binding:"Required"`
+}
+
+// SignImagesResponse imageId -> 签名后的链接
+type SignImagesResponse struct {
+	URLs map[string]string `json:"urls"`
+}
+
+// UploadResponse 聊天附件上传结果，url 在有效期内可放入 image_url 或 file_url
+type UploadResponse struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"` // image 或 file
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+	ExpiresAt   string `json:"expiresAt"`
+}
+
+// GetSessionListRequest 获取我的会话列表请求，fastgptAppId 为空时返回全部科目
+type GetSessionListRequest struct {
+	FastgptAppId string `json:"fastgptAppId"`
+	Archived     bool   `json:"archived"` // true 只返回已归档的会话
+	Offset       int    `json:"offset"`
+	Limit        int    `json:"limit"`
+}
+
+// SessionIdRequest 按会话主键操作的请求
+type SessionIdRequest struct {
+	ID string `json:"id" binding:"Required"`
+}
+
+// RenameSessionRequest 重命名会话请求
+type RenameSessionRequest struct {
+	ID    string `json:"id" binding:"Required"`
+	Title string `json:"title" binding:"Required"`
+}
+
+// PinSessionRequest 置顶或取消置顶会话请求
+type PinSessionRequest struct {
+	ID     string `json:"id" binding:"Required"`
+	Pinned bool   `json:"pinned"`
+}
+
+// ArchiveSessionRequest 归档或取消归档会话请求
+type ArchiveSessionRequest struct {
+	ID       string `json:"id" binding:"Required"`
+	Archived bool   `json:"archived"`
+}
+
+// SearchSessionsRequest 搜索我的聊天记录请求
+type SearchSessionsRequest struct {
+	Keyword      string `json:"keyword" binding:"Required"`
+	FastgptAppId string `json:"fastgptAppId"`
+	Offset       int    `json:"offset"`
+	Limit        int    `json:"limit"`
+}
+
+// SessionItem 会话列表项，fastgptAppId 与 chatId 用于继续对话
+type SessionItem struct {
+	ID            string `json:"id"`
+	FastgptAppId  string `json:"fastgptAppId"`
+	AppName       string `json:"appName"`
+	ChatId        string `json:"chatId"`
+	Title         string `json:"title"`
+	Pinned        bool   `json:"pinned"`
+	Archived      bool   `json:"archived"`
+	LastMessageAt string `json:"lastMessageAt"`
+}
+
+// SessionListResponse 会话列表响应
+type SessionListResponse struct {
+	Sessions []SessionItem `json:"sessions"`
+	Total    int64         `json:"total"`
+}
+
+// SessionSearchHit 命中的消息
+type SessionSearchHit struct {
+	Session   SessionItem `json:"session"`
+	MessageId string      `json:"messageId"`
+	Role      string      `json:"role"`
+	Snippet   string      `json:"snippet"`
+	CreatedAt string      `json:"createdAt"`
+}
+
+// SessionSearchResponse 聊天记录搜索响应
+type SessionSearchResponse struct {
+	Hits  []SessionSearchHit `json:"hits"`
+	Total int64              `json:"total"`
+}
@@ -0,0 +1,144 @@
+package eval
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+)
+
+// Score 按前 k 条检索结果计算单个问题的 recall@k 和倒数排名
+// 期望的数据按数据ID匹配，期望的集合只要前 k 条中有该集合的任一数据即视为召回
+func Score(c model.EvalCase, list []sdk.SearchResultItem, k int) model.EvalCaseResult {
+	result := model.EvalCaseResult{
+		CaseId:                c.ID,
+		Question:              c.Question,
+		ExpectedCollectionIds: c.ExpectedCollectionIds,
+		ExpectedDataIds:       c.ExpectedDataIds,
+		Hits:                  make([]model.EvalHit, 0, k),
+	}
+	wantData := toSet(c.ExpectedDataIds)
+	wantCollection := toSet(c.ExpectedCollectionIds)
+	found := make(map[string]bool)
+
+	for i, item := range list {
+		if i >= k {
+			break
+		}
+		relevant := false
+		if wantData[item.ID] {
+			relevant = true
+			found[item.ID] = true
+		}
+		if wantCollection[item.CollectionId] {
+			relevant = true
+			found[item.CollectionId] = true
+		}
+		if relevant && result.ReciprocalRank == 0 {
+			result.ReciprocalRank = 1 / float64(i+1)
+		}
+		result.Hits = append(result.Hits, model.EvalHit{
+			Rank:         i + 1,
+			DataId:       item.ID,
+			CollectionId: item.CollectionId,
+			SourceName:   item.SourceName,
+			Relevant:     relevant,
+		})
+	}
+
+	targets := len(wantData) + len(wantCollection)
+	for _, id := range append(append([]string{}, c.ExpectedDataIds...), c.ExpectedCollectionIds...) {
+		if !found[id] {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+	if targets > 0 {
+		result.Recall = float64(len(found)) / float64(targets)
+	}
+	return result
+}
+
+// Summarize 计算平均 recall@k 和 MRR
+func Summarize(results []model.EvalCaseResult) (recall, mrr float64) {
+	if len(results) == 0 {
+		return 0, 0
+	}
+	for _, r := range results {
+		recall += r.Recall
+		mrr += r.ReciprocalRank
+	}
+	n := float64(len(results))
+	return recall / n, mrr / n
+}
+
+// CaseDiff 同一问题在两次运行中的差异，Lost 为基准运行命中而目标运行未命中的期望ID
+type CaseDiff struct {
+	CaseId       string
+	Question     string
+	BaseRecall   float64
+	TargetRecall float64
+	BaseRR       float64
+	TargetRR     float64
+	Lost         []string
+	Gained       []string
+}
+
+// Compare 对比两次运行中指标或命中情况发生变化的问题，只在一次运行中出现的问题也会列出
+func Compare(base, target []model.EvalCaseResult) []CaseDiff {
+	baseByCase := make(map[string]model.EvalCaseResult, len(base))
+	for _, r := range base {
+		baseByCase[r.CaseId] = r
+	}
+
+	var diffs []CaseDiff
+	seen := make(map[string]bool, len(target))
+	for _, t := range target {
+		seen[t.CaseId] = true
+		b, ok := baseByCase[t.CaseId]
+		if !ok {
+			diffs = append(diffs, CaseDiff{CaseId: t.CaseId, Question: t.Question, TargetRecall: t.Recall, TargetRR: t.ReciprocalRank})
+			continue
+		}
+		lost := subtract(t.Missing, b.Missing)
+		gained := subtract(b.Missing, t.Missing)
+		if b.Recall == t.Recall && b.ReciprocalRank == t.ReciprocalRank && len(lost) == 0 && len(gained) == 0 {
+			continue
+		}
+		diffs = append(diffs, CaseDiff{
+			CaseId:       t.CaseId,
+			Question:     t.Question,
+			BaseRecall:   b.Recall,
+			TargetRecall: t.Recall,
+			BaseRR:       b.ReciprocalRank,
+			TargetRR:     t.ReciprocalRank,
+			Lost:         lost,
+			Gained:       gained,
+		})
+	}
+	for _, b := range base {
+		if !seen[b.CaseId] {
+			diffs = append(diffs, CaseDiff{CaseId: b.CaseId, Question: b.Question, BaseRecall: b.Recall, BaseRR: b.ReciprocalRank})
+		}
+	}
+	return diffs
+}
+
+func toSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			set[id] = true
+		}
+	}
+	return set
+}
+
+// subtract 返回 a 中不在 b 中的元素
+func subtract(a, b []string) []string {
+	exclude := toSet(b)
+	var out []string
+	for _, id := range a {
+		if !exclude[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
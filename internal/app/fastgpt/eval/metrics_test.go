@@ -0,0 +1,185 @@
+package eval
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"math"
+	"strings"
+	"testing"
+)
+
+func evalCase(id string, collections, data []string) model.EvalCase {
+	c := model.EvalCase{Question: "q-" + id, ExpectedCollectionIds: collections, ExpectedDataIds: data}
+	c.ID = id
+	return c
+}
+
+func items(pairs ...string) []sdk.SearchResultItem {
+	// 参数依次为 数据ID、集合ID
+	list := make([]sdk.SearchResultItem, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		list = append(list, sdk.SearchResultItem{ID: pairs[i], CollectionId: pairs[i+1]})
+	}
+	return list
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		c           model.EvalCase
+		list        []sdk.SearchResultItem
+		k           int
+		wantRecall  float64
+		wantRR      float64
+		wantMissing []string
+		wantHits    int
+		relevant    []bool
+	}{
+		{
+			name: "data hit at first rank",
+			c:    evalCase("1", nil, []string{"d1"}),
+			list: items("d1", "c1", "d2", "c1"), k: 5,
+			wantRecall: 1, wantRR: 1, wantHits: 2, relevant: []bool{true, false},
+		},
+		{
+			name: "collection hit at third rank",
+			c:    evalCase("2", []string{"c9"}, nil),
+			list: items("d1", "c1", "d2", "c2", "d3", "c9"), k: 5,
+			wantRecall: 1, wantRR: 1.0 / 3, wantHits: 3, relevant: []bool{false, false, true},
+		},
+		{
+			name: "hit beyond k ignored",
+			c:    evalCase("3", nil, []string{"d3"}),
+			list: items("d1", "c1", "d2", "c1", "d3", "c1"), k: 2,
+			wantRecall: 0, wantRR: 0, wantMissing: []string{"d3"}, wantHits: 2, relevant: []bool{false, false},
+		},
+		{
+			name: "partial recall",
+			c:    evalCase("4", []string{"c2"}, []string{"d1", "d5"}),
+			list: items("d9", "c9", "d1", "c1", "d3", "c2"), k: 3,
+			wantRecall: 2.0 / 3, wantRR: 0.5, wantMissing: []string{"d5"}, wantHits: 3, relevant: []bool{false, true, true},
+		},
+		{
+			name: "collection matched by several items counted once",
+			c:    evalCase("5", []string{"c1"}, nil),
+			list: items("d1", "c1", "d2", "c1"), k: 5,
+			wantRecall: 1, wantRR: 1, wantHits: 2, relevant: []bool{true, true},
+		},
+		{
+			name: "no expectation",
+			c:    evalCase("6", nil, nil),
+			list: items("d1", "c1"), k: 5,
+			wantRecall: 0, wantRR: 0, wantHits: 1, relevant: []bool{false},
+		},
+		{
+			name: "empty result",
+			c:    evalCase("7", []string{"c1"}, []string{"d1"}),
+			list: nil, k: 5,
+			wantRecall: 0, wantRR: 0, wantMissing: []string{"d1", "c1"}, wantHits: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Score(tt.c, tt.list, tt.k)
+			if got.CaseId != tt.c.ID || got.Question != tt.c.Question {
+				t.Errorf("case: got %s %q", got.CaseId, got.Question)
+			}
+			if !near(got.Recall, tt.wantRecall) {
+				t.Errorf("recall: got %v, want %v", got.Recall, tt.wantRecall)
+			}
+			if !near(got.ReciprocalRank, tt.wantRR) {
+				t.Errorf("reciprocal rank: got %v, want %v", got.ReciprocalRank, tt.wantRR)
+			}
+			if strings.Join(got.Missing, ",") != strings.Join(tt.wantMissing, ",") {
+				t.Errorf("missing: got %v, want %v", got.Missing, tt.wantMissing)
+			}
+			if len(got.Hits) != tt.wantHits {
+				t.Fatalf("hits: got %d, want %d", len(got.Hits), tt.wantHits)
+			}
+			for i, h := range got.Hits {
+				if h.Rank != i+1 {
+					t.Errorf("hit %d rank: got %d", i, h.Rank)
+				}
+				if h.Relevant != tt.relevant[i] {
+					t.Errorf("hit %d relevant: got %v, want %v", i, h.Relevant, tt.relevant[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name       string
+		results    []model.EvalCaseResult
+		wantRecall float64
+		wantMRR    float64
+	}{
+		{name: "empty", results: nil},
+		{name: "single", results: []model.EvalCaseResult{{Recall: 0.5, ReciprocalRank: 1}}, wantRecall: 0.5, wantMRR: 1},
+		{
+			name:       "average",
+			results:    []model.EvalCaseResult{{Recall: 1, ReciprocalRank: 1}, {Recall: 0, ReciprocalRank: 0}, {Recall: 0.5, ReciprocalRank: 0.5}},
+			wantRecall: 0.5, wantMRR: 0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recall, mrr := Summarize(tt.results)
+			if !near(recall, tt.wantRecall) || !near(mrr, tt.wantMRR) {
+				t.Errorf("got recall=%v mrr=%v, want recall=%v mrr=%v", recall, mrr, tt.wantRecall, tt.wantMRR)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := []model.EvalCaseResult{
+		{CaseId: "same", Recall: 1, ReciprocalRank: 1},
+		{CaseId: "worse", Recall: 1, ReciprocalRank: 1, Missing: nil},
+		{CaseId: "better", Recall: 0.5, ReciprocalRank: 0.5, Missing: []string{"d2"}},
+		{CaseId: "rank only", Recall: 1, ReciprocalRank: 1},
+		{CaseId: "removed", Recall: 0.5, ReciprocalRank: 0.25},
+	}
+	target := []model.EvalCaseResult{
+		{CaseId: "same", Recall: 1, ReciprocalRank: 1},
+		{CaseId: "worse", Recall: 0.5, ReciprocalRank: 1, Missing: []string{"c1"}},
+		{CaseId: "better", Recall: 1, ReciprocalRank: 1},
+		{CaseId: "rank only", Recall: 1, ReciprocalRank: 0.5},
+		{CaseId: "added", Recall: 1, ReciprocalRank: 1},
+	}
+
+	diffs := Compare(base, target)
+	byCase := make(map[string]CaseDiff, len(diffs))
+	order := make([]string, 0, len(diffs))
+	for _, d := range diffs {
+		byCase[d.CaseId] = d
+		order = append(order, d.CaseId)
+	}
+	want := []string{"worse", "better", "rank only", "added", "removed"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Fatalf("diffs: got %v, want %v", order, want)
+	}
+
+	if d := byCase["worse"]; strings.Join(d.Lost, ",") != "c1" || len(d.Gained) != 0 || d.BaseRecall != 1 || d.TargetRecall != 0.5 {
+		t.Errorf("worse: got %+v", d)
+	}
+	if d := byCase["better"]; strings.Join(d.Gained, ",") != "d2" || len(d.Lost) != 0 {
+		t.Errorf("better: got %+v", d)
+	}
+	if d := byCase["rank only"]; d.BaseRR != 1 || d.TargetRR != 0.5 || len(d.Lost)+len(d.Gained) != 0 {
+		t.Errorf("rank only: got %+v", d)
+	}
+	if d := byCase["added"]; d.BaseRecall != 0 || d.TargetRecall != 1 {
+		t.Errorf("added: got %+v", d)
+	}
+	if d := byCase["removed"]; d.BaseRecall != 0.5 || d.BaseRR != 0.25 || d.TargetRecall != 0 {
+		t.Errorf("removed: got %+v", d)
+	}
+}
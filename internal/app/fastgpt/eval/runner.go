@@ -0,0 +1,151 @@
+package eval
+
+import (
+	"HelpStudent/core/logx"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	jobs "HelpStudent/internal/app/jobs/service"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// JobType 检索评测的后台任务类型
+const JobType = "fastgpt.eval"
+
+type jobPayload struct {
+	RunId string `json:"runId"`
+}
+
+// Enqueue 将评测运行放入后台任务队列
+func Enqueue(ctx context.Context, run *model.EvalRun) error {
+	j, err := jobs.Enqueue(ctx, JobType, jobPayload{RunId: run.ID}, jobs.WithCreatedBy(run.CreatedBy))
+	if err != nil {
+		return err
+	}
+	run.JobId = j.ID
+	return dao.Eval.UpdateRun(ctx, run.ID, map[string]interface{}{"job_id": j.ID})
+}
+
+// Handle 逐个问题调用 searchTest 并计算指标，每个问题完成后保存结果，重试时从已完成的问题继续
+func Handle(ctx context.Context, task *jobs.Task) error {
+	var payload jobPayload
+	if err := task.Decode(&payload); err != nil {
+		return err
+	}
+	run, err := dao.Eval.GetRun(ctx, payload.RunId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	if run.Status == model.EvalSucceeded {
+		return nil
+	}
+
+	err = execute(ctx, task, run)
+	if err == nil {
+		task.SetResult(map[string]interface{}{"recall": run.Recall, "mrr": run.MRR})
+		return nil
+	}
+
+	// 状态更新不受 ctx 取消影响
+	store := context.WithoutCancel(ctx)
+	switch {
+	case errors.Is(err, jobs.ErrCanceled) || ctx.Err() != nil && task.CancelRequested(store):
+		finish(store, run, model.EvalCanceled, "")
+	case ctx.Err() != nil:
+		// 进程退出，重启后继续
+	case jobs.IsPermanent(err) || task.Attempts >= task.MaxAttempts:
+		finish(store, run, model.EvalFailed, err.Error())
+	default:
+		_ = dao.Eval.UpdateRun(store, run.ID, map[string]interface{}{"error": err.Error()})
+	}
+	return err
+}
+
+func execute(ctx context.Context, task *jobs.Task, run *model.EvalRun) error {
+	cases, err := dao.Eval.GetCases(ctx, run.SetId)
+	if err != nil {
+		return err
+	}
+	if len(cases) == 0 {
+		return jobs.Permanent(errors.New("评测集没有问题"))
+	}
+	run.Cases = len(cases)
+	if err := dao.Eval.UpdateRun(ctx, run.ID, map[string]interface{}{
+		"status": model.EvalRunning,
+		"cases":  run.Cases,
+	}); err != nil {
+		return err
+	}
+
+	client, err := sdk.NewAppClient(ctx, run.AppId)
+	if err != nil {
+		return err
+	}
+
+	// 已保存的结果说明此前执行到一半被中断
+	results := []model.EvalCaseResult(run.Results)
+	done := make(map[string]bool, len(results))
+	for _, r := range results {
+		done[r.CaseId] = true
+	}
+	for _, c := range cases {
+		if done[c.ID] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		list, err := client.SearchTest(sdk.SearchTestRequest{
+			DatasetId:  run.DatasetId,
+			Text:       c.Question,
+			Limit:      run.Limit,
+			Similarity: run.Similarity,
+			SearchMode: run.SearchMode,
+		})
+		if err != nil {
+			return fmt.Errorf("检索问题“%s”失败: %w", c.Question, err)
+		}
+		results = append(results, Score(c, list.List, run.K))
+		if err := dao.Eval.UpdateRun(ctx, run.ID, map[string]interface{}{
+			"results": datatypes.NewJSONSlice(results),
+		}); err != nil {
+			return err
+		}
+		if err := task.Progress(ctx, len(results), len(cases), fmt.Sprintf("已评测 %d/%d 个问题", len(results), len(cases))); err != nil {
+			return err
+		}
+	}
+
+	run.Recall, run.MRR = Summarize(results)
+	now := time.Now()
+	if err := dao.Eval.UpdateRun(ctx, run.ID, map[string]interface{}{
+		"status":      model.EvalSucceeded,
+		"recall":      run.Recall,
+		"mrr":         run.MRR,
+		"error":       "",
+		"finished_at": &now,
+	}); err != nil {
+		return err
+	}
+	logx.SystemLogger.Infof("eval run %s finished: recall@%d=%.3f, mrr=%.3f", run.ID, run.K, run.Recall, run.MRR)
+	return nil
+}
+
+// finish 记录评测运行的最终状态
+func finish(ctx context.Context, run *model.EvalRun, status, reason string) {
+	now := time.Now()
+	_ = dao.Eval.UpdateRun(ctx, run.ID, map[string]interface{}{
+		"status":      status,
+		"error":       reason,
+		"finished_at": &now,
+	})
+}
@@ -0,0 +1,238 @@
+package export
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+const (
+	// pageSize 每次 getPaginationRecords 获取的记录数
+	pageSize = 50
+	// MaxRecords 单个会话最多导出的记录数
+	MaxRecords = 5000
+)
+
+var ErrUnknownFormat = errors.New("导出格式只能是 md、html 或 json")
+
+// Conversation 导出的会话
+type Conversation struct {
+	AppName    string   `json:"appName"`
+	ChatId     string   `json:"chatId"`
+	Title      string   `json:"title"`
+	StaffId    string   `json:"staffId,omitempty"`
+	ExportedAt string   `json:"exportedAt"`
+	Truncated  bool     `json:"truncated,omitempty"` // 记录数超过 MaxRecords，只导出了前面的部分
+	Records    []Record `json:"records"`
+}
+
+// Record 一条消息，Role 为 user、assistant 或 system
+type Record struct {
+	DataId    string   `json:"dataId"`
+	Role      string   `json:"role"`
+	Time      string   `json:"time,omitempty"`
+	Text      string   `json:"text"`
+	Reasoning string   `json:"reasoning,omitempty"`
+	Images    []string `json:"images,omitempty"`
+	Quotes    []Quote  `json:"quotes,omitempty"`
+	Feedback  string   `json:"feedback,omitempty"` // good 或 bad
+}
+
+// Quote 回答引用的知识库片段
+type Quote struct {
+	Index      int    `json:"index"`
+	SourceName string `json:"sourceName"`
+	Q          string `json:"q"`
+	A          string `json:"a,omitempty"`
+}
+
+// ImageResolver 将 FastGPT 图片 ID 转换为导出文件中使用的链接
+type ImageResolver func(imageId string) string
+
+// ValidFormat 检查导出格式
+func ValidFormat(format string) bool {
+	return format == FormatMarkdown || format == FormatHTML || format == FormatJSON
+}
+
+// Fetch 分页获取会话的全部记录
+func Fetch(client *sdk.Client, app *model.FastgptApp, chatId string) (*Conversation, error) {
+	conv := &Conversation{
+		AppName:    app.AppName,
+		ChatId:     chatId,
+		ExportedAt: time.Now().Format("2006-01-02 15:04:05"),
+		Records:    []Record{},
+	}
+	for offset := 0; ; offset += pageSize {
+		page, err := client.GetPaginationRecords(sdk.GetPaginationRecordsRequest{
+			AppId:    app.AppId,
+			ChatId:   chatId,
+			Offset:   offset,
+			PageSize: pageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page.List {
+			if len(conv.Records) >= MaxRecords {
+				conv.Truncated = true
+				return conv, nil
+			}
+			conv.Records = append(conv.Records, parseRecord(r))
+		}
+		if len(page.List) < pageSize || (page.Total > 0 && offset+len(page.List) >= page.Total) {
+			break
+		}
+	}
+	if conv.Title == "" {
+		for _, r := range conv.Records {
+			if r.Role == model.ChatRoleUser && r.Text != "" {
+				conv.Title = firstLine(r.Text, 50)
+				break
+			}
+		}
+	}
+	return conv, nil
+}
+
+// valueItem FastGPT 消息内容数组中的一项
+type valueItem struct {
+	Type string `json:"type"`
+	Text struct {
+		Content string `json:"content"`
+	} `json:"text"`
+	Reasoning struct {
+		Content string `json:"content"`
+	} `json:"reasoning"`
+	File struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"file"`
+}
+
+type quoteItem struct {
+	SourceName string `json:"sourceName"`
+	Q          string `json:"q"`
+	A          string `json:"a"`
+}
+
+func parseRecord(r sdk.ChatRecord) Record {
+	rec := Record{DataId: r.DataId, Time: r.Time}
+	switch r.Obj {
+	case "Human":
+		rec.Role = model.ChatRoleUser
+	case "System":
+		rec.Role = model.ChatRoleSystem
+	default:
+		rec.Role = model.ChatRoleAssistant
+	}
+	if rec.DataId == "" {
+		rec.DataId = r.ID
+	}
+	switch {
+	case r.UserGoodFeedback != "":
+		rec.Feedback = "good"
+	case r.UserBadFeedback != "":
+		rec.Feedback = "bad"
+	}
+
+	var items []valueItem
+	if json.Unmarshal(r.Value, &items) != nil {
+		// 旧版本 FastGPT 的 value 为字符串
+		var s string
+		if json.Unmarshal(r.Value, &s) == nil {
+			rec.Text = s
+		}
+	}
+	var texts, reasoning []string
+	for _, item := range items {
+		switch item.Type {
+		case "text":
+			if item.Text.Content != "" {
+				texts = append(texts, item.Text.Content)
+			}
+		case "reasoning":
+			if item.Reasoning.Content != "" {
+				reasoning = append(reasoning, item.Reasoning.Content)
+			}
+		case "file":
+			if item.File.Type == "image" && item.File.URL != "" {
+				rec.Images = append(rec.Images, item.File.URL)
+			}
+		}
+	}
+	if len(texts) > 0 {
+		rec.Text = strings.Join(texts, "\n\n")
+	}
+	rec.Reasoning = strings.Join(reasoning, "\n\n")
+	rec.Quotes = parseQuotes(r)
+	return rec
+}
+
+// parseQuotes 优先使用 totalQuoteList，没有时从各节点的 quoteList 中收集
+func parseQuotes(r sdk.ChatRecord) []Quote {
+	var list []quoteItem
+	if len(r.TotalQuoteList) > 0 {
+		_ = json.Unmarshal(r.TotalQuoteList, &list)
+	}
+	if len(list) == 0 && len(r.ResponseData) > 0 {
+		var nodes []struct {
+			QuoteList []quoteItem `json:"quoteList"`
+		}
+		if json.Unmarshal(r.ResponseData, &nodes) == nil {
+			for _, n := range nodes {
+				list = append(list, n.QuoteList...)
+			}
+		}
+	}
+	quotes := make([]Quote, 0, len(list))
+	for i, q := range list {
+		quotes = append(quotes, Quote{Index: i + 1, SourceName: q.SourceName, Q: q.Q, A: q.A})
+	}
+	return quotes
+}
+
+// imagePattern 匹配回答中 FastGPT 的图片链接，包括带域名的完整链接
+var imagePattern = regexp.MustCompile(`(?:https?://[^\s()"'<>]*)?/api/system/img/([A-Za-z0-9._-]+)`)
+
+// RewriteImages 将记录中的 FastGPT 图片链接替换为 resolve 返回的链接
+func (c *Conversation) RewriteImages(resolve ImageResolver) {
+	rewrite := func(s string) string {
+		return imagePattern.ReplaceAllStringFunc(s, func(m string) string {
+			return resolve(imagePattern.FindStringSubmatch(m)[1])
+		})
+	}
+	for i := range c.Records {
+		r := &c.Records[i]
+		r.Text = rewrite(r.Text)
+		for j := range r.Images {
+			r.Images[j] = rewrite(r.Images[j])
+		}
+		for j := range r.Quotes {
+			r.Quotes[j].Q = rewrite(r.Quotes[j].Q)
+			r.Quotes[j].A = rewrite(r.Quotes[j].A)
+		}
+	}
+}
+
+// firstLine 返回第一行的前 n 个字符
+func firstLine(s string, n int) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) > n {
+		runes = runes[:n]
+	}
+	return string(runes)
+}
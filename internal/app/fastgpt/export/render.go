@@ -0,0 +1,216 @@
+package export
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// Render 按格式渲染会话
+func Render(conv *Conversation, format string) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return renderMarkdown(conv), nil
+	case FormatHTML:
+		return renderHTML(conv)
+	case FormatJSON:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(conv); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// ContentType 导出格式对应的 Content-Type
+func ContentType(format string) string {
+	switch format {
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatJSON:
+		return "application/json; charset=utf-8"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// FileName 导出文件名，只保留会话 ID 中可以安全用作文件名的字符
+func FileName(conv *Conversation, format string) string {
+	return "chat-" + safeName(conv.ChatId) + "." + format
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func safeName(s string) string {
+	s = unsafeName.ReplaceAllString(s, "_")
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+func roleName(role string) string {
+	switch role {
+	case model.ChatRoleUser:
+		return "提问"
+	case model.ChatRoleSystem:
+		return "系统"
+	default:
+		return "回答"
+	}
+}
+
+func renderMarkdown(conv *Conversation) []byte {
+	var b strings.Builder
+	title := conv.Title
+	if title == "" {
+		title = "聊天记录"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "- 应用：%s\n- 会话：%s\n", conv.AppName, conv.ChatId)
+	if conv.StaffId != "" {
+		fmt.Fprintf(&b, "- 学号：%s\n", conv.StaffId)
+	}
+	fmt.Fprintf(&b, "- 导出时间：%s\n", conv.ExportedAt)
+	if conv.Truncated {
+		fmt.Fprintf(&b, "- 记录过多，只导出了前 %d 条\n", MaxRecords)
+	}
+
+	for _, r := range conv.Records {
+		b.WriteString("\n---\n\n")
+		fmt.Fprintf(&b, "### %s", roleName(r.Role))
+		if r.Time != "" {
+			fmt.Fprintf(&b, " · %s", r.Time)
+		}
+		b.WriteString("\n\n")
+		if r.Reasoning != "" {
+			b.WriteString(quoteBlock("思考过程：\n"+r.Reasoning, "> "))
+			b.WriteString("\n")
+		}
+		if r.Text != "" {
+			b.WriteString(r.Text)
+			b.WriteString("\n\n")
+		}
+		for _, img := range r.Images {
+			fmt.Fprintf(&b, "![](%s)\n\n", img)
+		}
+		if len(r.Quotes) > 0 {
+			b.WriteString("**引用**\n\n")
+			for _, q := range r.Quotes {
+				fmt.Fprintf(&b, "%d. **%s**\n\n", q.Index, q.SourceName)
+				b.WriteString(quoteBlock(q.Q, "   > "))
+				if q.A != "" {
+					b.WriteString("   >\n")
+					b.WriteString(quoteBlock(q.A, "   > "))
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+	return []byte(b.String())
+}
+
+// quoteBlock 为每一行加上引用前缀
+func quoteBlock(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(prefix+l, " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// markdownImage 匹配 Markdown 图片
+var markdownImage = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+
+// textHTML 转义文本，并将其中的 Markdown 图片转换为 <img>，只保留 http(s)、站内和 data:image 链接
+func textHTML(s string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range markdownImage.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(html.EscapeString(s[last:m[0]]))
+		b.WriteString(imgTag(s[m[4]:m[5]], s[m[2]:m[3]]))
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(s[last:]))
+	return template.HTML(b.String())
+}
+
+func imgTag(src, alt string) string {
+	if !strings.HasPrefix(src, "https://") && !strings.HasPrefix(src, "http://") &&
+		!strings.HasPrefix(src, "/") && !strings.HasPrefix(src, "data:image/") {
+		return html.EscapeString(src)
+	}
+	return fmt.Sprintf(`<img src="%s" alt="%s">`, html.EscapeString(src), html.EscapeString(alt))
+}
+
+var pageTemplate = template.Must(template.New("chat").Funcs(template.FuncMap{
+	"text":     textHTML,
+	"img":      func(src string) template.HTML { return template.HTML(imgTag(src, "")) },
+	"roleName": roleName,
+	"max":      func() int { return MaxRecords },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{if .Title}}{{.Title}}{{else}}聊天记录{{end}}</title>
+<style>
+body { font-family: -apple-system, "PingFang SC", "Microsoft YaHei", sans-serif; max-width: 860px; margin: 2em auto; padding: 0 1em; color: #222; line-height: 1.6; }
+header { border-bottom: 2px solid #ddd; margin-bottom: 1.5em; }
+header p { margin: .2em 0; color: #666; font-size: 14px; }
+.record { margin: 1.2em 0; padding: .8em 1em; border-radius: 6px; page-break-inside: avoid; }
+.user { background: #eef5ff; }
+.assistant { background: #f7f7f7; }
+.system { background: #fff8e6; }
+.meta { font-size: 12px; color: #888; margin-bottom: .4em; }
+.text { white-space: pre-wrap; word-break: break-word; }
+.reasoning { white-space: pre-wrap; color: #777; border-left: 3px solid #ccc; padding-left: .8em; margin-bottom: .6em; font-size: 14px; }
+.quotes { margin-top: .8em; font-size: 13px; }
+.quotes li { margin-bottom: .6em; }
+.quote { white-space: pre-wrap; color: #555; border-left: 3px solid #9ab; padding-left: .6em; }
+img { max-width: 100%; }
+@media print { body { margin: 0; max-width: none; } .record { border: 1px solid #ddd; } }
+</style>
+</head>
+<body>
+<header>
+<h1>{{if .Title}}{{.Title}}{{else}}聊天记录{{end}}</h1>
+<p>应用：{{.AppName}}</p>
+<p>会话：{{.ChatId}}</p>
+{{if .StaffId}}<p>学号：{{.StaffId}}</p>{{end}}
+<p>导出时间：{{.ExportedAt}}</p>
+{{if .Truncated}}<p>记录过多，只导出了前 {{max}} 条</p>{{end}}
+</header>
+{{range .Records}}
+<section class="record {{.Role}}">
+<div class="meta">{{roleName .Role}}{{if .Time}} · {{.Time}}{{end}}{{if eq .Feedback "good"}} · 评价：有帮助{{else if eq .Feedback "bad"}} · 评价：没帮助{{end}}</div>
+{{if .Reasoning}}<div class="reasoning">{{.Reasoning}}</div>{{end}}
+<div class="text">{{text .Text}}</div>
+{{range .Images}}<div>{{img .}}</div>{{end}}
+{{if .Quotes}}<ol class="quotes">{{range .Quotes}}
+<li><strong>{{.SourceName}}</strong><div class="quote">{{text .Q}}{{if .A}}
+
+{{text .A}}{{end}}</div></li>{{end}}
+</ol>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+func renderHTML(conv *Conversation) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, conv); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
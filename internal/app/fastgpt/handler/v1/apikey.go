@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/service"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+)
+
+// HandleCreateAPIKey 创建个人 API Key，用于 OpenAI 兼容接口
+func HandleCreateAPIKey(c flamego.Context, r flamego.Render, req dto.CreateAPIKeyRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+
+	plain, key, err := service.CreateAPIKey(c.Request().Context(), authInfo, req.Name)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, dto.CreateAPIKeyResponse{
+		ID:   key.ID,
+		Name: key.Name,
+		Key:  plain,
+	})
+}
+
+// HandleGetAPIKeyList 获取当前用户的个人 API Key 列表
+func HandleGetAPIKeyList(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+	keys, err := dao.APIKey.ListKeys(c.Request().Context(), authInfo.StaffId)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	items := make([]dto.APIKeyItem, 0, len(keys))
+	for _, key := range keys {
+		item := dto.APIKeyItem{
+			ID:        key.ID,
+			Name:      key.Name,
+			KeyPrefix: key.KeyPrefix + "****",
+			CreatedAt: key.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if key.LastUsedAt != nil {
+			item.LastUsedAt = key.LastUsedAt.Format("2006-01-02 15:04:05")
+		}
+		items = append(items, item)
+	}
+
+	response.HTTPSuccess(r, items)
+}
+
+// HandleDeleteAPIKey 删除当前用户的个人 API Key
+func HandleDeleteAPIKey(c flamego.Context, r flamego.Render, req dto.DeleteAPIKeyRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+
+	affected, err := dao.APIKey.DeleteKey(c.Request().Context(), req.ID, authInfo.StaffId)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if affected == 0 {
+		response.HTTPFail(r, 404001, "API Key 不存在")
+		return
+	}
+
+	response.HTTPSuccess(r, nil)
+}
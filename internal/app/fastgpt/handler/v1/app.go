@@ -7,8 +7,11 @@ import (
 	"HelpStudent/internal/app/fastgpt/dao"
 	"HelpStudent/internal/app/fastgpt/dto"
 	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/service"
 	dao2 "HelpStudent/internal/app/managers/dao"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/flamego/binding"
 	"github.com/flamego/flamego"
@@ -40,17 +43,37 @@ func HandleCreateApp(c flamego.Context, r flamego.Render, req dto.CreateAppReque
 		return
 	}
 
+	if !service.HasBackend(req.Backend) {
+		response.HTTPFail(r, 400018, "FastGPT 后端不存在")
+		return
+	}
+	if req.CacheTTL < 0 {
+		response.HTTPFail(r, 400023, "缓存时间不能为负数")
+		return
+	}
+	uploadTypes, err := uploadTypesValue(req.UploadTypes)
+	if err != nil || req.UploadMaxSize < -1 {
+		response.HTTPFail(r, 400034, fmt.Sprintf("附件大小应不小于 -1，类型只能是 %s", strings.Join(service.UploadTypes, "、")))
+		return
+	}
+
 	// 创建应用
 	app := &model.FastgptApp{
 		AppName:     req.AppName,
 		AppId:       req.AppId,
 		ShareId:     req.ShareId,
-		APIKey:      req.APIKey,
+		Backend:     req.Backend,
+		CacheTTL:    req.CacheTTL,
 		Description: req.Description,
 		CreatedBy:   authInfo.Uid,
+
+		UploadMaxSize: req.UploadMaxSize,
+		UploadTypes:   uploadTypes,
 	}
 
-	if err := dao.FastgptApp.CreateApp(app); err != nil {
+	// API Key 绑定应用主键加密后保存
+	seal := func(id string) (string, error) { return service.EncryptAPIKey(id, req.APIKey) }
+	if err := dao.FastgptApp.CreateAppWithKey(app, seal); err != nil {
 		logx.SystemLogger.CtxError(c.Request().Context(), err)
 		response.ServiceErr(r, err)
 		return
@@ -81,19 +104,30 @@ func HandleGetAppList(c flamego.Context, r flamego.Render, req dto.GetAppListReq
 		return
 	}
 
-	// 转换为 DTO
+	// 转换为 DTO，API Key 只返回脱敏后的值
 	var appItems []dto.AppItem
 	for _, app := range apps {
+		maskedKey := "****"
+		if plain, err := service.DecryptAPIKey(app.ID, app.APIKey); err == nil {
+			maskedKey = service.MaskAPIKey(plain)
+		} else {
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+		}
 		appItems = append(appItems, dto.AppItem{
 			ID:          app.ID,
 			AppName:     app.AppName,
 			AppId:       app.AppId,
 			ShareId:     app.ShareId,
-			APIKey:      app.APIKey,
+			APIKey:      maskedKey,
+			Backend:     app.Backend,
+			CacheTTL:    app.CacheTTL,
 			Description: app.Description,
 			CreatedBy:   app.CreatedBy,
 			CreatedAt:   app.CreatedAt.Format("2006-01-02 15:04:05"),
 			UpdatedAt:   app.UpdatedAt.Format("2006-01-02 15:04:05"),
+
+			UploadMaxSize: app.UploadMaxSize,
+			UploadTypes:   splitUploadTypes(app.UploadTypes),
 		})
 	}
 
@@ -140,7 +174,42 @@ func HandleUpdateApp(c flamego.Context, r flamego.Render, req dto.UpdateAppReque
 		updates["share_id"] = req.ShareId
 	}
 	if req.APIKey != "" {
-		updates["api_key"] = req.APIKey
+		apiKey, err := service.EncryptAPIKey(req.ID, req.APIKey)
+		if err != nil {
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+			response.ServiceErr(r, err)
+			return
+		}
+		updates["api_key"] = apiKey
+	}
+	if req.Backend != nil {
+		if !service.HasBackend(*req.Backend) {
+			response.HTTPFail(r, 400018, "FastGPT 后端不存在")
+			return
+		}
+		updates["backend"] = *req.Backend
+	}
+	if req.CacheTTL != nil {
+		if *req.CacheTTL < 0 {
+			response.HTTPFail(r, 400023, "缓存时间不能为负数")
+			return
+		}
+		updates["cache_ttl"] = *req.CacheTTL
+	}
+	if req.UploadMaxSize != nil {
+		if *req.UploadMaxSize < -1 {
+			response.HTTPFail(r, 400034, "附件大小应不小于 -1")
+			return
+		}
+		updates["upload_max_size"] = *req.UploadMaxSize
+	}
+	if req.UploadTypes != nil {
+		uploadTypes, err := uploadTypesValue(*req.UploadTypes)
+		if err != nil {
+			response.HTTPFail(r, 400034, fmt.Sprintf("附件类型只能是 %s", strings.Join(service.UploadTypes, "、")))
+			return
+		}
+		updates["upload_types"] = uploadTypes
 	}
 	if req.Description != "" {
 		updates["description"] = req.Description
@@ -156,6 +225,8 @@ func HandleUpdateApp(c flamego.Context, r flamego.Render, req dto.UpdateAppReque
 		response.ServiceErr(r, err)
 		return
 	}
+	// 应用配置变化后旧的回答不再可信
+	service.InvalidateResponseCache(c.Request().Context(), req.ID)
 
 	response.HTTPSuccess(r, nil)
 }
@@ -191,6 +262,69 @@ func HandleDeleteApp(c flamego.Context, r flamego.Render, req dto.DeleteAppReque
 		response.ServiceErr(r, err)
 		return
 	}
+	service.InvalidateResponseCache(c.Request().Context(), req.ID)
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleRevealAppKey 查看应用 API Key 明文，仅管理员可用，每次查看都会记录审计日志
+func HandleRevealAppKey(c flamego.Context, r flamego.Render, req dto.RevealAppKeyRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法查看 API Key")
+		return
+	}
+
+	apiKey, err := service.RevealAPIKey(c.Request().Context(), authInfo, req.ID, c.RemoteAddr())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "应用不存在")
+			return
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	logx.SystemLogger.Infof("API Key revealed: app=%s, staffId=%s, ip=%s", req.ID, authInfo.StaffId, c.RemoteAddr())
+
+	response.HTTPSuccess(r, dto.RevealAppKeyResponse{APIKey: apiKey})
+}
 
+// HandleClearAppCache 清除应用的回答缓存，知识库更新后由管理员手动调用
+func HandleClearAppCache(c flamego.Context, r flamego.Render, req dto.ClearAppCacheRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法清除缓存")
+		return
+	}
+
+	service.InvalidateResponseCache(c.Request().Context(), req.ID)
 	response.HTTPSuccess(r, nil)
 }
+
+// uploadTypesValue 校验附件类型并转换为保存的格式
+func uploadTypesValue(types []string) (string, error) {
+	for _, t := range types {
+		if !service.UploadTypeSupported(t) {
+			return "", fmt.Errorf("unsupported upload type: %s", t)
+		}
+	}
+	return strings.Join(types, ","), nil
+}
+
+func splitUploadTypes(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
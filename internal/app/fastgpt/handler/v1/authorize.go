@@ -0,0 +1,52 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/service"
+	"errors"
+
+	"github.com/flamego/flamego"
+)
+
+// authorizeApp 获取应用并校验当前用户的使用权限，失败时直接写入响应
+func authorizeApp(c flamego.Context, r flamego.Render, authInfo auth.Info, fastgptAppId string) (*model.FastgptApp, bool) {
+	app, err := service.AuthorizeApp(authInfo, fastgptAppId)
+	return app, handleAuthorizeErr(c, r, err)
+}
+
+// authorizeAppByShareID 根据 ShareID 获取应用并校验当前用户的使用权限，失败时直接写入响应
+func authorizeAppByShareID(c flamego.Context, r flamego.Render, authInfo auth.Info, shareId string) (*model.FastgptApp, bool) {
+	app, err := service.AuthorizeAppByShareID(authInfo, shareId)
+	return app, handleAuthorizeErr(c, r, err)
+}
+
+func handleAuthorizeErr(c flamego.Context, r flamego.Render, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrAppNotFound):
+		response.HTTPFail(r, 400013, "应用不存在或已禁用")
+	case errors.Is(err, service.ErrAppForbidden):
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.HTTPFail(r, 403013, "无权使用该应用")
+	default:
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+	}
+	return false
+}
+
+// authorizeErrMessage 将权限校验错误转换为 SSE 错误消息
+func authorizeErrMessage(err error) string {
+	switch {
+	case errors.Is(err, service.ErrAppNotFound):
+		return `{"error":"应用不存在或已禁用"}`
+	case errors.Is(err, service.ErrAppForbidden):
+		return `{"error":"无权使用该应用"}`
+	default:
+		return `{"error":"内部异常"}`
+	}
+}
@@ -0,0 +1,534 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/eval"
+	"HelpStudent/internal/app/fastgpt/model"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// maxEvalK recall@k 允许的最大 k
+const maxEvalK = 50
+
+// HandleCreateEvalSet 管理员为知识库创建检索评测集
+func HandleCreateEvalSet(c flamego.Context, r flamego.Render, req dto.CreateEvalSetRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理评测集")
+		return
+	}
+	app, ok := authorizeApp(c, r, authInfo, req.FastgptAppId)
+	if !ok {
+		return
+	}
+
+	set := &model.EvalSet{
+		AppId:       app.ID,
+		DatasetId:   req.DatasetId,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		CreatedBy:   authInfo.StaffId,
+	}
+	if err := dao.Eval.CreateSet(c.Request().Context(), set); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, evalSetItem(*set, 0))
+}
+
+// HandleUpdateEvalSet 管理员修改评测集名称或说明
+func HandleUpdateEvalSet(c flamego.Context, r flamego.Render, req dto.UpdateEvalSetRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理评测集")
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			response.HTTPFail(r, 400001, "评测集名称不能为空")
+			return
+		}
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		response.HTTPSuccess(r, nil)
+		return
+	}
+
+	affected, err := dao.Eval.UpdateSet(c.Request().Context(), req.ID, updates)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if affected == 0 {
+		response.HTTPFail(r, 404001, "评测集不存在")
+		return
+	}
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleDeleteEvalSet 管理员删除评测集及其问题，历史运行记录保留
+func HandleDeleteEvalSet(c flamego.Context, r flamego.Render, req dto.EvalSetIdRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理评测集")
+		return
+	}
+
+	affected, err := dao.Eval.DeleteSet(c.Request().Context(), req.ID)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if affected == 0 {
+		response.HTTPFail(r, 404001, "评测集不存在")
+		return
+	}
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleGetEvalSets 管理员查看评测集
+func HandleGetEvalSets(c flamego.Context, r flamego.Render, req dto.GetEvalSetsRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理评测集")
+		return
+	}
+
+	sets, err := dao.Eval.ListSets(c.Request().Context(), req.FastgptAppId, req.DatasetId)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	ids := make([]string, 0, len(sets))
+	for _, set := range sets {
+		ids = append(ids, set.ID)
+	}
+	counts, err := dao.Eval.CountCases(c.Request().Context(), ids)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	items := make([]dto.EvalSetItem, 0, len(sets))
+	for _, set := range sets {
+		items = append(items, evalSetItem(set, counts[set.ID]))
+	}
+	response.HTTPSuccess(r, items)
+}
+
+// HandleGetEvalCases 管理员查看评测集中的问题
+func HandleGetEvalCases(c flamego.Context, r flamego.Render, req dto.EvalSetIdRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理评测集")
+		return
+	}
+	if _, ok := loadEvalSet(c, r, req.ID); !ok {
+		return
+	}
+
+	cases, err := dao.Eval.GetCases(c.Request().Context(), req.ID)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	items := make([]dto.EvalCaseItem, 0, len(cases))
+	for _, ec := range cases {
+		items = append(items, dto.EvalCaseItem{
+			ID:                    ec.ID,
+			Question:              ec.Question,
+			ExpectedCollectionIds: ec.ExpectedCollectionIds,
+			ExpectedDataIds:       ec.ExpectedDataIds,
+		})
+	}
+
+	response.HTTPSuccess(r, dto.EvalCaseListResponse{Cases: items})
+}
+
+// HandleAddEvalCases 管理员批量添加评测问题
+func HandleAddEvalCases(c flamego.Context, r flamego.Render, req dto.AddEvalCasesRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理评测集")
+		return
+	}
+	if _, ok := loadEvalSet(c, r, req.SetId); !ok {
+		return
+	}
+
+	cases := make([]model.EvalCase, 0, len(req.Cases))
+	for _, input := range req.Cases {
+		ec := model.EvalCase{
+			SetId:                 req.SetId,
+			Question:              strings.TrimSpace(input.Question),
+			ExpectedCollectionIds: cleanWords(input.ExpectedCollectionIds),
+			ExpectedDataIds:       cleanWords(input.ExpectedDataIds),
+		}
+		if ec.Question == "" || len(ec.ExpectedCollectionIds)+len(ec.ExpectedDataIds) == 0 {
+			response.HTTPFail(r, 400030, "每个问题都需要填写问题内容和至少一个期望的集合或数据ID")
+			return
+		}
+		cases = append(cases, ec)
+	}
+	if err := dao.Eval.AddCases(c.Request().Context(), cases); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleUpdateEvalCase 管理员修改评测问题
+func HandleUpdateEvalCase(c flamego.Context, r flamego.Render, req dto.UpdateEvalCaseRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理评测集")
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Question != nil {
+		if strings.TrimSpace(*req.Question) == "" {
+			response.HTTPFail(r, 400030, "问题内容不能为空")
+			return
+		}
+		updates["question"] = strings.TrimSpace(*req.Question)
+	}
+	if req.ExpectedCollectionIds != nil {
+		updates["expected_collection_ids"] = datatypes.NewJSONSlice(cleanWords(*req.ExpectedCollectionIds))
+	}
+	if req.ExpectedDataIds != nil {
+		updates["expected_data_ids"] = datatypes.NewJSONSlice(cleanWords(*req.ExpectedDataIds))
+	}
+	if len(updates) == 0 {
+		response.HTTPSuccess(r, nil)
+		return
+	}
+
+	affected, err := dao.Eval.UpdateCase(c.Request().Context(), req.SetId, req.ID, updates)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if affected == 0 {
+		response.HTTPFail(r, 404001, "评测问题不存在")
+		return
+	}
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleDeleteEvalCases 管理员删除评测问题
+func HandleDeleteEvalCases(c flamego.Context, r flamego.Render, req dto.DeleteEvalCasesRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理评测集")
+		return
+	}
+
+	if _, err := dao.Eval.DeleteCases(c.Request().Context(), req.SetId, req.Ids); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleCreateEvalRun 管理员按指定检索参数运行评测，在后台逐个问题调用 searchTest
+func HandleCreateEvalRun(c flamego.Context, r flamego.Render, req dto.CreateEvalRunRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法运行评测")
+		return
+	}
+	if req.K == 0 {
+		req.K = 5
+	}
+	switch {
+	case req.K < 0 || req.K > maxEvalK:
+		response.HTTPFail(r, 400030, "k 应在 1-50 之间")
+		return
+	case req.Similarity < 0 || req.Similarity > 1:
+		response.HTTPFail(r, 400030, "similarity 应在 0-1 之间")
+		return
+	case req.Limit < 0:
+		response.HTTPFail(r, 400030, "limit 不能为负数")
+		return
+	}
+	switch req.SearchMode {
+	case "", "embedding", "fullTextRecall", "mixedRecall":
+	default:
+		response.HTTPFail(r, 400030, "searchMode 应为 embedding、fullTextRecall 或 mixedRecall")
+		return
+	}
+
+	set, ok := loadEvalSet(c, r, req.SetId)
+	if !ok {
+		return
+	}
+	counts, err := dao.Eval.CountCases(c.Request().Context(), []string{set.ID})
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if counts[set.ID] == 0 {
+		response.HTTPFail(r, 400031, "评测集还没有问题")
+		return
+	}
+
+	run := &model.EvalRun{
+		SetId:      set.ID,
+		AppId:      set.AppId,
+		DatasetId:  set.DatasetId,
+		SearchMode: req.SearchMode,
+		Similarity: req.Similarity,
+		Limit:      req.Limit,
+		K:          req.K,
+		Status:     model.EvalPending,
+		CreatedBy:  authInfo.StaffId,
+	}
+	if err := dao.Eval.CreateRun(c.Request().Context(), run); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if err := eval.Enqueue(c.Request().Context(), run); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, evalRunItem(*run, false))
+}
+
+// HandleGetEvalRuns 管理员分页查看评测集的运行记录
+func HandleGetEvalRuns(c flamego.Context, r flamego.Render, req dto.GetEvalRunsRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法查看评测")
+		return
+	}
+
+	runs, total, err := dao.Eval.ListRuns(c.Request().Context(), req.SetId, req.Offset, req.Limit)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	items := make([]dto.EvalRunItem, 0, len(runs))
+	for _, run := range runs {
+		items = append(items, evalRunItem(run, false))
+	}
+
+	response.HTTPSuccess(r, dto.EvalRunListResponse{Runs: items, Total: total})
+}
+
+// HandleGetEvalRun 管理员查看评测运行详情，包含每个问题的检索结果
+// 路由: GET /fastgpt/eval/runs/detail?id=xxx
+func HandleGetEvalRun(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法查看评测")
+		return
+	}
+	id := c.Query("id")
+	if id == "" {
+		response.HTTPFail(r, 400001, "缺少必要参数 id")
+		return
+	}
+
+	run, ok := loadEvalRun(c, r, id)
+	if !ok {
+		return
+	}
+	response.HTTPSuccess(r, evalRunItem(*run, true))
+}
+
+// HandleCompareEvalRuns 管理员对比同一评测集的两次运行，列出指标或命中情况变化的问题
+func HandleCompareEvalRuns(c flamego.Context, r flamego.Render, req dto.CompareEvalRunsRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法查看评测")
+		return
+	}
+
+	base, ok := loadEvalRun(c, r, req.BaseId)
+	if !ok {
+		return
+	}
+	target, ok := loadEvalRun(c, r, req.TargetId)
+	if !ok {
+		return
+	}
+	if base.SetId != target.SetId {
+		response.HTTPFail(r, 400030, "只能对比同一评测集的运行")
+		return
+	}
+	if base.Status != model.EvalSucceeded || target.Status != model.EvalSucceeded {
+		response.HTTPFail(r, 400030, "只能对比已完成的运行")
+		return
+	}
+
+	diffs := eval.Compare(base.Results, target.Results)
+	items := make([]dto.EvalCaseDiffItem, 0, len(diffs))
+	for _, d := range diffs {
+		items = append(items, dto.EvalCaseDiffItem{
+			CaseId:       d.CaseId,
+			Question:     d.Question,
+			BaseRecall:   d.BaseRecall,
+			TargetRecall: d.TargetRecall,
+			BaseRR:       d.BaseRR,
+			TargetRR:     d.TargetRR,
+			Lost:         d.Lost,
+			Gained:       d.Gained,
+		})
+	}
+
+	response.HTTPSuccess(r, dto.CompareEvalRunsResponse{
+		Base:        evalRunItem(*base, false),
+		Target:      evalRunItem(*target, false),
+		RecallDelta: target.Recall - base.Recall,
+		MRRDelta:    target.MRR - base.MRR,
+		Diffs:       items,
+	})
+}
+
+// loadEvalSet 获取评测集，失败时已写入响应
+func loadEvalSet(c flamego.Context, r flamego.Render, id string) (*model.EvalSet, bool) {
+	set, err := dao.Eval.GetSet(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "评测集不存在")
+			return nil, false
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return nil, false
+	}
+	return set, true
+}
+
+// loadEvalRun 获取评测运行，失败时已写入响应
+func loadEvalRun(c flamego.Context, r flamego.Render, id string) (*model.EvalRun, bool) {
+	run, err := dao.Eval.GetRun(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "评测运行不存在")
+			return nil, false
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return nil, false
+	}
+	return run, true
+}
+
+func evalSetItem(set model.EvalSet, cases int64) dto.EvalSetItem {
+	return dto.EvalSetItem{
+		ID:          set.ID,
+		AppId:       set.AppId,
+		DatasetId:   set.DatasetId,
+		Name:        set.Name,
+		Description: set.Description,
+		Cases:       cases,
+		CreatedBy:   set.CreatedBy,
+		CreatedAt:   set.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func evalRunItem(run model.EvalRun, detail bool) dto.EvalRunItem {
+	item := dto.EvalRunItem{
+		ID:         run.ID,
+		SetId:      run.SetId,
+		JobId:      run.JobId,
+		SearchMode: run.SearchMode,
+		Similarity: run.Similarity,
+		Limit:      run.Limit,
+		K:          run.K,
+		Status:     run.Status,
+		Cases:      run.Cases,
+		Recall:     run.Recall,
+		MRR:        run.MRR,
+		Error:      run.Error,
+		CreatedBy:  run.CreatedBy,
+		CreatedAt:  run.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if detail {
+		if raw, err := json.Marshal(run.Results); err == nil {
+			item.Results = raw
+		}
+	}
+	if run.FinishedAt != nil {
+		item.FinishedAt = run.FinishedAt.Format("2006-01-02 15:04:05")
+	}
+	return item
+}
@@ -0,0 +1,239 @@
+package v1
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/export"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"HelpStudent/internal/app/fastgpt/service"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"archive/zip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/flamego/flamego"
+	"gorm.io/gorm"
+)
+
+// maxExportSessions 批量导出时最多导出的会话数
+const maxExportSessions = 1000
+
+// HandleExportChat 导出一个会话，参数通过查询参数传入：fastgptAppId、chatId、format（md/html/json，默认 md）
+// 学生只能导出自己的会话，管理员可以导出任意会话
+func HandleExportChat(c flamego.Context, r flamego.Render, w http.ResponseWriter, authInfo auth.Info) {
+	fastgptAppId, chatId := c.Query("fastgptAppId"), c.Query("chatId")
+	format := c.Query("format")
+	if format == "" {
+		format = export.FormatMarkdown
+	}
+	if fastgptAppId == "" || chatId == "" {
+		response.HTTPFail(r, 400001, "fastgptAppId 和 chatId 不能为空")
+		return
+	}
+	if !export.ValidFormat(format) {
+		response.HTTPFail(r, 400001, export.ErrUnknownFormat.Error())
+		return
+	}
+	app, ok := authorizeApp(c, r, authInfo, fastgptAppId)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request().Context()
+	staffId := ""
+	session, err := dao.Chat.GetSession(ctx, authInfo.Uid, app.ID, chatId)
+	switch {
+	case err == nil:
+		staffId = session.StaffId
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if !dao2.Managers.IsManager(authInfo.StaffId) {
+			response.HTTPFail(r, 404001, "会话不存在")
+			return
+		}
+	default:
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	client, err := getSDKClient(app)
+	if err != nil {
+		upstreamFail(c, r, err)
+		return
+	}
+	conv, err := export.Fetch(client, app, chatId)
+	if err != nil {
+		upstreamFail(c, r, err)
+		return
+	}
+	conv.StaffId = staffId
+	conv.RewriteImages(exportImageResolver(ctx, app, format))
+	data, err := export.Render(conv, format)
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", "attachment; filename="+export.FileName(conv, format))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		logx.SystemLogger.CtxError(ctx, "write chat export failed", err)
+	}
+}
+
+// HandleExportAppChats 管理员将应用的全部会话导出为 zip，参数：fastgptAppId、format
+// 按学号分目录，会话较多时边获取边写入；获取失败的会话记录在 errors.txt 中
+func HandleExportAppChats(c flamego.Context, r flamego.Render, w http.ResponseWriter, authInfo auth.Info) {
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员无法批量导出会话")
+		return
+	}
+	format := c.Query("format")
+	if format == "" {
+		format = export.FormatMarkdown
+	}
+	if !export.ValidFormat(format) {
+		response.HTTPFail(r, 400001, export.ErrUnknownFormat.Error())
+		return
+	}
+	app, ok := authorizeApp(c, r, authInfo, c.Query("fastgptAppId"))
+	if !ok {
+		return
+	}
+
+	ctx := c.Request().Context()
+	client, err := getSDKClient(app)
+	if err != nil {
+		upstreamFail(c, r, err)
+		return
+	}
+	histories, err := listAllHistories(client, app)
+	if err != nil {
+		upstreamFail(c, r, err)
+		return
+	}
+	// 本地会话用于标注学号
+	owners := make(map[string]string)
+	sessions, err := dao.Chat.ListAppSessions(ctx, app.ID)
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+	for _, s := range sessions {
+		if _, ok := owners[s.ChatId]; !ok {
+			owners[s.ChatId] = s.StaffId
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=chats-%s-%s.zip", app.ID, time.Now().Format("20060102")))
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	defer func() {
+		if err := zw.Close(); err != nil {
+			logx.SystemLogger.CtxError(ctx, "close chat export zip failed", err)
+		}
+	}()
+
+	resolve := exportImageResolver(ctx, app, format)
+	var failed []string
+	for _, h := range histories {
+		if ctx.Err() != nil {
+			return
+		}
+		conv, err := export.Fetch(client, app, h.ChatId)
+		if err != nil {
+			logx.SystemLogger.CtxError(ctx, "export chat failed", h.ChatId, err)
+			failed = append(failed, fmt.Sprintf("%s\t%v", h.ChatId, err))
+			continue
+		}
+		conv.Title = h.Title
+		if h.CustomTitle != "" {
+			conv.Title = h.CustomTitle
+		}
+		conv.StaffId = owners[h.ChatId]
+		conv.RewriteImages(resolve)
+		data, err := export.Render(conv, format)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s\t%v", h.ChatId, err))
+			continue
+		}
+
+		dir := conv.StaffId
+		if dir == "" {
+			dir = "unknown"
+		}
+		f, err := zw.Create(dir + "/" + export.FileName(conv, format))
+		if err != nil {
+			logx.SystemLogger.CtxError(ctx, "write chat export zip failed", err)
+			return
+		}
+		if _, err := f.Write(data); err != nil {
+			logx.SystemLogger.CtxError(ctx, "write chat export zip failed", err)
+			return
+		}
+	}
+
+	if len(histories) >= maxExportSessions {
+		failed = append(failed, fmt.Sprintf("会话数超过 %d，只导出了最近的 %d 个", maxExportSessions, maxExportSessions))
+	}
+	if len(failed) > 0 {
+		if f, err := zw.Create("errors.txt"); err == nil {
+			_, _ = f.Write([]byte(strings.Join(failed, "\n") + "\n"))
+		}
+	}
+}
+
+// listAllHistories 分页获取应用的会话列表，最多 maxExportSessions 个
+func listAllHistories(client *sdk.Client, app *model.FastgptApp) ([]sdk.ChatHistory, error) {
+	const pageSize = 100
+	var all []sdk.ChatHistory
+	for offset := 0; len(all) < maxExportSessions; offset += pageSize {
+		page, err := client.GetHistories(sdk.GetHistoriesRequest{AppId: app.AppId, Offset: offset, PageSize: pageSize})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.List...)
+		if len(page.List) < pageSize || (page.Total > 0 && offset+len(page.List) >= page.Total) {
+			break
+		}
+	}
+	if len(all) > maxExportSessions {
+		all = all[:maxExportSessions]
+	}
+	return all, nil
+}
+
+// exportImageResolver HTML 导出时将图片内嵌，便于离线查看和打印；其它格式使用签名链接，有效期见 Image.URLTTL
+func exportImageResolver(ctx context.Context, app *model.FastgptApp, format string) export.ImageResolver {
+	now := time.Now()
+	baseURL := strings.TrimRight(config.GetConfig().BaseURL, "/")
+	link := func(imageId string) string {
+		return baseURL + service.SignImageURL(imageId, app.ID, now)
+	}
+	if format != export.FormatHTML {
+		return link
+	}
+	backends := service.ImageBackends(app.ID, "")
+	return func(imageId string) string {
+		img, err := service.GetImage(ctx, backends, imageId, "")
+		if err != nil {
+			logx.SystemLogger.CtxError(ctx, "embed export image failed", imageId, err)
+			return link(imageId)
+		}
+		return "data:" + img.ContentType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+	}
+}
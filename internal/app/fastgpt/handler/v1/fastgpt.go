@@ -1,101 +1,35 @@
 package v1
 
 import (
-	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
-	"time"
 
-	"HelpStudent/config"
 	"HelpStudent/core/auth"
 	"HelpStudent/core/logx"
 	"HelpStudent/core/middleware/response"
-	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/core/middleware/sse"
 	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
 	"HelpStudent/internal/app/fastgpt/service"
 
 	"github.com/flamego/binding"
 	"github.com/flamego/flamego"
 )
 
-// getFastGPTClient 获取 FastGPT 客户端（使用指定的 API Key）
-func getFastGPTClient(apiKey string) *service.FastGPTClient {
-	cfg := config.GetConfig()
-	return service.NewFastGPTClient(cfg.FastGPT.BaseURL, apiKey)
-}
-
-// HandleGetImage 代理图片请求到 FastGPT
-// 路由: GET /api/system/img/:imageId
-func HandleGetImage(c flamego.Context, r flamego.Render) {
-	imageId := c.Param("imageId")
-	if imageId == "" {
-		response.HTTPFail(r, 400001, "缺少图片ID")
-		return
-	}
-
-	cfg := config.GetConfig()
-	imageURL := cfg.FastGPT.BaseURL + "/system/img/" + imageId
-
-	// 创建 HTTP 客户端请求
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", imageURL, nil)
-	if err != nil {
-		response.ServiceErr(r, err)
-		return
-	}
-
-	// 设置请求头，保持与原始请求一致
-	req.Header.Set("Accept", "image/*,*/*")
-	req.Header.Set("User-Agent", c.Request().Header.Get("User-Agent"))
-
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		response.ServiceErr(r, err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
-	}
-
-	// 设置响应头
-	contentType := resp.Header.Get("Content-Type")
-	if contentType != "" {
-		c.ResponseWriter().Header().Set("Content-Type", contentType)
-	}
-	contentLength := resp.Header.Get("Content-Length")
-	if contentLength != "" {
-		c.ResponseWriter().Header().Set("Content-Length", contentLength)
-	}
-	// 设置缓存头
-	c.ResponseWriter().Header().Set("Cache-Control", "public, max-age=86400")
-
-	// 将图片内容写入响应
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	io.Copy(c.ResponseWriter(), resp.Body)
-}
-
 // HandleChatCompletion 处理聊天补全请求
 func HandleChatCompletion(c flamego.Context, r flamego.Render, req dto.ChatCompletionRequest, errs binding.Errors, authInfo auth.Info) {
 	if errs != nil {
 		response.InValidParam(r, errs)
 		return
 	}
-	// TODO检查这个用户是否可以使用这个app
-
-	// 根据 fastgptAppId 获取对应的 API Key
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.HTTPFail(r, 400013, "应用不存在或已禁用")
+	// 根据 fastgptAppId 获取对应的 API Key，并检查这个用户是否可以使用这个app
+	app, ok := authorizeApp(c, r, authInfo, req.FastgptAppId)
+	if !ok {
 		return
 	}
 
@@ -105,588 +39,433 @@ func HandleChatCompletion(c flamego.Context, r flamego.Render, req dto.ChatCompl
 		return
 	}
 
-	// 非流式请求
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/v1/chat/completions", req)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
+	// 检查科目的考试/作业限制时段，限制期间不占用配额
+	lockoutPrompt, lockErr := checkLockout(c.Request().Context(), authInfo, app)
+	if lockErr != nil {
+		response.HTTPFail(r, service.LockoutCode, lockErr.Message)
 		return
 	}
 
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
+	// 检查提问中的敏感词，被拦截的提问不占用配额
+	ctx := c.Request().Context()
+	messages, blocked := moderatePrompt(ctx, authInfo, app, req.Messages)
+	if blocked {
+		response.HTTPFail(r, 400020, "提问包含敏感内容")
 		return
 	}
 
-	// 直接返回 FastGPT 的响应
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
-}
-
-// sendSSEMessage 安全地发送 SSE 消息，捕获可能的 panic
-func sendSSEMessage(msg chan<- *dto.SSEMessage, message *dto.SSEMessage) (sent bool) {
-	defer func() {
-		if r := recover(); r != nil {
-			sent = false
-		}
-	}()
-	msg <- message
-	return true
-}
-
-// HandleStreamChatCompletion 处理流式聊天补全请求（使用 flamego/sse）
-func HandleStreamChatCompletion(c flamego.Context, req dto.ChatCompletionRequest, errs binding.Errors, authInfo auth.Info, msg chan<- *dto.SSEMessage) {
-	fmt.Println("========== 开始发送消息 ==========")
-
-	if errs != nil {
-		sendSSEMessage(msg, &dto.SSEMessage{Data: `{"error":"参数错误"}`, Event: "error"})
-		return
-	}
-
-	// 根据 fastgptAppId 获取对应的 API Key
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
+	// 校验附件并重新签名
+	messages, err := resolveAttachments(ctx, authInfo, app, messages)
 	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		sendSSEMessage(msg, &dto.SSEMessage{Data: `{"error":"应用不存在或已禁用"}`, Event: "error"})
+		if errors.Is(err, service.ErrAttachmentInvalid) {
+			response.HTTPFail(r, 400035, err.Error())
+			return
+		}
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
 		return
 	}
+	req.Messages = messages
+	question := lastQuestion(req.Messages)
 
-	// 强制设置为流式模式
-	req.Stream = true
+	// 注入应用的提示词模板
+	prompt := applyPromptTemplate(ctx, authInfo, app, &req)
+	prompt += applyLockoutPrompt(lockoutPrompt, &req)
 
-	// 发起流式请求
-	resp, err := getFastGPTClient(app.APIKey).ForwardStreamRequest("POST", "/v1/chat/completions", req)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		sendSSEMessage(msg, &dto.SSEMessage{Data: `{"error":"请求失败"}`, Event: "error"})
+	// 检查聊天配额，请求校验通过后才占用
+	if !checkQuota(c, r, authInfo, app) {
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d", resp.StatusCode)
-		sendSSEMessage(msg, &dto.SSEMessage{Data: `{"error":"FastGPT API 调用失败"}`, Event: "error"})
-		return
+	// 应用开启缓存时，相同问题直接返回缓存，并发的相同请求只调用一次 FastGPT
+	key, cacheable := service.ResponseCacheKey(app, service.ResponseKindJSON, req.Detail, question, prompt, req.Variables)
+	if cacheable {
+		if v, ok := service.GetCachedResponse(ctx, key); ok {
+			replyCachedChat(ctx, r, authInfo, app, req, v.(*sdk.ChatCompletionResponse))
+			return
+		}
 	}
-
-	// 读取并转发流式响应
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// 跳过空行
-		if len(strings.TrimSpace(line)) == 0 {
-			continue
+	fetch := func() (any, error) {
+		resp, err := completeChat(ctx, authInfo, app, req)
+		if err == nil && cacheable {
+			service.SetCachedResponse(ctx, app, key, resp)
 		}
-
-		// 解析 SSE 格式数据
-		if strings.HasPrefix(line, "data: ") {
-			data := strings.TrimPrefix(line, "data: ")
-			fmt.Printf("[SSE发送] %s\n", data)
-			if !sendSSEMessage(msg, &dto.SSEMessage{Data: data}) {
-				// 客户端已断开连接
-				fmt.Println("[SSE发送] 客户端已断开连接")
-				return
-			}
-
-			// 检查是否是结束标记
-			if data == "[DONE]" {
-				fmt.Println("========== 消息发送完成 ==========")
-				break
-			}
+		return resp, err
+	}
+
+	var (
+		v     any
+		fresh = true
+	)
+	if cacheable {
+		v, fresh, err = service.CollapseResponse(ctx, key, fetch)
+		if !fresh && ctx.Err() == nil && errors.Is(err, context.Canceled) {
+			// 第一个请求的客户端已断开，上游调用随之取消，自行请求
+			fresh = true
+			v, err = fetch()
 		}
+	} else {
+		v, err = fetch()
 	}
-
-	if err := scanner.Err(); err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), "Stream read error", err)
-	}
-}
-
-// HandleGetHistories 获取聊天历史列表
-func HandleGetHistories(c flamego.Context, r flamego.Render, req dto.GetHistoriesRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
-
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
-	if err != nil {
-		response.ServiceErr(r, err)
-		return
-	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/chat/getHistories", req)
 	if err != nil {
-		response.ServiceErr(r, err)
+		if !fresh && ctx.Err() != nil {
+			// 等待相同请求时客户端已断开
+			return
+		}
+		if errors.Is(err, errAnswerBlocked) {
+			response.HTTPFail(r, 400021, "回答包含敏感内容")
+			return
+		}
+		upstreamFail(c, r, err)
 		return
 	}
-
-	if statusCode != http.StatusOK {
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
+	if !fresh {
+		replyCachedChat(ctx, r, authInfo, app, req, v.(*sdk.ChatCompletionResponse))
 		return
 	}
 
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
+	resp := v.(*sdk.ChatCompletionResponse)
+	recordTranscript(ctx, authInfo, app, req, resp.Answer(), false)
+	response.HTTPSuccess(r, resp)
 }
 
-// HandleUpdateHistory 更新聊天会话
-func HandleUpdateHistory(c flamego.Context, r flamego.Render, req dto.UpdateHistoryRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
+// errAnswerBlocked 回答命中 block 词表
+var errAnswerBlocked = errors.New("answer blocked by moderation")
 
-	// 使用 appId 获取 API Key
-	app, err := dao.FastgptApp.GetAppByID(req.AppId)
+// completeChat 调用 FastGPT 获取非流式回答，记录用量并检查回答中的敏感词
+// 上游请求跟随 ctx，客户端断开或服务关闭时中止
+func completeChat(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, req dto.ChatCompletionRequest) (*sdk.ChatCompletionResponse, error) {
+	client, err := getSDKClient(app)
 	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
+		return nil, err
 	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/chat/history/updateHistory", req)
+	resp, err := client.ChatCompletions(ctx, newChatRequest(req))
 	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
+		return nil, err
 	}
+	service.RecordUsage(ctx, authInfo, app, resp.TotalTokens())
 
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
+	result := service.Moderate(ctx, authInfo, app.ID, model.ModerationAnswer, resp.Answer())
+	if result.Blocked {
+		return nil, errAnswerBlocked
 	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
-}
-
-// HandleGetPaginationRecords 获取聊天记录
-func HandleGetPaginationRecords(c flamego.Context, r flamego.Render, req dto.GetPaginationRecordsRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
-
-	// 使用 fastgptAppId 获取 API Key
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/chat/getPaginationRecords", req)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
+	if result.Text != resp.Answer() {
+		resp.Choices[0].Message.Content = result.Text
 	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
+	return resp, nil
 }
 
-// HandleCreateDataset 创建数据集
-func HandleCreateDataset(c flamego.Context, r flamego.Render, req dto.DatasetCreateRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
-
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/dataset/create", req)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
-	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
+// replyCachedChat 返回缓存的回答，只记录请求次数不计 token
+// 缓存的回答被多个请求共享，复制后再使用
+func replyCachedChat(ctx context.Context, r flamego.Render, authInfo auth.Info, app *model.FastgptApp, req dto.ChatCompletionRequest, resp *sdk.ChatCompletionResponse) {
+	resp = resp.Clone()
+	service.RecordUsage(ctx, authInfo, app, 0)
+	recordTranscript(ctx, authInfo, app, req, resp.Answer(), false)
+	response.HTTPSuccess(r, resp)
 }
 
-// HandleListDatasets 列出数据集
-func HandleListDatasets(c flamego.Context, r flamego.Render, req dto.DatasetListRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
-
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/dataset/list", req)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
+// sendSSEMessage 安全地发送 SSE 消息，捕获可能的 panic
+// 连接已关闭时立即返回 false，避免阻塞在无人接收的 channel 上
+func sendSSEMessage(notifier sse.Notifier, msg chan<- *dto.SSEMessage, message *dto.SSEMessage) (sent bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			sent = false
+		}
+	}()
+	select {
+	case msg <- message:
+		return true
+	case <-notifier.Closed():
+		return false
 	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
 }
 
-// HandleGetDatasetDetail 获取数据集详情
-func HandleGetDatasetDetail(c flamego.Context, r flamego.Render, authInfo auth.Info) {
-	id := c.Query("id")
-	fastgptAppId := c.Query("fastgptAppId")
-	if id == "" || fastgptAppId == "" {
-		response.HTTPFail(r, 400001, "缺少必要参数")
-		return
-	}
+// HandleStreamChatCompletion 处理流式聊天补全请求（使用 flamego/sse）
+func HandleStreamChatCompletion(c flamego.Context, req dto.ChatCompletionRequest, errs binding.Errors, authInfo auth.Info, msg chan<- *dto.SSEMessage, notifier sse.Notifier) {
+	fmt.Println("========== 开始发送消息 ==========")
 
-	app, err := dao.FastgptApp.GetAppByID(fastgptAppId)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
+	if errs != nil {
+		sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: `{"error":"参数错误"}`, Event: "error"})
 		return
 	}
 
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequestWithQuery("GET", "/core/dataset/detail", map[string]string{
-		"id": id,
-	})
+	// 根据 fastgptAppId 获取对应的 API Key，并检查这个用户是否可以使用这个app
+	app, err := service.AuthorizeApp(authInfo, req.FastgptAppId)
 	if err != nil {
 		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
+		sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: authorizeErrMessage(err), Event: "error"})
 		return
 	}
 
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
+	// 检查科目的考试/作业限制时段
+	lockoutPrompt, lockErr := checkLockout(c.Request().Context(), authInfo, app)
+	if lockErr != nil {
+		sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: lockoutErrMessage(lockErr), Event: "error"})
 		return
 	}
 
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
-}
-
-// HandleDeleteDataset 删除数据集
-func HandleDeleteDataset(c flamego.Context, r flamego.Render, authInfo auth.Info) {
-	id := c.Query("id")
-	fastgptAppId := c.Query("fastgptAppId")
-	if id == "" || fastgptAppId == "" {
-		response.HTTPFail(r, 400001, "缺少必要参数")
+	// 检查提问中的敏感词，被拦截的提问不占用配额
+	messages, blocked := moderatePrompt(c.Request().Context(), authInfo, app, req.Messages)
+	if blocked {
+		sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: `{"error":"提问包含敏感内容"}`, Event: "error"})
 		return
 	}
+	req.Messages = messages
 
-	app, err := dao.FastgptApp.GetAppByID(fastgptAppId)
-	if err != nil {
+	// 校验附件并重新签名
+	if req.Messages, err = resolveAttachments(c.Request().Context(), authInfo, app, req.Messages); err != nil {
 		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
+		sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: attachmentErrMessage(err), Event: "error"})
 		return
 	}
+	question := lastQuestion(req.Messages)
 
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequestWithQuery("DELETE", "/core/dataset/delete", map[string]string{
-		"id": id,
-	})
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
-	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
-}
-
-// HandleCreateCollectionText 从文本创建集合
-func HandleCreateCollectionText(c flamego.Context, r flamego.Render, req dto.CreateCollectionTextRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
+	// 注入应用的提示词模板
+	prompt := applyPromptTemplate(c.Request().Context(), authInfo, app, &req)
+	prompt += applyLockoutPrompt(lockoutPrompt, &req)
 
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
-	if err != nil {
+	// 检查聊天配额，请求校验通过后才占用
+	if err := service.CheckQuota(c.Request().Context(), authInfo, app); err != nil {
 		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
+		sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: quotaErrMessage(err), Event: "error"})
 		return
 	}
 
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/dataset/collection/create/text", req)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
+	key, cacheable := service.ResponseCacheKey(app, service.ResponseKindStream, req.Detail, question, prompt, req.Variables)
+	if !cacheable {
+		relayStream(c, authInfo, app, req, msg, notifier)
 		return
 	}
-
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
+	if v, ok := service.GetCachedResponse(c.Request().Context(), key); ok {
+		replayStream(c.Request().Context(), authInfo, app, req, msg, notifier, v.([]string))
 		return
 	}
 
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
-}
-
-// HandleCreateCollectionLink 从链接创建集合
-func HandleCreateCollectionLink(c flamego.Context, r flamego.Render, req dto.CreateCollectionLinkRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
-
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
+	// 第一个请求边转发边记录，完整结束后写入缓存；同时到达的相同请求等待后回放，等待期间断开则直接返回
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-notifier.Closed():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	v, fresh, err := service.CollapseResponse(ctx, key, func() (any, error) {
+		chunks, complete := relayStream(c, authInfo, app, req, msg, notifier)
+		if !complete {
+			return nil, service.ErrResponseNotCached
+		}
+		service.SetCachedResponse(c.Request().Context(), app, key, chunks)
+		return chunks, nil
+	})
+	if fresh || ctx.Err() != nil {
 		return
 	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/dataset/collection/create/link", req)
 	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
+		// 第一个请求没有完整结束（断开、出错或被拦截），自行请求
+		relayStream(c, authInfo, app, req, msg, notifier)
 		return
 	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
+	replayStream(c.Request().Context(), authInfo, app, req, msg, notifier, v.([]string))
 }
 
-// HandlePushData 推送数据到集合
-func HandlePushData(c flamego.Context, r flamego.Render, req dto.PushDataRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
+// relayStream 转发 FastGPT 的流式回答，返回已发送的 data 块以及是否完整结束
+func relayStream(c flamego.Context, authInfo auth.Info, app *model.FastgptApp, req dto.ChatCompletionRequest, msg chan<- *dto.SSEMessage, notifier sse.Notifier) (chunks []string, complete bool) {
+	// 上游请求跟随浏览器连接，断开后立即中止读取
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-notifier.Closed():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
+	// 发起流式请求，上游总是使用 detail 模式，以便从 flowResponses 统计 token 用量
+	client, err := getSDKClient(app)
 	if err != nil {
 		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
+		sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: upstreamErrMessage(err), Event: "error"})
+		return nil, false
 	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/dataset/data/pushData", req)
+	upstream := newChatRequest(req)
+	upstream.Detail = true
+	resp, err := client.StreamChatCompletions(ctx, upstream)
 	if err != nil {
 		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
+		sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: upstreamErrMessage(err), Event: "error"})
+		return nil, false
 	}
+	defer resp.Body.Close()
 
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
-}
-
-// HandleSearchTest 搜索测试
-func HandleSearchTest(c flamego.Context, r flamego.Render, req dto.SearchTestRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
+	moderator := service.NewAnswerModerator(c.Request().Context(), authInfo, app.ID)
+	relay, err := relayBody(resp.Body, req.Detail, moderator, func(data string) bool {
+		fmt.Printf("[SSE发送] %s\n", data)
+		return sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: data})
+	})
 
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
+	// 上游读取出错且回答不完整时不保存
+	failed := err != nil && ctx.Err() == nil && !relay.done
+	if !failed {
+		recordTranscript(c.Request().Context(), authInfo, app, req, relay.answer.String(), true)
+	}
+	service.RecordUsage(c.Request().Context(), authInfo, app, relay.usage.Total())
+
+	switch {
+	case relay.blocked:
+		// 命中 block 词表，中止回答
+		sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: `{"error":"回答包含敏感内容"}`, Event: "error"})
+		return relay.chunks, false
+	case relay.aborted:
+		// 客户端已断开连接
+		fmt.Println("[SSE发送] 客户端已断开连接")
+		service.RecordStreamAborted(ctx, app.ID)
+		return relay.chunks, false
+	case err != nil && ctx.Err() != nil:
+		// 客户端断开导致上游读取被中止
+		logx.SystemLogger.CtxInfo(c.Request().Context(), "client disconnected, abort upstream stream", app.ID)
+		service.RecordStreamAborted(ctx, app.ID)
+		return relay.chunks, false
+	case failed:
+		logx.SystemLogger.CtxError(c.Request().Context(), "Stream read error", err)
+		return relay.chunks, false
+	case err != nil:
+		// 回答已完整，只是没有读到 flowResponses
+		logx.SystemLogger.CtxError(c.Request().Context(), "read flowResponses failed", err)
 	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/dataset/searchTest", req)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
+	if !relay.done {
+		return relay.chunks, false
 	}
-
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
+	if !finishStream(notifier, msg, req, &relay.citations) {
+		service.RecordStreamAborted(ctx, app.ID)
+		return relay.chunks, false
 	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
+	fmt.Println("========== 消息发送完成 ==========")
+	return relay.chunks, true
 }
 
-// HandleOutLinkInit 外链聊天初始化
-// 路由: GET /fastgpt/core/chat/outLink/init?chatId=xxx&shareId=xxx&outLinkUid=xxx
-func HandleOutLinkInit(c flamego.Context, r flamego.Render, authInfo auth.Info) {
-	chatId := c.Query("chatId")
-	shareId := c.Query("shareId")
-	outLinkUid := c.Query("outLinkUid")
-
-	if shareId == "" {
-		response.HTTPFail(r, 400001, "缺少必要参数 shareId")
-		return
-	}
-
-	// 根据 shareId 获取对应的 API Key
-	app, err := dao.FastgptApp.GetAppByShareID(shareId)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.HTTPFail(r, 400013, "应用不存在或已禁用")
-		return
-	}
-
-	// 构建查询参数
-	queryParams := map[string]string{
-		"shareId": shareId,
-	}
-	if chatId != "" {
-		queryParams["chatId"] = chatId
-	}
-	if outLinkUid != "" {
-		queryParams["outLinkUid"] = outLinkUid
-	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequestWithQuery("GET", "/core/chat/outLink/init", queryParams)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
-	}
-
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
-	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
+// streamRelay 一次流式回答的转发结果
+type streamRelay struct {
+	chunks    []string // 已发送的 data 块，以及 [DONE] 和之后的 flowResponses，用于写入缓存
+	answer    strings.Builder
+	usage     service.UsageCollector
+	citations service.CitationCollector
+	done      bool // 收到回答的 [DONE]
+	blocked   bool // 回答命中 block 词表
+	aborted   bool // 发送失败，客户端已断开
 }
 
-// HandleOutLinkDelHistory 外链删除聊天历史
-// 路由: DELETE /api/core/chat/delHistory?appId=xxx&chatId=xxx&shareId=xxx&outLinkUid=xxx
-func HandleOutLinkDelHistory(c flamego.Context, r flamego.Render, authInfo auth.Info) {
-	fastgptAppId := c.Query("FastgptAppId")
-	chatId := c.Query("chatId")
-	shareId := c.Query("shareId")
-	outLinkUid := c.Query("outLinkUid")
+// relayBody 读取 FastGPT detail 模式的流式响应，检查敏感词后交给 send 发送，[DONE] 本身不发送
+// FastGPT 在回答的 [DONE] 之后才发送 flowResponses，收到 [DONE] 后继续读取，用于统计 token 和收集引用
+// detail 为 false 时只发送非 detail 模式下也有的回答和错误
+func relayBody(body io.Reader, detail bool, moderator *service.AnswerModerator, send func(data string) bool) (*streamRelay, error) {
+	relay := &streamRelay{}
+	err := service.ScanChatStream(body, func(event, data string) bool {
+		if relay.done {
+			// [DONE] 之后的数据不转发
+			if event == "flowResponses" || data != "[DONE]" && strings.HasPrefix(data, "[") {
+				relay.usage.Feed(data)
+				relay.citations.Feed(data)
+				relay.chunks = append(relay.chunks, data)
+				return false
+			}
+			return true
+		}
 
-	if shareId == "" || chatId == "" {
-		response.HTTPFail(r, 400001, "缺少必要参数 shareId 或 chatId")
-		return
-	}
+		relay.usage.Feed(data)
+		if data != "[DONE]" && !detail && !service.IsPlainStreamEvent(event) {
+			return true
+		}
+		data, blocked := moderateStreamData(moderator, data)
+		if blocked {
+			relay.blocked = true
+			return false
+		}
+		if data == "[DONE]" {
+			if rest := flushStreamData(moderator); rest != "" {
+				relay.answer.WriteString(service.ExtractAnswerDelta(rest))
+				if !send(rest) {
+					relay.aborted = true
+					return false
+				}
+				relay.chunks = append(relay.chunks, rest)
+			}
+			relay.chunks = append(relay.chunks, data)
+			relay.done = true
+			return true
+		}
 
-	// 根据 shareId 获取对应的 API Key
-	app, err := dao.FastgptApp.GetAppByID(fastgptAppId)
-	if err != nil {
-		response.HTTPFail(r, 400013, "应用不存在或已禁用")
-		return
-	}
+		relay.answer.WriteString(service.ExtractAnswerDelta(data))
+		relay.citations.Feed(data)
+		if !send(data) {
+			relay.aborted = true
+			return false
+		}
+		relay.chunks = append(relay.chunks, data)
+		return true
+	})
+	return relay, err
+}
 
-	// 构建查询参数
-	queryParams := map[string]string{
-		"chatId":  chatId,
-		"shareId": shareId,
-		"appId":   app.AppId,
-	}
-	if outLinkUid != "" {
-		queryParams["outLinkUid"] = outLinkUid
-	}
+// replayStream 按原始顺序回放缓存的 data 块，前端无需区分是否命中缓存
+func replayStream(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, req dto.ChatCompletionRequest, msg chan<- *dto.SSEMessage, notifier sse.Notifier, chunks []string) {
+	var answer strings.Builder
+	defer func() {
+		recordTranscript(ctx, authInfo, app, req, answer.String(), true)
+		service.RecordUsage(ctx, authInfo, app, 0)
+	}()
 
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequestWithQuery("DELETE", "/core/chat/delHistory", queryParams)
-	if err != nil {
-		response.ServiceErr(r, err)
-		return
+	var (
+		citations service.CitationCollector
+		done      bool
+	)
+	for _, data := range chunks {
+		citations.Feed(data)
+		// [DONE] 和之后的 flowResponses 不转发，最后统一发送
+		if data == "[DONE]" {
+			done = true
+		}
+		if done {
+			continue
+		}
+		answer.WriteString(service.ExtractAnswerDelta(data))
+		if !sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: data}) {
+			service.RecordStreamAborted(ctx, app.ID)
+			return
+		}
 	}
-
-	if statusCode != http.StatusOK {
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
+	if !finishStream(notifier, msg, req, &citations) {
+		service.RecordStreamAborted(ctx, app.ID)
 	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
 }
 
-// HandleGetCollectionQuote 获取集合引用详情
-// 路由: POST /fastgpt/core/chat/quote/getCollectionQuote
-func HandleGetCollectionQuote(c flamego.Context, r flamego.Render, req dto.GetCollectionQuoteRequest, errs binding.Errors, authInfo auth.Info) {
-	if errs != nil {
-		response.InValidParam(r, errs)
-		return
-	}
+// finishStream 发送 citations 事件和结束标记
+func finishStream(notifier sse.Notifier, msg chan<- *dto.SSEMessage, req dto.ChatCompletionRequest, citations *service.CitationCollector) bool {
+	return sendCitations(notifier, msg, req, citations) && sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: "[DONE]"})
+}
 
-	app, err := dao.FastgptApp.GetAppByID(req.FastgptAppId)
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
+// sendCitations detail 模式下在 [DONE] 之前发送 citations 事件，汇总本次回答的引用来源，前端无需逐条查询
+func sendCitations(notifier sse.Notifier, msg chan<- *dto.SSEMessage, req dto.ChatCompletionRequest, citations *service.CitationCollector) bool {
+	if !req.Detail {
+		return true
 	}
-
-	respBody, statusCode, err := getFastGPTClient(app.APIKey).ForwardRequest("POST", "/core/chat/quote/getCollectionQuote", req)
+	data, err := json.Marshal(map[string]interface{}{"citations": citations.Citations()})
 	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.ServiceErr(r, err)
-		return
+		return true
 	}
+	return sendSSEMessage(notifier, msg, &dto.SSEMessage{Data: string(data), Event: "citations"})
+}
 
-	if statusCode != http.StatusOK {
-		logx.SystemLogger.CtxError(c.Request().Context(), "FastGPT API error: status=%d, body=%s", statusCode, string(respBody))
-		response.HTTPFail(r, 500001, "FastGPT API 调用失败")
-		return
+// lastQuestion 返回最后一条用户消息的文本，多模态消息不参与缓存
+func lastQuestion(messages []dto.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != model.ChatRoleUser {
+			continue
+		}
+		q, _ := messages[i].Content.(string)
+		return q
 	}
-
-	c.ResponseWriter().Header().Set("Content-Type", "application/json")
-	c.ResponseWriter().WriteHeader(http.StatusOK)
-	c.ResponseWriter().Write(respBody)
+	return ""
 }
@@ -0,0 +1,139 @@
+package v1
+
+import (
+	"HelpStudent/internal/app/fastgpt/service"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// detailStream FastGPT detail 模式的流式响应，flowResponses 在 [DONE] 之后
+const detailStream = `event: flowNodeStatus
+data: {"status":"running","name":"知识库搜索"}
+
+event: answer
+data: {"choices":[{"delta":{"content":"你好"},"index":0,"finish_reason":null}]}
+
+event: answer
+data: {"choices":[{"delta":{"content":"同学"},"index":0,"finish_reason":"stop"}]}
+
+event: answer
+data: [DONE]
+
+event: flowResponses
+data: [{"moduleName":"知识库搜索","quoteList":[{"id":"q1","q":"引用","sourceName":"讲义.pdf"}]},{"moduleName":"AI 对话","tokens":86,"toolDetail":[{"tokens":14}]}]
+
+`
+
+func TestRelayBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		detail     bool
+		failSend   int // 第几次发送失败，0 表示不失败
+		wantSent   []string
+		wantUsage  int64
+		wantDone   bool
+		wantAbort  bool
+		wantCites  int
+		wantAnswer string
+	}{
+		{
+			name:       "plain client",
+			body:       detailStream,
+			wantSent:   []string{"你好", "同学"},
+			wantUsage:  100,
+			wantDone:   true,
+			wantCites:  1,
+			wantAnswer: "你好同学",
+		},
+		{
+			name:       "detail client",
+			body:       detailStream,
+			detail:     true,
+			wantSent:   []string{"知识库搜索", "你好", "同学"},
+			wantUsage:  100,
+			wantDone:   true,
+			wantCites:  1,
+			wantAnswer: "你好同学",
+		},
+		{
+			name:       "no flowResponses",
+			body:       strings.SplitAfter(detailStream, "data: [DONE]\n\n")[0],
+			wantSent:   []string{"你好", "同学"},
+			wantUsage:  0,
+			wantDone:   true,
+			wantAnswer: "你好同学",
+		},
+		{
+			name:       "client disconnected",
+			body:       detailStream,
+			failSend:   2,
+			wantSent:   []string{"你好"},
+			wantAbort:  true,
+			wantAnswer: "你好同学",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			relay, err := relayBody(strings.NewReader(tt.body), tt.detail, &service.AnswerModerator{}, func(data string) bool {
+				if len(sent)+1 == tt.failSend {
+					return false
+				}
+				sent = append(sent, data)
+				return true
+			})
+			if err != nil {
+				t.Fatalf("relay failed: %v", err)
+			}
+			if len(sent) != len(tt.wantSent) {
+				t.Fatalf("sent: got %v, want %d chunks", sent, len(tt.wantSent))
+			}
+			for i, want := range tt.wantSent {
+				if !strings.Contains(sent[i], want) {
+					t.Errorf("chunk %d: got %s, want it to contain %q", i, sent[i], want)
+				}
+			}
+			if got := relay.usage.Total(); got != tt.wantUsage {
+				t.Errorf("usage: got %d, want %d", got, tt.wantUsage)
+			}
+			if relay.done != tt.wantDone || relay.aborted != tt.wantAbort {
+				t.Errorf("done/aborted: got %v/%v, want %v/%v", relay.done, relay.aborted, tt.wantDone, tt.wantAbort)
+			}
+			if got := len(relay.citations.Citations()); got != tt.wantCites {
+				t.Errorf("citations: got %d, want %d", got, tt.wantCites)
+			}
+			if got := relay.answer.String(); got != tt.wantAnswer {
+				t.Errorf("answer: got %q, want %q", got, tt.wantAnswer)
+			}
+		})
+	}
+}
+
+func TestRelayBody_ReadError(t *testing.T) {
+	// 回答未结束时上游断开
+	body := strings.SplitAfter(detailStream, "finish_reason\":null}]}\n\n")[0]
+	relay, err := relayBody(&failingReader{r: strings.NewReader(body)}, false, &service.AnswerModerator{}, func(string) bool { return true })
+	if !errors.Is(err, errUpstreamReset) {
+		t.Fatalf("err: got %v, want errUpstreamReset", err)
+	}
+	if relay.done {
+		t.Error("relay marked done without [DONE]")
+	}
+}
+
+var errUpstreamReset = errors.New("connection reset")
+
+// failingReader 读完内容后返回错误，模拟上游连接中断
+type failingReader struct {
+	r *strings.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.r.Len() == 0 {
+		return 0, errUpstreamReset
+	}
+	return f.r.Read(p)
+}
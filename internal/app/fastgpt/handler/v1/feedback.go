@@ -0,0 +1,249 @@
+package v1
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+	"github.com/xuri/excelize/v2"
+)
+
+// HandleSubmitFeedback 提交对某条回答的评价，重复提交会覆盖之前的评价
+func HandleSubmitFeedback(c flamego.Context, r flamego.Render, req dto.SubmitFeedbackRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if req.Rating != model.FeedbackLike && req.Rating != model.FeedbackDislike {
+		response.HTTPFail(r, 400019, "评价只能是 like 或 dislike")
+		return
+	}
+
+	app, ok := authorizeApp(c, r, authInfo, req.FastgptAppId)
+	if !ok {
+		return
+	}
+
+	feedback := &model.ChatFeedback{
+		UserId:  authInfo.Uid,
+		StaffId: authInfo.StaffId,
+		AppId:   app.ID,
+		ChatId:  req.ChatId,
+		DataId:  req.DataId,
+		Rating:  req.Rating,
+		Reason:  req.Reason,
+	}
+	if err := dao.Feedback.SaveFeedback(c.Request().Context(), feedback); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	if config.GetConfig().FastGPT.ForwardFeedback {
+		forwardFeedback(c.Request().Context(), app, req, authInfo.StaffId)
+	}
+
+	response.HTTPSuccess(r, nil)
+}
+
+// forwardFeedback 将评价同步到 FastGPT，失败只记录日志，本地评价仍然有效
+func forwardFeedback(ctx context.Context, app *model.FastgptApp, req dto.SubmitFeedbackRequest, staffId string) {
+	reason := req.Reason
+	if reason == "" {
+		reason = "yes"
+	}
+	fb := sdk.UpdateUserFeedbackRequest{
+		AppId:      app.AppId,
+		ChatId:     req.ChatId,
+		DataId:     req.DataId,
+		ShareId:    req.ShareId,
+		OutLinkUid: req.OutLinkUid,
+	}
+	if req.Rating == model.FeedbackLike {
+		fb.UserGoodFeedback = &reason
+	} else {
+		fb.UserBadFeedback = &reason
+	}
+
+	client, err := getSDKClient(app)
+	if err == nil {
+		err = client.UpdateUserFeedback(fb)
+	}
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, "forward feedback to fastgpt failed", err)
+		return
+	}
+	if err := dao.Feedback.MarkForwarded(ctx, staffId, app.ID, req.ChatId, req.DataId); err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+	}
+}
+
+// HandleGetFeedbackList 管理员按应用筛选评价列表
+func HandleGetFeedbackList(c flamego.Context, r flamego.Render, req dto.GetFeedbackListRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员无法查看评价")
+		return
+	}
+
+	filter, err := feedbackFilter(req)
+	if err != nil {
+		response.HTTPFail(r, 400001, "日期格式应为 2006-01-02")
+		return
+	}
+	list, total, err := dao.Feedback.ListFeedback(c.Request().Context(), filter, req.Offset, req.Limit)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, dto.FeedbackListResponse{
+		Feedbacks: feedbackItems(c.Request().Context(), list),
+		Total:     total,
+	})
+}
+
+// HandleExportFeedback 管理员导出评价为 Excel，筛选条件与列表接口相同，通过查询参数传入
+func HandleExportFeedback(c flamego.Context, r flamego.Render, w http.ResponseWriter, authInfo auth.Info) {
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员无法导出评价")
+		return
+	}
+
+	filter, err := feedbackFilter(dto.GetFeedbackListRequest{
+		FastgptAppId: c.Query("fastgptAppId"),
+		Rating:       c.Query("rating"),
+		StaffId:      c.Query("staffId"),
+		StartDate:    c.Query("startDate"),
+		EndDate:      c.Query("endDate"),
+	})
+	if err != nil {
+		response.HTTPFail(r, 400001, "日期格式应为 2006-01-02")
+		return
+	}
+	list, _, err := dao.Feedback.ListFeedback(c.Request().Context(), filter, 0, -1)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		_ = f.Close()
+	}()
+
+	sheetName := "回答评价"
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		response.ServiceErr(r, err)
+		return
+	}
+
+	// 设置表头
+	headers := []string{"应用", "学号", "会话ID", "消息ID", "评价", "原因", "已同步", "时间"}
+	for i, header := range headers {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, item := range feedbackItems(c.Request().Context(), list) {
+		row := []interface{}{item.AppName, item.StaffId, item.ChatId, item.DataId, item.Rating, item.Reason, item.Forwarded, item.UpdatedAt}
+		for j, value := range row {
+			cell := fmt.Sprintf("%c%d", 'A'+j, i+2)
+			f.SetCellValue(sheetName, cell, value)
+		}
+	}
+	f.SetColWidth(sheetName, "A", "D", 20)
+	f.SetColWidth(sheetName, "F", "F", 50)
+	f.SetColWidth(sheetName, "H", "H", 20)
+
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	// 设置响应头
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename=feedback.xlsx")
+	w.Header().Set("Content-Transfer-Encoding", "binary")
+
+	if err := f.Write(w); err != nil {
+		logx.SystemLogger.Error("写入Excel文件失败", err)
+	}
+}
+
+// feedbackFilter 将请求转换为查询条件，结束日期包含当天
+func feedbackFilter(req dto.GetFeedbackListRequest) (dao.FeedbackFilter, error) {
+	filter := dao.FeedbackFilter{
+		AppId:   req.FastgptAppId,
+		Rating:  req.Rating,
+		StaffId: req.StaffId,
+	}
+	if req.StartDate != "" {
+		start, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+		if err != nil {
+			return filter, err
+		}
+		filter.StartTime = &start
+	}
+	if req.EndDate != "" {
+		end, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+		if err != nil {
+			return filter, err
+		}
+		end = end.AddDate(0, 0, 1)
+		filter.EndTime = &end
+	}
+	return filter, nil
+}
+
+// feedbackItems 转换为 DTO 并补充应用名称
+func feedbackItems(ctx context.Context, list []model.ChatFeedback) []dto.FeedbackItem {
+	appNames := make(map[string]string)
+	items := make([]dto.FeedbackItem, 0, len(list))
+	for _, fb := range list {
+		name, ok := appNames[fb.AppId]
+		if !ok {
+			if app, err := dao.FastgptApp.GetAppByPrimaryID(ctx, fb.AppId); err == nil {
+				name = app.AppName
+			}
+			appNames[fb.AppId] = name
+		}
+		items = append(items, dto.FeedbackItem{
+			ID:        fb.ID,
+			AppId:     fb.AppId,
+			AppName:   name,
+			StaffId:   fb.StaffId,
+			ChatId:    fb.ChatId,
+			DataId:    fb.DataId,
+			Rating:    fb.Rating,
+			Reason:    fb.Reason,
+			Forwarded: fb.Forwarded,
+			UpdatedAt: fb.UpdatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return items
+}
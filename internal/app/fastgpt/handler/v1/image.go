@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+)
+
+// maxSignImages 单次最多签名的图片数
+const maxSignImages = 100
+
+// HandleGetImage 代理图片请求到 FastGPT
+// 路由: GET /api/system/img/:imageId?fastgptAppId=xxx&exp=xxx&sig=xxx，链接由 /fastgpt/images/sign 生成
+// 也可以携带登录的 Authorization 请求头直接访问，此时可用 ?shareId=xxx 指定应用
+func HandleGetImage(c flamego.Context, r flamego.Render) {
+	imageId := c.Param("imageId")
+	if imageId == "" {
+		response.HTTPFail(r, 400001, "缺少图片ID")
+		return
+	}
+
+	var backends []string
+	if sig := c.Query("sig"); sig != "" {
+		if err := service.VerifyImageSignature(imageId, c.Query("fastgptAppId"), c.Query("exp"), sig, time.Now()); err != nil {
+			response.HTTPFail(r, 403016, err.Error())
+			return
+		}
+		backends = service.ImageBackends(c.Query("fastgptAppId"), "")
+	} else {
+		if !bearerAuthorized(c.Request().Header.Get("Authorization")) {
+			response.HTTPFail(r, 403016, service.ErrImageSignature.Error())
+			return
+		}
+		backends = service.ImageBackends(c.Query("fastgptAppId"), c.Query("shareId"))
+	}
+
+	img, err := service.GetImage(c.Request().Context(), backends, imageId, c.Request().Header.Get("User-Agent"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImageTooLarge):
+			response.HTTPFail(r, 413001, fmt.Sprintf("图片不能超过 %dMB", service.ImageSettings().MaxSize))
+		case errors.Is(err, service.ErrImageType):
+			response.HTTPFail(r, 415001, err.Error())
+		default:
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+			response.HTTPFail(r, 500001, "FastGPT API 调用失败")
+		}
+		return
+	}
+
+	// 设置响应头，访问受控，只允许浏览器缓存
+	header := c.ResponseWriter().Header()
+	header.Set("ETag", img.ETag)
+	header.Set("Cache-Control", "private, max-age=86400")
+	header.Set("X-Content-Type-Options", "nosniff")
+	if etagMatch(c.Request().Header.Get("If-None-Match"), img.ETag) {
+		c.ResponseWriter().WriteHeader(http.StatusNotModified)
+		return
+	}
+	header.Set("Content-Type", img.ContentType)
+	header.Set("Content-Length", strconv.Itoa(len(img.Data)))
+
+	// 将图片内容写入响应
+	c.ResponseWriter().WriteHeader(http.StatusOK)
+	c.ResponseWriter().Write(img.Data)
+}
+
+// HandleSignImages 为当前用户可以使用的应用中的图片生成签名链接，用于 <img> 等无法携带请求头的场景
+func HandleSignImages(c flamego.Context, r flamego.Render, req dto.SignImagesRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if len(req.ImageIds) > maxSignImages {
+		response.HTTPFail(r, 400001, fmt.Sprintf("一次最多签名 %d 张图片", maxSignImages))
+		return
+	}
+	app, ok := authorizeApp(c, r, authInfo, req.FastgptAppId)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	urls := make(map[string]string, len(req.ImageIds))
+	for _, id := range req.ImageIds {
+		id = strings.TrimPrefix(id, "/api/system/img/")
+		if id == "" || strings.Contains(id, "/") {
+			continue
+		}
+		urls[id] = service.SignImageURL(id, app.ID, now)
+	}
+
+	response.HTTPSuccess(r, dto.SignImagesResponse{URLs: urls})
+}
+
+// bearerAuthorized 检查登录的 Authorization 请求头
+func bearerAuthorized(header string) bool {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	_, err := auth.ParseToken(token)
+	return err == nil
+}
+
+// etagMatch 检查 If-None-Match 是否包含 etag
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/ingest"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+	"gorm.io/gorm"
+)
+
+// HandleUploadCourseFile 管理员上传课程文件导入知识库
+// 表单字段: file、fastgptAppId、datasetId，可选 trainingType、chunkSize、chunkOverlap
+// 文本在请求内提取，提取失败直接返回；分块推送在后台进行，通过任务接口查看进度
+func HandleUploadCourseFile(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法导入文件")
+		return
+	}
+
+	cfg := ingest.Settings()
+	req := c.Request()
+	datasetId := req.FormValue("datasetId")
+	if datasetId == "" {
+		response.HTTPFail(r, 400001, "缺少必要参数 datasetId")
+		return
+	}
+	app, ok := authorizeApp(c, r, authInfo, req.FormValue("fastgptAppId"))
+	if !ok {
+		return
+	}
+
+	// 从 FormFile 获取文件
+	file, header, err := req.FormFile("file")
+	if err != nil {
+		response.HTTPFail(r, 400002, "获取上传文件失败")
+		return
+	}
+	defer func(file multipart.File) {
+		_ = file.Close()
+	}(file)
+
+	// 检查文件类型和大小
+	fileType := ingest.FileType(header.Filename)
+	if fileType == "" {
+		response.HTTPFail(r, 400003, "仅支持 PDF、DOCX、PPTX、XLSX、Markdown 和 TXT 文件")
+		return
+	}
+	maxSize := cfg.MaxFileSize << 20
+	if header.Size > maxSize {
+		response.HTTPFail(r, 400024, fmt.Sprintf("文件不能超过 %dMB", cfg.MaxFileSize))
+		return
+	}
+
+	// 读取文件内容
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.HTTPFail(r, 400004, "读取文件内容失败")
+		return
+	}
+	if int64(len(data)) > maxSize {
+		response.HTTPFail(r, 400024, fmt.Sprintf("文件不能超过 %dMB", cfg.MaxFileSize))
+		return
+	}
+
+	// 分块参数，未传时使用配置
+	chunkSize, chunkOverlap := cfg.ChunkSize, cfg.ChunkOverlap
+	if v := req.FormValue("chunkSize"); v != "" {
+		if chunkSize, err = strconv.Atoi(v); err != nil {
+			chunkSize = 0
+		}
+	}
+	if v := req.FormValue("chunkOverlap"); v != "" {
+		if chunkOverlap, err = strconv.Atoi(v); err != nil {
+			chunkOverlap = -1
+		}
+	}
+	if chunkSize < 100 || chunkSize > 8000 || chunkOverlap < 0 || chunkOverlap >= chunkSize {
+		response.HTTPFail(r, 400026, "chunkSize 应在 100-8000 之间，chunkOverlap 不能为负且应小于 chunkSize")
+		return
+	}
+	trainingType := req.FormValue("trainingType")
+	if trainingType == "" {
+		trainingType = "chunk"
+	}
+
+	text, err := ingest.ExtractText(fileType, data)
+	if err != nil {
+		response.HTTPFail(r, 400025, "提取文本失败: "+err.Error())
+		return
+	}
+
+	// 保存原始文件
+	storageKey, err := ingest.Store(app.ID, header.Filename, data)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	job := &model.IngestJob{
+		AppId:        app.ID,
+		DatasetId:    datasetId,
+		FileName:     header.Filename,
+		FileType:     fileType,
+		FileSize:     int64(len(data)),
+		StorageKey:   storageKey,
+		ChunkSize:    chunkSize,
+		ChunkOverlap: chunkOverlap,
+		Content:      text,
+		TrainingType: trainingType,
+		Status:       model.IngestPending,
+		CreatedBy:    authInfo.StaffId,
+	}
+	if err := dao.Ingest.CreateJob(c.Request().Context(), job); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if err := ingest.Enqueue(c.Request().Context(), job); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, ingestJobItem(*job))
+}
+
+// HandlePushDataAsync 将大批量数据推送放入后台任务，立即返回任务ID
+func HandlePushDataAsync(c flamego.Context, r flamego.Render, req dto.PushDataRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法推送数据")
+		return
+	}
+	app, ok := authorizeApp(c, r, authInfo, req.FastgptAppId)
+	if !ok {
+		return
+	}
+
+	data := make([]sdk.DataItem, 0, len(req.Data))
+	for _, item := range req.Data {
+		data = append(data, sdk.DataItem{Q: item.Q, A: item.A, Indexes: item.Indexes})
+	}
+	job, err := ingest.EnqueuePushData(c.Request().Context(), app.ID, authInfo.StaffId, sdk.PushDataRequest{
+		CollectionId: req.CollectionId,
+		TrainingType: req.TrainingType,
+		Data:         data,
+	})
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, dto.AsyncJobResponse{JobId: job.ID})
+}
+
+// HandleGetIngestJob 管理员查看导入任务进度
+// 路由: GET /fastgpt/ingest/jobs/detail?id=xxx
+func HandleGetIngestJob(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法查看导入任务")
+		return
+	}
+	id := c.Query("id")
+	if id == "" {
+		response.HTTPFail(r, 400001, "缺少必要参数 id")
+		return
+	}
+
+	job, err := dao.Ingest.GetJob(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "导入任务不存在")
+			return
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, ingestJobItem(*job))
+}
+
+// HandleGetIngestJobList 管理员分页查看导入任务
+func HandleGetIngestJobList(c flamego.Context, r flamego.Render, req dto.GetIngestJobListRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法查看导入任务")
+		return
+	}
+
+	jobs, total, err := dao.Ingest.ListJobs(c.Request().Context(), req.FastgptAppId, req.Offset, req.Limit)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	items := make([]dto.IngestJobItem, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, ingestJobItem(job))
+	}
+
+	response.HTTPSuccess(r, dto.IngestJobListResponse{Jobs: items, Total: total})
+}
+
+func ingestJobItem(job model.IngestJob) dto.IngestJobItem {
+	item := dto.IngestJobItem{
+		ID:           job.ID,
+		AppId:        job.AppId,
+		DatasetId:    job.DatasetId,
+		CollectionId: job.CollectionId,
+		FileName:     job.FileName,
+		FileType:     job.FileType,
+		FileSize:     job.FileSize,
+		ChunkSize:    job.ChunkSize,
+		ChunkOverlap: job.ChunkOverlap,
+		JobId:        job.JobId,
+		Status:       job.Status,
+		TotalChunks:  job.TotalChunks,
+		PushedChunks: job.PushedChunks,
+		Error:        job.Error,
+		CreatedBy:    job.CreatedBy,
+		CreatedAt:    job.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if job.TotalChunks > 0 {
+		item.Progress = float64(job.PushedChunks) * 100 / float64(job.TotalChunks)
+	}
+	if job.FinishedAt != nil {
+		item.FinishedAt = job.FinishedAt.Format("2006-01-02 15:04:05")
+	}
+	return item
+}
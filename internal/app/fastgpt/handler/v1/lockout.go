@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/service"
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// lockoutVariableKey restrict 时段的提示词同时写入的变量名，供工作流引用
+const lockoutVariableKey = "lockoutPrompt"
+
+// applyLockoutPrompt 在 restrict 时段注入限制提示词，返回的文本用于区分缓存
+func applyLockoutPrompt(text string, req *dto.ChatCompletionRequest) string {
+	if text == "" {
+		return ""
+	}
+	messages := make([]dto.Message, 0, len(req.Messages)+1)
+	messages = append(messages, dto.Message{Role: model.ChatRoleSystem, Content: text})
+	req.Messages = append(messages, req.Messages...)
+
+	variables := make(map[string]interface{}, len(req.Variables)+1)
+	for k, v := range req.Variables {
+		variables[k] = v
+	}
+	variables[lockoutVariableKey] = text
+	req.Variables = variables
+	return text
+}
+
+// checkLockout 检查科目限制时段，读取失败时不影响对话
+func checkLockout(ctx context.Context, authInfo auth.Info, app *model.FastgptApp) (string, *service.LockoutError) {
+	prompt, err := service.CheckLockout(ctx, authInfo, app)
+	if err == nil {
+		return prompt, nil
+	}
+	var lockErr *service.LockoutError
+	if errors.As(err, &lockErr) {
+		return "", lockErr
+	}
+	logx.SystemLogger.CtxError(ctx, "check lockout failed", app.ID, err)
+	return "", nil
+}
+
+// lockoutErrMessage 将限制时段错误转换为 SSE 错误消息
+func lockoutErrMessage(err *service.LockoutError) string {
+	body := map[string]interface{}{
+		"error": err.Message,
+		"code":  service.LockoutCode,
+	}
+	if !err.Until.IsZero() {
+		body["until"] = err.Until.Format("2006-01-02 15:04:05")
+	}
+	data, _ := json.Marshal(body)
+	return string(data)
+}
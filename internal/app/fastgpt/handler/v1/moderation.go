@@ -0,0 +1,469 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/service"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+	"gorm.io/gorm"
+)
+
+// moderatePrompt 检查最后一条用户消息，命中 mask 词表时返回替换后的消息
+// 历史消息在提出时已经检查过，不重复记录
+func moderatePrompt(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, messages []dto.Message) ([]dto.Message, bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != model.ChatRoleUser {
+			continue
+		}
+		content, blocked := moderateContent(ctx, authInfo, app.ID, messages[i].Content)
+		if blocked {
+			return messages, true
+		}
+		masked := make([]dto.Message, len(messages))
+		copy(masked, messages)
+		masked[i].Content = content
+		return masked, false
+	}
+	return messages, false
+}
+
+// moderateContent 检查消息内容，多模态内容只检查文本部分
+func moderateContent(ctx context.Context, authInfo auth.Info, appId string, content interface{}) (interface{}, bool) {
+	switch v := content.(type) {
+	case string:
+		result := service.Moderate(ctx, authInfo, appId, model.ModerationPrompt, v)
+		return result.Text, result.Blocked
+	case []interface{}:
+		parts := make([]interface{}, len(v))
+		for i, item := range v {
+			parts[i] = item
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			text, ok := m["text"].(string)
+			if !ok {
+				continue
+			}
+			result := service.Moderate(ctx, authInfo, appId, model.ModerationPrompt, text)
+			if result.Blocked {
+				return content, true
+			}
+			part := make(map[string]interface{}, len(m))
+			for k, val := range m {
+				part[k] = val
+			}
+			part["text"] = result.Text
+			parts[i] = part
+		}
+		return parts, false
+	}
+	return content, false
+}
+
+// moderateStreamData 检查流式 data 块中的回答增量，被替换或暂存时重写 choices[0].delta.content
+// 回答的最后一块同时带上检查器暂存的尾部
+func moderateStreamData(moderator *service.AnswerModerator, data string) (string, bool) {
+	delta := service.ExtractAnswerDelta(data)
+	result := moderator.Feed(delta)
+	if result.Blocked {
+		return data, true
+	}
+	if service.IsAnswerFinished(data) {
+		result.Text += moderator.Flush()
+	}
+	if result.Text == delta {
+		return data, false
+	}
+
+	var chunk map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return data, false
+	}
+	choices, _ := chunk["choices"].([]interface{})
+	if len(choices) == 0 {
+		return data, false
+	}
+	choice, _ := choices[0].(map[string]interface{})
+	if choice == nil {
+		return data, false
+	}
+	d, _ := choice["delta"].(map[string]interface{})
+	if d == nil {
+		d = make(map[string]interface{})
+		choice["delta"] = d
+	}
+	d["content"] = result.Text
+	b, err := json.Marshal(chunk)
+	if err != nil {
+		return data, false
+	}
+	return string(b), false
+}
+
+// flushStreamData 上游没有发送带 finish_reason 的块就结束时，将检查器暂存的尾部包装为一个 data 块
+func flushStreamData(moderator *service.AnswerModerator) string {
+	rest := moderator.Flush()
+	if rest == "" {
+		return ""
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		"choices": []interface{}{map[string]interface{}{"index": 0, "delta": map[string]interface{}{"content": rest}}},
+	})
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// validModeration 校验词表的处理方式和检查范围
+func validModeration(action, scope string) bool {
+	switch action {
+	case model.ModerationBlock, model.ModerationMask, model.ModerationLog:
+	default:
+		return false
+	}
+	switch scope {
+	case model.ModerationPrompt, model.ModerationAnswer, model.ModerationBoth:
+	default:
+		return false
+	}
+	return true
+}
+
+// cleanWords 去掉空白和重复的敏感词
+func cleanWords(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	cleaned := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.TrimSpace(w)
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		cleaned = append(cleaned, w)
+	}
+	return cleaned
+}
+
+// HandleCreateModerationList 管理员创建敏感词表
+func HandleCreateModerationList(c flamego.Context, r flamego.Render, req dto.CreateModerationListRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理敏感词")
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = model.ModerationBoth
+	}
+	if !validModeration(req.Action, req.Scope) {
+		response.HTTPFail(r, 400022, "action 只能是 block、mask 或 log，scope 只能是 prompt、answer 或 both")
+		return
+	}
+
+	ctx := c.Request().Context()
+	exists, err := dao.Moderation.CheckListNameExists(ctx, req.Name, "")
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if exists {
+		response.HTTPFail(r, 400014, "词表名称已存在")
+		return
+	}
+
+	list := &model.ModerationList{
+		Name:      req.Name,
+		Action:    req.Action,
+		Scope:     req.Scope,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		CreatedBy: authInfo.StaffId,
+	}
+	if err := dao.Moderation.CreateList(ctx, list); err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if err := dao.Moderation.AddWords(ctx, list.ID, cleanWords(req.Words)); err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+	service.ReloadModeration()
+
+	response.HTTPSuccess(r, moderationListItem(*list))
+}
+
+// HandleUpdateModerationList 管理员修改词表名称、处理方式、范围或启用状态
+func HandleUpdateModerationList(c flamego.Context, r flamego.Render, req dto.UpdateModerationListRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理敏感词")
+		return
+	}
+
+	ctx := c.Request().Context()
+	list, err := dao.Moderation.GetList(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "词表不存在")
+			return
+		}
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil && *req.Name != list.Name {
+		exists, err := dao.Moderation.CheckListNameExists(ctx, *req.Name, list.ID)
+		if err != nil {
+			logx.SystemLogger.CtxError(ctx, err)
+			response.ServiceErr(r, err)
+			return
+		}
+		if exists {
+			response.HTTPFail(r, 400014, "词表名称已存在")
+			return
+		}
+		updates["name"] = *req.Name
+	}
+	action, scope := list.Action, list.Scope
+	if req.Action != nil {
+		action = *req.Action
+		updates["action"] = action
+	}
+	if req.Scope != nil {
+		scope = *req.Scope
+		updates["scope"] = scope
+	}
+	if !validModeration(action, scope) {
+		response.HTTPFail(r, 400022, "action 只能是 block、mask 或 log，scope 只能是 prompt、answer 或 both")
+		return
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if len(updates) == 0 {
+		response.HTTPSuccess(r, nil)
+		return
+	}
+
+	if _, err := dao.Moderation.UpdateList(ctx, list.ID, updates); err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+	service.ReloadModeration()
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleDeleteModerationList 管理员删除词表
+func HandleDeleteModerationList(c flamego.Context, r flamego.Render, req dto.ModerationListIdRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理敏感词")
+		return
+	}
+
+	affected, err := dao.Moderation.DeleteList(c.Request().Context(), req.ID)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if affected == 0 {
+		response.HTTPFail(r, 404001, "词表不存在")
+		return
+	}
+	service.ReloadModeration()
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleGetModerationLists 管理员获取全部词表
+func HandleGetModerationLists(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理敏感词")
+		return
+	}
+
+	lists, err := dao.Moderation.ListLists(c.Request().Context())
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	items := make([]dto.ModerationListItem, 0, len(lists))
+	for _, list := range lists {
+		items = append(items, moderationListItem(list))
+	}
+
+	response.HTTPSuccess(r, items)
+}
+
+// HandleGetModerationWords 管理员查看词表中的敏感词
+func HandleGetModerationWords(c flamego.Context, r flamego.Render, req dto.ModerationListIdRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理敏感词")
+		return
+	}
+
+	words, err := dao.Moderation.GetWords(c.Request().Context(), req.ID)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, dto.ModerationWordsResponse{ListId: req.ID, Words: words})
+}
+
+// HandleAddModerationWords 管理员向词表添加敏感词
+func HandleAddModerationWords(c flamego.Context, r flamego.Render, req dto.ModerationWordsRequest, errs binding.Errors, authInfo auth.Info) {
+	handleModerationWords(c, r, req, errs, authInfo, dao.Moderation.AddWords)
+}
+
+// HandleDeleteModerationWords 管理员从词表删除敏感词
+func HandleDeleteModerationWords(c flamego.Context, r flamego.Render, req dto.ModerationWordsRequest, errs binding.Errors, authInfo auth.Info) {
+	handleModerationWords(c, r, req, errs, authInfo, dao.Moderation.DeleteWords)
+}
+
+// handleModerationWords 添加和删除敏感词的公共流程
+func handleModerationWords(c flamego.Context, r flamego.Render, req dto.ModerationWordsRequest, errs binding.Errors, authInfo auth.Info, apply func(context.Context, string, []string) error) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理敏感词")
+		return
+	}
+
+	ctx := c.Request().Context()
+	if _, err := dao.Moderation.GetList(ctx, req.ListId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "词表不存在")
+			return
+		}
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+	words := cleanWords(req.Words)
+	if len(words) == 0 {
+		response.HTTPFail(r, 400001, "敏感词不能为空")
+		return
+	}
+	if err := apply(ctx, req.ListId, words); err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+	service.ReloadModeration()
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleReloadModeration 管理员手动刷新词表缓存，例如直接修改数据库之后
+func HandleReloadModeration(r flamego.Render, authInfo auth.Info) {
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理敏感词")
+		return
+	}
+	service.ReloadModeration()
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleGetModerationEvents 管理员查看敏感词命中记录
+func HandleGetModerationEvents(c flamego.Context, r flamego.Render, req dto.GetModerationEventsRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员无法查看命中记录")
+		return
+	}
+
+	filter := dao.ModerationEventFilter{
+		AppId:   req.FastgptAppId,
+		StaffId: req.StaffId,
+		Action:  req.Action,
+	}
+	if req.StartDate != "" || req.EndDate != "" {
+		dates, err := feedbackFilter(dto.GetFeedbackListRequest{StartDate: req.StartDate, EndDate: req.EndDate})
+		if err != nil {
+			response.HTTPFail(r, 400001, "日期格式应为 2006-01-02")
+			return
+		}
+		filter.StartTime, filter.EndTime = dates.StartTime, dates.EndTime
+	}
+
+	events, total, err := dao.Moderation.ListEvents(c.Request().Context(), filter, req.Offset, req.Limit)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	items := make([]dto.ModerationEventItem, 0, len(events))
+	for _, e := range events {
+		items = append(items, dto.ModerationEventItem{
+			ID:        e.ID,
+			StaffId:   e.StaffId,
+			AppId:     e.AppId,
+			ListName:  e.ListName,
+			Action:    e.Action,
+			Direction: e.Direction,
+			Terms:     e.Terms,
+			Excerpt:   e.Excerpt,
+			CreatedAt: e.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	response.HTTPSuccess(r, dto.ModerationEventListResponse{Events: items, Total: total})
+}
+
+func moderationListItem(list model.ModerationList) dto.ModerationListItem {
+	return dto.ModerationListItem{
+		ID:        list.ID,
+		Name:      list.Name,
+		Action:    list.Action,
+		Scope:     list.Scope,
+		Enabled:   list.Enabled,
+		CreatedBy: list.CreatedBy,
+		CreatedAt: list.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
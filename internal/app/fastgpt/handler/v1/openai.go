@@ -0,0 +1,358 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+	"github.com/oklog/ulid/v2"
+)
+
+// openAIError 按 OpenAI 的错误结构返回
+func openAIError(r flamego.Render, status int, message, errType, code string) {
+	r.JSON(status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    errType,
+			"param":   nil,
+			"code":    code,
+		},
+	})
+}
+
+// APIKeyAuthorization 使用个人 API Key 鉴权，供 OpenAI 兼容接口使用
+func APIKeyAuthorization(c flamego.Context, r flamego.Render) {
+	token := c.Request().Header.Get("Authorization")
+	if !strings.HasPrefix(token, "Bearer ") {
+		openAIError(r, http.StatusUnauthorized, "缺少 API Key", "invalid_request_error", "invalid_api_key")
+		return
+	}
+	authInfo, err := service.AuthenticateAPIKey(c.Request().Context(), strings.TrimPrefix(token, "Bearer "))
+	if err != nil {
+		if !errors.Is(err, service.ErrInvalidAPIKey) {
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+		}
+		openAIError(r, http.StatusUnauthorized, "无效的 API Key", "invalid_request_error", "invalid_api_key")
+		return
+	}
+	c.Map(authInfo)
+}
+
+// HandleOpenAIListModels 列出当前用户可以使用的模型（即 FastGPT 应用）
+// 路由: GET /v1/models
+func HandleOpenAIListModels(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+	apps, _, err := dao.FastgptApp.GetAllApps(c.Request().Context(), 0, -1)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		openAIError(r, http.StatusInternalServerError, "内部异常", "server_error", "")
+		return
+	}
+
+	models := make([]dto.OpenAIModel, 0, len(apps))
+	for i := range apps {
+		ok, err := service.CanUseApp(authInfo, &apps[i])
+		if err != nil {
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		models = append(models, dto.OpenAIModel{
+			ID:      apps[i].AppName,
+			Object:  "model",
+			Created: apps[i].CreatedAt.Unix(),
+			OwnedBy: "fastgpt",
+		})
+	}
+
+	r.JSON(http.StatusOK, dto.OpenAIModelList{Object: "list", Data: models})
+}
+
+// HandleOpenAIChatCompletion OpenAI 兼容的对话接口，stream 为 true 时按 OpenAI SSE 格式输出
+// 路由: POST /v1/chat/completions
+func HandleOpenAIChatCompletion(c flamego.Context, r flamego.Render, req dto.OpenAIChatCompletionRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		openAIError(r, http.StatusBadRequest, "请求校验失败", "invalid_request_error", "")
+		return
+	}
+
+	app, err := service.AuthorizeAppByModel(authInfo, req.Model)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAppNotFound):
+			openAIError(r, http.StatusNotFound, fmt.Sprintf("模型 %s 不存在", req.Model), "invalid_request_error", "model_not_found")
+		case errors.Is(err, service.ErrAppForbidden):
+			openAIError(r, http.StatusForbidden, "无权使用该模型", "invalid_request_error", "model_not_found")
+		default:
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+			openAIError(r, http.StatusInternalServerError, "内部异常", "server_error", "")
+		}
+		return
+	}
+
+	lockoutPrompt, lockErr := checkLockout(c.Request().Context(), authInfo, app)
+	if lockErr != nil {
+		openAIError(r, http.StatusForbidden, lockErr.Message, "permission_error", "subject_locked")
+		return
+	}
+
+	messages, blocked := moderatePrompt(c.Request().Context(), authInfo, app, req.Messages)
+	if blocked {
+		openAIError(r, http.StatusBadRequest, "提问包含敏感内容", "invalid_request_error", "content_filter")
+		return
+	}
+
+	messages, err = resolveAttachments(c.Request().Context(), authInfo, app, messages)
+	if err != nil {
+		if errors.Is(err, service.ErrAttachmentInvalid) {
+			openAIError(r, http.StatusBadRequest, err.Error(), "invalid_request_error", "invalid_attachment")
+			return
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		openAIError(r, http.StatusInternalServerError, "内部异常", "server_error", "")
+		return
+	}
+
+	chatReq := dto.ChatCompletionRequest{
+		FastgptAppId: app.ID,
+		ChatId:       req.ChatId,
+		Stream:       req.Stream,
+		Variables:    req.Variables,
+		Messages:     messages,
+		CustomUid:    req.User,
+	}
+	applyPromptTemplate(c.Request().Context(), authInfo, app, &chatReq)
+	applyLockoutPrompt(lockoutPrompt, &chatReq)
+
+	// 请求校验通过后才占用配额
+	if err := service.CheckQuota(c.Request().Context(), authInfo, app); err != nil {
+		var quotaErr *service.QuotaError
+		if errors.As(err, &quotaErr) {
+			openAIError(r, http.StatusTooManyRequests, quotaErr.Message, "insufficient_quota", "rate_limit_exceeded")
+			return
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		openAIError(r, http.StatusInternalServerError, "内部异常", "server_error", "")
+		return
+	}
+
+	if req.Stream {
+		streamOpenAIChatCompletion(c, r, authInfo, app, req.Model, chatReq)
+		return
+	}
+
+	client, err := getSDKClient(app)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		_, msg := upstreamErr(err)
+		openAIError(r, http.StatusServiceUnavailable, msg, "api_error", "")
+		return
+	}
+	resp, err := client.ChatCompletions(c.Request().Context(), newChatRequest(chatReq))
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		_, msg := upstreamErr(err)
+		openAIError(r, http.StatusBadGateway, msg, "api_error", "")
+		return
+	}
+
+	service.RecordUsage(c.Request().Context(), authInfo, app, resp.TotalTokens())
+	result := service.Moderate(c.Request().Context(), authInfo, app.ID, model.ModerationAnswer, resp.Answer())
+	answer := result.Text
+	if result.Blocked {
+		answer = ""
+	}
+	recordTranscript(c.Request().Context(), authInfo, app, chatReq, answer, false)
+
+	completion := dto.OpenAIChatCompletion{
+		ID:      openAICompletionID(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+		Choices: []dto.OpenAIChoice{{
+			Index:        0,
+			Message:      dto.Message{Role: model.ChatRoleAssistant, Content: answer},
+			FinishReason: "stop",
+		}},
+		Usage: dto.OpenAIUsage{TotalTokens: resp.TotalTokens()},
+	}
+	if len(resp.Choices) > 0 && resp.Choices[0].FinishReason != "" {
+		completion.Choices[0].FinishReason = resp.Choices[0].FinishReason
+	}
+	if result.Blocked {
+		completion.Choices[0].FinishReason = "content_filter"
+	}
+	if resp.Usage != nil {
+		completion.Usage = dto.OpenAIUsage(*resp.Usage)
+	}
+	r.JSON(http.StatusOK, completion)
+}
+
+// streamOpenAIChatCompletion 转发流式对话，将 FastGPT 的数据块改写为标准的 chat.completion.chunk
+func streamOpenAIChatCompletion(c flamego.Context, r flamego.Render, authInfo auth.Info, app *model.FastgptApp, modelName string, req dto.ChatCompletionRequest) {
+	// 直接写响应，请求的 context 在客户端断开后即被取消
+	ctx := c.Request().Context()
+	client, err := getSDKClient(app)
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		_, msg := upstreamErr(err)
+		openAIError(r, http.StatusServiceUnavailable, msg, "api_error", "")
+		return
+	}
+	// 上游使用 detail 模式，回答结束后读取 flowResponses 统计 token 用量，再向客户端发送 [DONE]
+	upstream := newChatRequest(req)
+	upstream.Detail = true
+	resp, err := client.StreamChatCompletions(ctx, upstream)
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		_, msg := upstreamErr(err)
+		openAIError(r, http.StatusBadGateway, msg, "api_error", "")
+		return
+	}
+	defer resp.Body.Close()
+
+	w := c.ResponseWriter()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var answer strings.Builder
+	var usage service.UsageCollector
+	var failed bool // 上游读取出错，回答不完整，不保存
+	defer func() {
+		if !failed {
+			recordTranscript(ctx, authInfo, app, req, answer.String(), true)
+		}
+		service.RecordUsage(context.WithoutCancel(ctx), authInfo, app, usage.Total())
+	}()
+
+	id := openAICompletionID()
+	created := time.Now().Unix()
+	moderator := service.NewAnswerModerator(ctx, authInfo, app.ID)
+	var done, blocked, aborted bool
+	err = service.ScanChatStream(resp.Body, func(event, data string) bool {
+		if data == "[DONE]" {
+			done = true
+			return true
+		}
+		if done {
+			// [DONE] 之后只有 flowResponses，用于统计 token
+			if event == "flowResponses" || strings.HasPrefix(data, "[") {
+				usage.Feed(data)
+				return false
+			}
+			return true
+		}
+		usage.Feed(data)
+		if !service.IsPlainStreamEvent(event) {
+			return true
+		}
+
+		var chunk dto.OpenAIChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil || len(chunk.Choices) == 0 {
+			return true
+		}
+		chunk.ID = id
+		chunk.Object = "chat.completion.chunk"
+		chunk.Created = created
+		chunk.Model = modelName
+
+		// 命中 block 词表时以 content_filter 结束回答
+		result := moderator.Feed(chunk.Choices[0].Delta.Content)
+		if result.Blocked {
+			reason := "content_filter"
+			chunk.Choices = []dto.OpenAIChunkChoice{{FinishReason: &reason}}
+			if writeOpenAIEvent(w, chunk) {
+				_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+				w.Flush()
+			}
+			blocked = true
+			return false
+		}
+		// 最后一块同时输出检查器暂存的尾部
+		if chunk.Choices[0].FinishReason != nil {
+			result.Text += moderator.Flush()
+		}
+		chunk.Choices[0].Delta.Content = result.Text
+		answer.WriteString(result.Text)
+
+		if !writeOpenAIEvent(w, chunk) {
+			aborted = true
+			return false
+		}
+		return true
+	})
+	if blocked {
+		return
+	}
+	if aborted {
+		service.RecordStreamAborted(ctx, app.ID)
+		return
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			service.RecordStreamAborted(ctx, app.ID)
+			return
+		}
+		if !done {
+			failed = true
+			logx.SystemLogger.CtxError(ctx, "Stream read error", err)
+			return
+		}
+		// 回答已完整，只是没有读到 flowResponses
+		logx.SystemLogger.CtxError(ctx, "read flowResponses failed", err)
+	}
+
+	// 上游没有发送带 finish_reason 的块时，在结束前输出暂存的尾部
+	if rest := moderator.Flush(); rest != "" {
+		answer.WriteString(rest)
+		chunk := dto.OpenAIChatCompletionChunk{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   modelName,
+			Choices: []dto.OpenAIChunkChoice{{}},
+		}
+		chunk.Choices[0].Delta.Content = rest
+		if !writeOpenAIEvent(w, chunk) {
+			service.RecordStreamAborted(ctx, app.ID)
+			return
+		}
+	}
+
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	w.Flush()
+}
+
+// writeOpenAIEvent 写入一个 SSE 数据块，客户端断开时返回 false
+func writeOpenAIEvent(w flamego.ResponseWriter, chunk dto.OpenAIChatCompletionChunk) bool {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return true
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+		return false
+	}
+	w.Flush()
+	return true
+}
+
+// openAICompletionID 生成 OpenAI 风格的对话 ID
+func openAICompletionID() string {
+	return "chatcmpl-" + strings.ToLower(ulid.Make().String())
+}
@@ -0,0 +1,231 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/service"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+	"gorm.io/gorm"
+)
+
+// maxPromptLength 模板最大字符数
+const maxPromptLength = 8000
+
+// applyPromptTemplate 按应用生效的模板注入提示词，返回渲染后的文本用于区分缓存
+// FastGPT 在传入 chatId 时只使用最后一条消息，需要保留会话的应用应使用 variables 方式
+func applyPromptTemplate(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, req *dto.ChatCompletionRequest) string {
+	tpl, err := service.ActivePromptTemplate(ctx, app.ID)
+	if err != nil {
+		// 模板读取失败不影响对话
+		logx.SystemLogger.CtxError(ctx, "load prompt template failed", app.ID, err)
+		return ""
+	}
+	if tpl == nil {
+		return ""
+	}
+
+	text := service.RenderPromptTemplate(tpl.Content, authInfo, app, time.Now())
+	switch tpl.Mode {
+	case model.PromptModeSystem:
+		messages := make([]dto.Message, 0, len(req.Messages)+1)
+		messages = append(messages, dto.Message{Role: model.ChatRoleSystem, Content: text})
+		req.Messages = append(messages, req.Messages...)
+	case model.PromptModeVariables:
+		variables := make(map[string]interface{}, len(req.Variables)+1)
+		for k, v := range req.Variables {
+			variables[k] = v
+		}
+		variables[tpl.VariableKey] = text
+		req.Variables = variables
+	}
+	return text
+}
+
+// HandleGetPromptTemplate 管理员查看应用的提示词模板及历史版本
+func HandleGetPromptTemplate(c flamego.Context, r flamego.Render, req dto.PromptAppRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理提示词模板")
+		return
+	}
+	if _, ok := loadPromptApp(c, r, req.FastgptAppId); !ok {
+		return
+	}
+
+	tpls, err := dao.Prompt.ListVersions(c.Request().Context(), req.FastgptAppId)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	resp := dto.PromptTemplateResponse{
+		Versions:     make([]dto.PromptTemplateItem, 0, len(tpls)),
+		Placeholders: service.PromptPlaceholders,
+	}
+	for _, tpl := range tpls {
+		resp.Versions = append(resp.Versions, promptTemplateItem(tpl))
+	}
+	// 版本号最大的生效，内容为空表示已停用
+	if len(tpls) > 0 && tpls[0].Content != "" {
+		resp.Active = &resp.Versions[0]
+	}
+
+	response.HTTPSuccess(r, resp)
+}
+
+// HandleSavePromptTemplate 管理员保存提示词模板，生成新版本并立即生效
+func HandleSavePromptTemplate(c flamego.Context, r flamego.Render, req dto.SavePromptTemplateRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理提示词模板")
+		return
+	}
+	if err := service.ValidatePromptTemplate(req.Mode, req.VariableKey, req.Content); err != nil {
+		response.HTTPFail(r, 400032, err.Error())
+		return
+	}
+	if utf8.RuneCountInString(req.Content) > maxPromptLength {
+		response.HTTPFail(r, 400032, fmt.Sprintf("模板不能超过 %d 字", maxPromptLength))
+		return
+	}
+	if _, ok := loadPromptApp(c, r, req.FastgptAppId); !ok {
+		return
+	}
+
+	tpl := &model.PromptTemplate{
+		AppId:     req.FastgptAppId,
+		Mode:      req.Mode,
+		Content:   req.Content,
+		Note:      req.Note,
+		CreatedBy: authInfo.StaffId,
+	}
+	if req.Mode == model.PromptModeVariables {
+		tpl.VariableKey = req.VariableKey
+	}
+	savePromptVersion(c, r, tpl)
+}
+
+// HandleRollbackPromptTemplate 管理员回滚到历史版本，以该版本的内容生成新版本
+func HandleRollbackPromptTemplate(c flamego.Context, r flamego.Render, req dto.RollbackPromptTemplateRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理提示词模板")
+		return
+	}
+
+	old, err := dao.Prompt.GetVersion(c.Request().Context(), req.FastgptAppId, req.Version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "模板版本不存在")
+			return
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	savePromptVersion(c, r, &model.PromptTemplate{
+		AppId:       old.AppId,
+		Mode:        old.Mode,
+		VariableKey: old.VariableKey,
+		Content:     old.Content,
+		Note:        fmt.Sprintf("回滚到版本 %d", old.Version),
+		CreatedBy:   authInfo.StaffId,
+	})
+}
+
+// HandlePreviewPromptTemplate 用当前用户的信息渲染模板，便于保存前检查
+func HandlePreviewPromptTemplate(c flamego.Context, r flamego.Render, req dto.PreviewPromptTemplateRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理提示词模板")
+		return
+	}
+	app, ok := loadPromptApp(c, r, req.FastgptAppId)
+	if !ok {
+		return
+	}
+
+	content := req.Content
+	if content == "" {
+		tpl, err := service.ActivePromptTemplate(c.Request().Context(), app.ID)
+		if err != nil {
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+			response.ServiceErr(r, err)
+			return
+		}
+		if tpl != nil {
+			content = tpl.Content
+		}
+	}
+
+	response.HTTPSuccess(r, dto.PreviewPromptTemplateResponse{
+		Text: service.RenderPromptTemplate(content, authInfo, app, time.Now()),
+	})
+}
+
+// savePromptVersion 保存新版本并使模板和回答缓存失效
+func savePromptVersion(c flamego.Context, r flamego.Render, tpl *model.PromptTemplate) {
+	ctx := c.Request().Context()
+	if err := dao.Prompt.CreateVersion(ctx, tpl); err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+	service.ReloadPromptTemplate(tpl.AppId)
+	// 提示词变化后旧回答不再适用
+	service.InvalidateResponseCache(ctx, tpl.AppId)
+
+	response.HTTPSuccess(r, promptTemplateItem(*tpl))
+}
+
+// loadPromptApp 获取应用，失败时已写入响应
+func loadPromptApp(c flamego.Context, r flamego.Render, id string) (*model.FastgptApp, bool) {
+	app, err := dao.FastgptApp.GetAppByPrimaryID(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "应用不存在")
+			return nil, false
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return nil, false
+	}
+	return app, true
+}
+
+func promptTemplateItem(tpl model.PromptTemplate) dto.PromptTemplateItem {
+	return dto.PromptTemplateItem{
+		Version:     tpl.Version,
+		Mode:        tpl.Mode,
+		VariableKey: tpl.VariableKey,
+		Content:     tpl.Content,
+		Note:        tpl.Note,
+		CreatedBy:   tpl.CreatedBy,
+		CreatedAt:   tpl.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,330 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"HelpStudent/internal/app/fastgpt/service"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+	"gorm.io/gorm"
+)
+
+// RoleManager 仅管理员可访问
+const RoleManager = "manager"
+
+// ProxyRoute 描述一条直接转发到 FastGPT 的路由
+type ProxyRoute struct {
+	Method      string      // HTTP 方法，本地与上游一致
+	Path        string      // 本地路由
+	Upstream    string      // FastGPT 接口路径
+	Request     interface{} // 请求 DTO，为 nil 时参数从查询字符串读取
+	Required    []string    // 查询参数方式下必填的参数
+	AppIdField  string      // 保存应用主键的 JSON 字段或查询参数，不转发给 FastGPT
+	AppIdAlias  []string    // AppIdField 的兼容写法，查询参数方式下依次尝试
+	ByShareId   bool        // AppIdField 保存的是 ShareID，原样转发给 FastGPT
+	InjectAppId string      // 转发时写入 FastGPT appId 的字段，为空则不写入
+	Role        string      // 需要的角色，为空表示登录即可
+	// InvalidateCache 知识库内容变化，成功后清除应用的回答缓存
+	InvalidateCache bool
+	// Scope 转发前按调用者限制访问范围，返回 handled 时直接以 data 响应不再转发
+	Scope func(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, body map[string]interface{}, params map[string]string) (data interface{}, handled bool, err error)
+	// OnSuccess 转发成功后调用，用于同步本地数据，body 和 params 为转发给 FastGPT 的参数
+	OnSuccess func(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, body map[string]interface{}, params map[string]string)
+}
+
+// Handlers 返回该路由的处理链：JSON 请求先绑定校验 DTO，再交给通用转发处理
+func (p ProxyRoute) Handlers() []flamego.Handler {
+	if p.Request == nil {
+		return []flamego.Handler{HandleProxy(p)}
+	}
+	return []flamego.Handler{binding.JSON(p.Request), HandleProxy(p)}
+}
+
+var bindingErrorsType = reflect.TypeOf(binding.Errors{})
+
+// HandleProxy 通用转发处理：校验角色和应用权限，转发请求并以统一结构返回 FastGPT 的 data
+func HandleProxy(p ProxyRoute) flamego.Handler {
+	return func(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+		var (
+			body   map[string]interface{}
+			params map[string]string
+			appKey string
+		)
+		if p.Request != nil {
+			if errs, ok := c.Value(bindingErrorsType).Interface().(binding.Errors); ok && len(errs) > 0 {
+				response.InValidParam(r, errs)
+				return
+			}
+			var err error
+			body, err = toFields(c.Value(reflect.TypeOf(p.Request)).Interface())
+			if err != nil {
+				response.ServiceErr(r, err)
+				return
+			}
+			appKey, _ = body[p.AppIdField].(string)
+			if !p.ByShareId {
+				delete(body, p.AppIdField)
+			}
+		} else {
+			params = make(map[string]string)
+			for key, values := range c.Request().URL.Query() {
+				if len(values) > 0 {
+					params[key] = values[0]
+				}
+			}
+			for _, alias := range p.AppIdAlias {
+				if params[p.AppIdField] == "" {
+					params[p.AppIdField] = params[alias]
+				}
+				delete(params, alias)
+			}
+			for _, key := range append([]string{p.AppIdField}, p.Required...) {
+				if params[key] == "" {
+					response.HTTPFail(r, 400001, "缺少必要参数 "+key)
+					return
+				}
+			}
+			appKey = params[p.AppIdField]
+			if !p.ByShareId {
+				delete(params, p.AppIdField)
+			}
+		}
+
+		if p.Role == RoleManager && !dao2.Managers.IsManager(authInfo.StaffId) {
+			response.HTTPFail(r, 403014, "非管理员用户无权操作")
+			return
+		}
+
+		var (
+			app *model.FastgptApp
+			ok  bool
+		)
+		if p.ByShareId {
+			app, ok = authorizeAppByShareID(c, r, authInfo, appKey)
+		} else {
+			app, ok = authorizeApp(c, r, authInfo, appKey)
+		}
+		if !ok {
+			return
+		}
+
+		if p.Scope != nil {
+			data, handled, err := p.Scope(c.Request().Context(), authInfo, app, body, params)
+			if errors.Is(err, ErrChatForbidden) {
+				response.HTTPFail(r, 403018, "无权访问该会话")
+				return
+			}
+			if err != nil {
+				logx.SystemLogger.CtxError(c.Request().Context(), err)
+				response.ServiceErr(r, err)
+				return
+			}
+			if handled {
+				response.HTTPSuccess(r, data)
+				return
+			}
+		}
+
+		if p.InjectAppId != "" {
+			if body != nil {
+				body[p.InjectAppId] = app.AppId
+			} else {
+				params[p.InjectAppId] = app.AppId
+			}
+		}
+
+		// body 为 nil 时按查询参数转发
+		var payload interface{}
+		if body != nil {
+			payload = body
+		}
+		client, err := getSDKClient(app)
+		if err != nil {
+			upstreamFail(c, r, err)
+			return
+		}
+		data, err := client.WithContext(c.Request().Context()).Do(p.Method, p.Upstream, payload, params)
+		if err != nil {
+			upstreamFail(c, r, err)
+			return
+		}
+		logx.SystemLogger.Infof("FastGPT proxy: %s %s -> %s, staffId=%s, app=%s", p.Method, p.Path, p.Upstream, authInfo.StaffId, app.ID)
+		if p.InvalidateCache {
+			service.InvalidateResponseCache(c.Request().Context(), app.ID)
+		}
+		if p.OnSuccess != nil {
+			p.OnSuccess(c.Request().Context(), authInfo, app, body, params)
+		}
+
+		response.HTTPSuccess(r, data)
+	}
+}
+
+// toFields 将绑定后的 DTO 转换为 JSON 字段表，便于去掉本地字段和写入 appId
+func toFields(req interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("unmarshal request: %w", err)
+	}
+	return fields, nil
+}
+
+// ErrChatForbidden 会话不属于当前用户
+var ErrChatForbidden = errors.New("chat not owned by caller")
+
+const (
+	// importPageSize 导入 FastGPT 会话时每页读取的数量
+	importPageSize = 100
+	// maxImportHistories 单次最多读取的 FastGPT 会话数
+	maxImportHistories = 1000
+)
+
+// ScopeHistories 学生只能看到自己的会话，先导入 FastGPT 中属于自己的外链会话，再从本地会话表按 FastGPT 的格式返回
+// 管理员仍转发给 FastGPT
+func ScopeHistories(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, body map[string]interface{}, _ map[string]string) (interface{}, bool, error) {
+	if dao2.Managers.IsManager(authInfo.StaffId) {
+		return nil, false, nil
+	}
+	offset, _ := body["offset"].(float64)
+	pageSize, _ := body["pageSize"].(float64)
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	// 导入失败时仍返回本地已有的会话
+	if err := importOutLinkHistories(ctx, authInfo, app); err != nil {
+		logx.SystemLogger.CtxError(ctx, "import FastGPT histories failed", app.ID, err)
+	}
+	sessions, total, err := dao.Chat.ListUserSessions(ctx, authInfo.Uid, dao.SessionFilter{AppId: app.ID}, int(offset), int(pageSize))
+	if err != nil {
+		return nil, false, err
+	}
+	list := make([]sdk.ChatHistory, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, sdk.ChatHistory{
+			ChatId:      s.ChatId,
+			AppId:       app.AppId,
+			Title:       s.Title,
+			CustomTitle: s.Title,
+			Top:         s.Pinned,
+			UpdateTime:  s.LastMessageAt.Format(time.RFC3339),
+		})
+	}
+	return sdk.HistoriesResult{List: list, Total: int(total)}, true, nil
+}
+
+// ScopeChat 学生只能访问自己的会话，chatId 从 JSON 字段或查询参数中读取，管理员不受限制
+// 本地没有记录的会话（开始保存会话之前或直接在外链中创建的）先从 FastGPT 导入自己的外链会话再检查
+func ScopeChat(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, body map[string]interface{}, params map[string]string) (interface{}, bool, error) {
+	if dao2.Managers.IsManager(authInfo.StaffId) {
+		return nil, false, nil
+	}
+	chatId := params["chatId"]
+	if body != nil {
+		chatId, _ = body["chatId"].(string)
+	}
+	if chatId == "" {
+		return nil, false, ErrChatForbidden
+	}
+
+	_, err := dao.Chat.GetSession(ctx, authInfo.Uid, app.ID, chatId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := importOutLinkHistories(ctx, authInfo, app); err != nil {
+			return nil, false, err
+		}
+		_, err = dao.Chat.GetSession(ctx, authInfo.Uid, app.ID, chatId)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrChatForbidden
+		}
+		return nil, false, err
+	}
+	return nil, false, nil
+}
+
+// outLinkUid 学生在 FastGPT 外链中的用户标识，使用学号，不采用请求中的 outLinkUid
+func outLinkUid(authInfo auth.Info) string {
+	return authInfo.StaffId
+}
+
+// importOutLinkHistories 将 FastGPT 中该学生在应用外链下的会话导入本地会话表，已导入的跳过
+// FastGPT 按 shareId 和 outLinkUid 过滤会话，只会返回属于该学生的；会话按更新时间倒序，某一页没有新会话时停止
+func importOutLinkHistories(ctx context.Context, authInfo auth.Info, app *model.FastgptApp) error {
+	if app.ShareId == "" {
+		return nil
+	}
+	client, err := getSDKClient(app)
+	if err != nil {
+		return err
+	}
+	client = client.WithContext(ctx)
+
+	for offset := 0; offset < maxImportHistories; offset += importPageSize {
+		page, err := client.GetHistories(sdk.GetHistoriesRequest{
+			AppId:      app.AppId,
+			Offset:     offset,
+			PageSize:   importPageSize,
+			ShareId:    app.ShareId,
+			OutLinkUid: outLinkUid(authInfo),
+		})
+		if err != nil {
+			return err
+		}
+		sessions := make([]*model.ChatSession, 0, len(page.List))
+		for _, h := range page.List {
+			sessions = append(sessions, importedSession(authInfo, app, h))
+		}
+		imported, err := dao.Chat.ImportSessions(ctx, sessions)
+		if err != nil {
+			return err
+		}
+		if imported == 0 || len(page.List) < importPageSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// importedSession 将 FastGPT 的会话转换为本地会话，更新时间无法解析时使用当前时间
+func importedSession(authInfo auth.Info, app *model.FastgptApp, h sdk.ChatHistory) *model.ChatSession {
+	title := h.CustomTitle
+	if title == "" {
+		title = h.Title
+	}
+	if r := []rune(title); len(r) > sessionTitleMaxLen {
+		title = string(r[:sessionTitleMaxLen])
+	}
+	updated, err := time.Parse(time.RFC3339, h.UpdateTime)
+	if err != nil {
+		updated = time.Now()
+	}
+	return &model.ChatSession{
+		UserId:        authInfo.Uid,
+		StaffId:       authInfo.StaffId,
+		AppId:         app.ID,
+		ChatId:        h.ChatId,
+		Title:         title,
+		LastMessageAt: updated,
+		Pinned:        h.Top,
+	}
+}
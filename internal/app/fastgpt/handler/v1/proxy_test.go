@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportedSession(t *testing.T) {
+	authInfo := auth.Info{Uid: "u1", StaffId: "2024001"}
+	app := &model.FastgptApp{AppId: "fastgpt-app"}
+	app.ID = "app1"
+	updated := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		history   sdk.ChatHistory
+		wantTitle string
+		wantTime  bool // 是否使用 FastGPT 的更新时间
+	}{
+		{
+			name:      "custom title preferred",
+			history:   sdk.ChatHistory{ChatId: "c1", Title: "自动标题", CustomTitle: "我的标题", Top: true, UpdateTime: updated.Format(time.RFC3339)},
+			wantTitle: "我的标题",
+			wantTime:  true,
+		},
+		{
+			name:      "fallback to title",
+			history:   sdk.ChatHistory{ChatId: "c2", Title: "自动标题", UpdateTime: updated.Format(time.RFC3339)},
+			wantTitle: "自动标题",
+			wantTime:  true,
+		},
+		{
+			name:      "long title truncated",
+			history:   sdk.ChatHistory{ChatId: "c3", Title: strings.Repeat("长", 80), UpdateTime: updated.Format(time.RFC3339)},
+			wantTitle: strings.Repeat("长", sessionTitleMaxLen),
+			wantTime:  true,
+		},
+		{
+			name:      "bad update time",
+			history:   sdk.ChatHistory{ChatId: "c4", Title: "t", UpdateTime: "yesterday"},
+			wantTitle: "t",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := importedSession(authInfo, app, tt.history)
+			if s.UserId != "u1" || s.StaffId != "2024001" || s.AppId != "app1" || s.ChatId != tt.history.ChatId {
+				t.Errorf("owner fields: got %+v", s)
+			}
+			if s.Title != tt.wantTitle {
+				t.Errorf("title: got %q, want %q", s.Title, tt.wantTitle)
+			}
+			if s.Pinned != tt.history.Top {
+				t.Errorf("pinned: got %v, want %v", s.Pinned, tt.history.Top)
+			}
+			if got := s.LastMessageAt.Equal(updated); got != tt.wantTime {
+				t.Errorf("last message at: got %v", s.LastMessageAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/ingest"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"HelpStudent/pkg/utils/sheet"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+
+	"github.com/flamego/flamego"
+)
+
+// HandleImportQA 管理员从 Excel/CSV 批量导入问答到集合
+// 表单字段: file、fastgptAppId、collectionId，可选 trainingType、preview（预览条数，默认 10）、dryRun
+// 表头需要问题列，答案列可选，列名以“索引”或 index 开头的列作为自定义索引
+func HandleImportQA(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法导入问答")
+		return
+	}
+
+	req := c.Request()
+	collectionId := req.FormValue("collectionId")
+	if collectionId == "" {
+		response.HTTPFail(r, 400001, "缺少必要参数 collectionId")
+		return
+	}
+	app, ok := authorizeApp(c, r, authInfo, req.FormValue("fastgptAppId"))
+	if !ok {
+		return
+	}
+	preview := 10
+	if v := req.FormValue("preview"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 100 {
+			response.HTTPFail(r, 400001, "preview 应为 0-100 的整数")
+			return
+		}
+		preview = n
+	}
+	dryRun, _ := strconv.ParseBool(req.FormValue("dryRun"))
+	trainingType := req.FormValue("trainingType")
+	if trainingType == "" {
+		trainingType = "chunk"
+	}
+
+	// 从 FormFile 获取文件
+	file, header, err := req.FormFile("file")
+	if err != nil {
+		response.HTTPFail(r, 400002, "获取上传文件失败")
+		return
+	}
+	defer func(file multipart.File) {
+		_ = file.Close()
+	}(file)
+
+	if !sheet.IsSheet(header.Filename) {
+		response.HTTPFail(r, 400003, "仅支持Excel或CSV文件(.xlsx, .xls, .csv)")
+		return
+	}
+	maxSize := int64(ingest.Settings().MaxFileSize) << 20
+	if header.Size > maxSize {
+		response.HTTPFail(r, 400024, fmt.Sprintf("文件不能超过 %d MB", ingest.Settings().MaxFileSize))
+		return
+	}
+
+	// 读取文件内容
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.HTTPFail(r, 400004, "读取文件内容失败")
+		return
+	}
+
+	items, rowErrs, err := ingest.ParseQA(header.Filename, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, ingest.ErrNoQuestionColumn):
+			response.HTTPFail(r, 400027, err.Error())
+		case errors.Is(err, sheet.ErrOpen):
+			response.HTTPFail(r, 400005, "打开文件失败")
+		case errors.Is(err, sheet.ErrNoSheet):
+			response.HTTPFail(r, 400006, "Excel文件中没有工作表")
+		default:
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+			response.HTTPFail(r, 400007, "读取文件内容失败")
+		}
+		return
+	}
+	total := len(items) + len(rowErrs)
+	if total == 0 {
+		response.HTTPFail(r, 400008, "文件没有数据行")
+		return
+	}
+	if len(items) > ingest.MaxQARows {
+		response.HTTPFail(r, 400029, fmt.Sprintf("单次最多导入 %d 条问答", ingest.MaxQARows))
+		return
+	}
+
+	resp := dto.ImportQAResponse{
+		Total:   total,
+		Errors:  rowErrs,
+		Preview: make([]dto.QAPreviewItem, 0, min(preview, len(items))),
+		DryRun:  dryRun,
+	}
+	for _, item := range items[:min(preview, len(items))] {
+		resp.Preview = append(resp.Preview, dto.QAPreviewItem{Line: item.Line, Q: item.Q, A: item.A, Indexes: item.Indexes})
+	}
+	if dryRun {
+		resp.SuccessCount = len(items)
+		resp.FailCount = len(rowErrs)
+		response.HTTPSuccess(r, resp)
+		return
+	}
+
+	success, pushErrs, err := ingest.PushQA(c.Request().Context(), app.ID, collectionId, trainingType, items)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	resp.SuccessCount = success
+	resp.FailCount = total - success
+	resp.Errors = append(resp.Errors, pushErrs...)
+
+	response.HTTPSuccess(r, resp)
+}
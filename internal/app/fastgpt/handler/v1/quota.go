@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/service"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"encoding/json"
+	"errors"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+)
+
+// checkQuota 检查聊天配额，超出时直接写入响应
+func checkQuota(c flamego.Context, r flamego.Render, authInfo auth.Info, app *model.FastgptApp) bool {
+	err := service.CheckQuota(c.Request().Context(), authInfo, app)
+	if err == nil {
+		return true
+	}
+	var quotaErr *service.QuotaError
+	if errors.As(err, &quotaErr) {
+		response.HTTPFail(r, quotaErr.Code, quotaErr.Message)
+		return false
+	}
+	logx.SystemLogger.CtxError(c.Request().Context(), err)
+	response.ServiceErr(r, err)
+	return false
+}
+
+// quotaErrMessage 将配额错误转换为 SSE 错误消息
+func quotaErrMessage(err error) string {
+	var quotaErr *service.QuotaError
+	if !errors.As(err, &quotaErr) {
+		return `{"error":"内部异常"}`
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"error": quotaErr.Message,
+		"code":  quotaErr.Code,
+	})
+	return string(data)
+}
+
+// HandleSetQuota 设置用户或应用的聊天配额
+func HandleSetQuota(c flamego.Context, r flamego.Render, req dto.SetQuotaRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员无法设置配额")
+		return
+	}
+
+	if req.Scope != model.QuotaScopeUser && req.Scope != model.QuotaScopeApp {
+		response.HTTPFail(r, 400016, "配额范围只能是 user 或 app")
+		return
+	}
+	if req.RequestsPerMinute < 0 || req.MessagesPerDay < 0 || req.TokensPerMonth < 0 {
+		response.HTTPFail(r, 400017, "配额不能为负数")
+		return
+	}
+	if req.Scope == model.QuotaScopeApp {
+		if _, err := dao.FastgptApp.GetAppByID(req.TargetId); err != nil {
+			response.HTTPFail(r, 404001, "应用不存在")
+			return
+		}
+	}
+
+	quota := &model.ChatQuota{
+		Scope:             req.Scope,
+		TargetId:          req.TargetId,
+		RequestsPerMinute: req.RequestsPerMinute,
+		MessagesPerDay:    req.MessagesPerDay,
+		TokensPerMonth:    req.TokensPerMonth,
+		CreatedBy:         authInfo.StaffId,
+	}
+	if err := dao.Quota.SaveQuota(c.Request().Context(), quota); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	service.InvalidateQuota(c.Request().Context(), req.Scope, req.TargetId)
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleGetQuotaList 获取配额列表
+func HandleGetQuotaList(c flamego.Context, r flamego.Render, req dto.GetQuotaListRequest, authInfo auth.Info) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员无法查看配额")
+		return
+	}
+
+	quotas, total, err := dao.Quota.ListQuotas(c.Request().Context(), req.Scope, req.Offset, req.Limit)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	var items []dto.QuotaItem
+	for _, q := range quotas {
+		items = append(items, dto.QuotaItem{
+			Scope:             q.Scope,
+			TargetId:          q.TargetId,
+			RequestsPerMinute: q.RequestsPerMinute,
+			MessagesPerDay:    q.MessagesPerDay,
+			TokensPerMonth:    q.TokensPerMonth,
+			CreatedBy:         q.CreatedBy,
+			UpdatedAt:         q.UpdatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	response.HTTPSuccess(r, dto.QuotaListResponse{
+		Quotas: items,
+		Total:  total,
+	})
+}
+
+// HandleDeleteQuota 删除配额，删除后用户恢复使用全局默认配额
+func HandleDeleteQuota(c flamego.Context, r flamego.Render, req dto.DeleteQuotaRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+
+	// 检查是否是管理员
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员无法删除配额")
+		return
+	}
+
+	affected, err := dao.Quota.DeleteQuota(c.Request().Context(), req.Scope, req.TargetId)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if affected == 0 {
+		response.HTTPFail(r, 404001, "配额不存在")
+		return
+	}
+	service.InvalidateQuota(c.Request().Context(), req.Scope, req.TargetId)
+
+	response.HTTPSuccess(r, nil)
+}
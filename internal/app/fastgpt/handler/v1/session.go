@@ -0,0 +1,319 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+	"gorm.io/gorm"
+)
+
+// snippetRadius 搜索结果中关键字前后保留的字符数
+const snippetRadius = 40
+
+// HandleGetSessionList 获取我在全部科目下的会话，置顶的在前
+func HandleGetSessionList(c flamego.Context, r flamego.Render, req dto.GetSessionListRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	filter := dao.SessionFilter{AppId: req.FastgptAppId, Archived: req.Archived}
+	sessions, total, err := dao.Chat.ListUserSessions(c.Request().Context(), authInfo.Uid, filter, req.Offset, req.Limit)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	apps := make(map[string]*model.FastgptApp)
+	items := make([]dto.SessionItem, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, sessionItem(c.Request().Context(), apps, s))
+	}
+
+	response.HTTPSuccess(r, dto.SessionListResponse{Sessions: items, Total: total})
+}
+
+// HandleRenameSession 重命名会话，同步修改 FastGPT 中的标题
+func HandleRenameSession(c flamego.Context, r flamego.Render, req dto.RenameSessionRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" || utf8.RuneCountInString(title) > 200 {
+		response.HTTPFail(r, 400001, "标题不能为空且不能超过 200 字")
+		return
+	}
+	session, app, ok := loadSession(c, r, authInfo, req.ID)
+	if !ok {
+		return
+	}
+
+	if err := dao.Chat.UpdateSession(c.Request().Context(), session.ID, map[string]interface{}{"title": title}); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	syncHistory(c.Request().Context(), app, sdk.UpdateHistoryRequest{AppId: app.AppId, ChatId: session.ChatId, CustomTitle: title})
+
+	session.Title = title
+	response.HTTPSuccess(r, sessionItem(c.Request().Context(), map[string]*model.FastgptApp{app.ID: app}, *session))
+}
+
+// HandlePinSession 置顶或取消置顶会话，同步修改 FastGPT 中的置顶状态
+func HandlePinSession(c flamego.Context, r flamego.Render, req dto.PinSessionRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	session, app, ok := loadSession(c, r, authInfo, req.ID)
+	if !ok {
+		return
+	}
+
+	if err := dao.Chat.UpdateSession(c.Request().Context(), session.ID, map[string]interface{}{"pinned": req.Pinned}); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	pinned := req.Pinned
+	syncHistory(c.Request().Context(), app, sdk.UpdateHistoryRequest{AppId: app.AppId, ChatId: session.ChatId, Top: &pinned})
+
+	session.Pinned = req.Pinned
+	response.HTTPSuccess(r, sessionItem(c.Request().Context(), map[string]*model.FastgptApp{app.ID: app}, *session))
+}
+
+// HandleArchiveSession 归档或取消归档会话，归档只在本地生效，不影响 FastGPT
+func HandleArchiveSession(c flamego.Context, r flamego.Render, req dto.ArchiveSessionRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	session, app, ok := loadSession(c, r, authInfo, req.ID)
+	if !ok {
+		return
+	}
+
+	if err := dao.Chat.UpdateSession(c.Request().Context(), session.ID, map[string]interface{}{"archived": req.Archived}); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	session.Archived = req.Archived
+	response.HTTPSuccess(r, sessionItem(c.Request().Context(), map[string]*model.FastgptApp{app.ID: app}, *session))
+}
+
+// HandleDeleteSession 删除会话，先删除 FastGPT 中的记录，成功后删除本地会话和消息
+func HandleDeleteSession(c flamego.Context, r flamego.Render, req dto.SessionIdRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	session, app, ok := loadSession(c, r, authInfo, req.ID)
+	if !ok {
+		return
+	}
+
+	client, err := getSDKClient(app)
+	if err != nil {
+		upstreamFail(c, r, err)
+		return
+	}
+	if err := client.DelHistory(sdk.DelHistoryRequest{AppId: app.AppId, ChatId: session.ChatId}); err != nil {
+		upstreamFail(c, r, err)
+		return
+	}
+	if err := dao.Chat.DeleteSession(c.Request().Context(), session); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, nil)
+}
+
+// HandleSearchSessions 在我自己的聊天记录中搜索关键字
+func HandleSearchSessions(c flamego.Context, r flamego.Render, req dto.SearchSessionsRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	keyword := strings.TrimSpace(req.Keyword)
+	if keyword == "" || utf8.RuneCountInString(keyword) > 100 {
+		response.HTTPFail(r, 400001, "关键字不能为空且不能超过 100 字")
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	ctx := c.Request().Context()
+	messages, total, err := dao.Chat.SearchMessages(ctx, authInfo.Uid, req.FastgptAppId, keyword, req.Offset, req.Limit)
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.SessionId)
+	}
+	sessions := make(map[string]model.ChatSession)
+	if len(ids) > 0 {
+		list, err := dao.Chat.GetSessionsByIds(ctx, ids)
+		if err != nil {
+			logx.SystemLogger.CtxError(ctx, err)
+			response.ServiceErr(r, err)
+			return
+		}
+		for _, s := range list {
+			sessions[s.ID] = s
+		}
+	}
+
+	apps := make(map[string]*model.FastgptApp)
+	hits := make([]dto.SessionSearchHit, 0, len(messages))
+	for _, m := range messages {
+		s, ok := sessions[m.SessionId]
+		if !ok {
+			continue
+		}
+		hits = append(hits, dto.SessionSearchHit{
+			Session:   sessionItem(ctx, apps, s),
+			MessageId: m.ID,
+			Role:      m.Role,
+			Snippet:   snippet(m.Content, keyword),
+			CreatedAt: m.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	response.HTTPSuccess(r, dto.SessionSearchResponse{Hits: hits, Total: total})
+}
+
+// loadSession 获取当前用户的会话和所属应用，失败时直接写入响应
+func loadSession(c flamego.Context, r flamego.Render, authInfo auth.Info, id string) (*model.ChatSession, *model.FastgptApp, bool) {
+	session, err := dao.Chat.GetUserSession(c.Request().Context(), authInfo.Uid, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "会话不存在")
+			return nil, nil, false
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return nil, nil, false
+	}
+	app, ok := authorizeApp(c, r, authInfo, session.AppId)
+	if !ok {
+		return nil, nil, false
+	}
+	return session, app, true
+}
+
+// syncHistory 将标题和置顶同步到 FastGPT，失败只记录日志，以本地为准
+func syncHistory(ctx context.Context, app *model.FastgptApp, req sdk.UpdateHistoryRequest) {
+	client, err := getSDKClient(app)
+	if err == nil {
+		err = client.UpdateHistory(req)
+	}
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, "sync chat history failed", app.ID, req.ChatId, err)
+	}
+}
+
+// SyncUpdatedHistory 直接转发的 updateHistory 成功后同步本地会话
+func SyncUpdatedHistory(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, body map[string]interface{}, _ map[string]string) {
+	chatId, _ := body["chatId"].(string)
+	updates := make(map[string]interface{})
+	if title, _ := body["customTitle"].(string); strings.TrimSpace(title) != "" {
+		updates["title"] = strings.TrimSpace(title)
+	}
+	if top, ok := body["top"].(bool); ok {
+		updates["pinned"] = top
+	}
+	if chatId == "" || len(updates) == 0 {
+		return
+	}
+	if err := dao.Chat.UpdateSessionByChat(ctx, authInfo.Uid, app.ID, chatId, updates); err != nil {
+		logx.SystemLogger.CtxError(ctx, "sync local chat session failed", err)
+	}
+}
+
+// SyncDeletedHistory 直接转发的 delHistory 成功后删除本地会话
+func SyncDeletedHistory(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, _ map[string]interface{}, params map[string]string) {
+	if params["chatId"] == "" {
+		return
+	}
+	if err := dao.Chat.DeleteSessionByChat(ctx, authInfo.Uid, app.ID, params["chatId"]); err != nil {
+		logx.SystemLogger.CtxError(ctx, "delete local chat session failed", err)
+	}
+}
+
+func sessionItem(ctx context.Context, apps map[string]*model.FastgptApp, s model.ChatSession) dto.SessionItem {
+	app, ok := apps[s.AppId]
+	if !ok {
+		app, _ = dao.FastgptApp.GetAppByPrimaryID(ctx, s.AppId)
+		apps[s.AppId] = app
+	}
+	item := dto.SessionItem{
+		ID:            s.ID,
+		FastgptAppId:  s.AppId,
+		ChatId:        s.ChatId,
+		Title:         s.Title,
+		Pinned:        s.Pinned,
+		Archived:      s.Archived,
+		LastMessageAt: s.LastMessageAt.Format("2006-01-02 15:04:05"),
+	}
+	if app != nil {
+		item.AppName = app.AppName
+	}
+	return item
+}
+
+// snippet 截取关键字附近的文本
+func snippet(content, keyword string) string {
+	runes := []rune(content)
+	lower := []rune(strings.ToLower(content))
+	key := []rune(strings.ToLower(keyword))
+	start := 0
+	if len(lower) == len(runes) {
+		for i := 0; i+len(key) <= len(lower); i++ {
+			if string(lower[i:i+len(key)]) == string(key) {
+				start = i
+				break
+			}
+		}
+	}
+	from := max(start-snippetRadius, 0)
+	to := min(start+len(key)+snippetRadius, len(runes))
+	text := string(runes[from:to])
+	if from > 0 {
+		text = "…" + text
+	}
+	if to < len(runes) {
+		text += "…"
+	}
+	return text
+}
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/service"
+	"context"
+)
+
+// sessionTitleMaxLen 自动生成的会话标题最大长度（字符数）
+const sessionTitleMaxLen = 50
+
+// lastUserPrompt 获取请求中最后一条用户消息的文本
+func lastUserPrompt(messages []dto.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == model.ChatRoleUser {
+			return service.MessageText(messages[i].Content)
+		}
+	}
+	return ""
+}
+
+// recordTranscript 保存本轮用户提问和助手回答，失败只记录日志，不影响聊天
+// 没有 chatId 的请求无法归入会话，没有回答说明上游未返回内容，均不保存
+func recordTranscript(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, req dto.ChatCompletionRequest, answer string, stream bool) {
+	if req.ChatId == "" || answer == "" {
+		return
+	}
+	// 客户端断开后仍需保存已收到的内容
+	ctx = context.WithoutCancel(ctx)
+
+	prompt := lastUserPrompt(req.Messages)
+	title := []rune(prompt)
+	if len(title) > sessionTitleMaxLen {
+		title = title[:sessionTitleMaxLen]
+	}
+
+	session := &model.ChatSession{
+		UserId:  authInfo.Uid,
+		StaffId: authInfo.StaffId,
+		AppId:   app.ID,
+		ChatId:  req.ChatId,
+		Title:   string(title),
+	}
+	messages := []*model.ChatMessage{
+		{Role: model.ChatRoleUser, Content: prompt, Stream: stream},
+		{Role: model.ChatRoleAssistant, Content: answer, Stream: stream},
+	}
+	if err := dao.Chat.RecordExchange(ctx, session, messages...); err != nil {
+		logx.SystemLogger.CtxError(ctx, "record chat transcript failed", err)
+	}
+}
@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flamego/flamego"
+	"gorm.io/gorm"
+)
+
+// HandleUploadAttachment 上传聊天附件（图片或 PDF），表单字段: file、fastgptAppId
+// 返回的链接可放入消息的 image_url 或 file_url，发送时会重新签名
+func HandleUploadAttachment(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+	req := c.Request()
+	app, ok := authorizeApp(c, r, authInfo, req.FormValue("fastgptAppId"))
+	if !ok {
+		return
+	}
+	maxSize, _ := service.AppUploadLimits(app)
+	if maxSize <= 0 {
+		response.HTTPFail(r, 403017, service.ErrUploadDisabled.Error())
+		return
+	}
+
+	file, header, err := req.FormFile("file")
+	if err != nil {
+		response.HTTPFail(r, 400002, "获取上传文件失败")
+		return
+	}
+	defer func(file multipart.File) {
+		_ = file.Close()
+	}(file)
+	if header.Size > maxSize<<20 {
+		response.HTTPFail(r, 400024, fmt.Sprintf("文件不能超过 %dMB", maxSize))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxSize<<20+1))
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.HTTPFail(r, 400004, "读取文件内容失败")
+		return
+	}
+
+	up, err := service.StoreUpload(c.Request().Context(), authInfo, app, header.Filename, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUploadTooLarge):
+			response.HTTPFail(r, 400024, fmt.Sprintf("文件不能超过 %dMB", maxSize))
+		case errors.Is(err, service.ErrUploadType):
+			response.HTTPFail(r, 400003, "该应用不支持此类型的附件")
+		default:
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+			response.ServiceErr(r, err)
+		}
+		return
+	}
+
+	link, expiresAt := service.SignUploadURL(up.ID, time.Now())
+	kind := "file"
+	if strings.HasPrefix(up.ContentType, "image/") {
+		kind = "image"
+	}
+	response.HTTPSuccess(r, dto.UploadResponse{
+		ID:          up.ID,
+		Type:        kind,
+		Name:        up.FileName,
+		ContentType: up.ContentType,
+		Size:        up.Size,
+		URL:         link,
+		ExpiresAt:   expiresAt.Format("2006-01-02 15:04:05"),
+	})
+}
+
+// HandleGetAttachment 下载聊天附件，供 FastGPT 和前端预览使用，需要有效签名
+// 路由: GET /api/chat/files/:id?exp=xxx&sig=xxx
+func HandleGetAttachment(c flamego.Context, r flamego.Render) {
+	id := c.Param("id")
+	if err := service.VerifyUploadSignature(id, c.Query("exp"), c.Query("sig"), time.Now()); err != nil {
+		response.HTTPFail(r, 403016, err.Error())
+		return
+	}
+	up, data, err := service.ReadUpload(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "附件不存在")
+			return
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	header := c.ResponseWriter().Header()
+	header.Set("Content-Type", up.ContentType)
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	header.Set("Cache-Control", "private, max-age=600")
+	header.Set("X-Content-Type-Options", "nosniff")
+	c.ResponseWriter().WriteHeader(http.StatusOK)
+	c.ResponseWriter().Write(data)
+}
+
+// resolveAttachments 校验并重新签名消息中的附件链接
+func resolveAttachments(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, messages []dto.Message) ([]dto.Message, error) {
+	count := 0
+	resolved := make([]dto.Message, 0, len(messages))
+	for _, m := range messages {
+		content, err := service.ResolveAttachments(ctx, authInfo, app, m.Content, &count)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, dto.Message{Role: m.Role, Content: content})
+	}
+	return resolved, nil
+}
+
+// attachmentErrMessage 将附件错误转换为 SSE 错误消息
+func attachmentErrMessage(err error) string {
+	if !errors.Is(err, service.ErrAttachmentInvalid) {
+		return `{"error":"内部异常"}`
+	}
+	data, _ := json.Marshal(map[string]interface{}{"error": err.Error()})
+	return string(data)
+}
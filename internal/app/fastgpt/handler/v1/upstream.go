@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"HelpStudent/core/breaker"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/fastgpt/dto"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"HelpStudent/internal/app/fastgpt/service"
+	"encoding/json"
+	"errors"
+
+	"github.com/flamego/flamego"
+)
+
+// getFastGPTClient 获取 FastGPT 客户端（使用应用的后端和 API Key，每个应用独立熔断）
+func getFastGPTClient(app *model.FastgptApp) (*service.FastGPTClient, error) {
+	return service.NewFastGPTClient(app.Backend, app.APIKey, service.WithBreaker(app.ID))
+}
+
+// getSDKClient 获取类型化的 FastGPT 客户端
+func getSDKClient(app *model.FastgptApp) (*sdk.Client, error) {
+	client, err := getFastGPTClient(app)
+	if err != nil {
+		return nil, err
+	}
+	return sdk.NewClient(client), nil
+}
+
+// upstreamErr 将调用 FastGPT 的错误转换为错误码和提示，优先使用 FastGPT 返回的错误信息
+func upstreamErr(err error) (int, string) {
+	var apiErr *sdk.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		msg := apiErr.Message
+		if msg == "" {
+			msg = apiErr.StatusText
+		}
+		if msg == "" {
+			msg = "FastGPT API 调用失败"
+		}
+		return 500001, msg
+	case errors.Is(err, breaker.ErrServiceUnavailable):
+		return 503001, "FastGPT 服务暂不可用，请稍后再试"
+	case errors.Is(err, service.ErrBackendNotFound):
+		return 503002, "应用配置的 FastGPT 后端不存在"
+	default:
+		return 500002, "请求 FastGPT 失败"
+	}
+}
+
+// upstreamFail 记录并返回调用 FastGPT 的错误
+func upstreamFail(c flamego.Context, r flamego.Render, err error) {
+	logx.SystemLogger.CtxError(c.Request().Context(), err)
+	code, msg := upstreamErr(err)
+	response.HTTPFail(r, code, msg, err)
+}
+
+// upstreamErrMessage 将调用 FastGPT 的错误转换为 SSE 错误消息
+func upstreamErrMessage(err error) string {
+	code, msg := upstreamErr(err)
+	data, _ := json.Marshal(map[string]interface{}{
+		"error": msg,
+		"code":  code,
+	})
+	return string(data)
+}
+
+// newChatRequest 将前端请求转换为 FastGPT 对话请求，去掉仅本系统使用的字段
+func newChatRequest(req dto.ChatCompletionRequest) sdk.ChatCompletionRequest {
+	messages := make([]sdk.Message, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		messages = append(messages, sdk.Message{Role: m.Role, Content: m.Content})
+	}
+	return sdk.ChatCompletionRequest{
+		ChatId:     req.ChatId,
+		Stream:     req.Stream,
+		Detail:     req.Detail,
+		Variables:  req.Variables,
+		Messages:   messages,
+		CustomUid:  req.CustomUid,
+		ShareId:    req.ShareId,
+		OutLinkUid: req.OutLinkUid,
+	}
+}
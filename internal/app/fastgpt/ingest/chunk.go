@@ -0,0 +1,72 @@
+package ingest
+
+import (
+	"regexp"
+	"strings"
+)
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// normalize 统一换行，去掉行尾空白并合并多余空行
+func normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t　")
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// Chunk 将文本切分为不超过 size 个字符的分块，相邻分块重叠 overlap 个字符
+// 切分点优先选在段落、换行、句末标点处，找不到时才在 size 处硬切
+func Chunk(text string, size, overlap int) []string {
+	if size <= 0 {
+		return nil
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	runes := []rune(normalize(text))
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			end = len(runes)
+		} else if cut := breakPoint(runes[start:end], size*6/10); cut > 0 {
+			end = start + cut
+		}
+
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// breakPoint 在 window 的 [from, len) 范围内从后往前找切分点，返回切分点之后的位置
+func breakPoint(window []rune, from int) int {
+	for _, seps := range []string{"\n\n", "\n", "。！？!?", "；;.", "，, "} {
+		for i := len(window) - 1; i >= from; i-- {
+			if seps == "\n\n" {
+				if window[i] == '\n' && i > 0 && window[i-1] == '\n' {
+					return i + 1
+				}
+				continue
+			}
+			if strings.ContainsRune(seps, window[i]) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		size    int
+		overlap int
+		want    []string
+	}{
+		{name: "empty", text: "", size: 4, want: nil},
+		{name: "blank", text: " \n\t ", size: 4, want: nil},
+		{name: "zero size", text: "abc", size: 0, want: nil},
+		{name: "shorter than size", text: "abc", size: 4, want: []string{"abc"}},
+		{name: "exact size", text: "abcd", size: 4, want: []string{"abcd"}},
+		{name: "hard cut", text: "abcdefghij", size: 4, want: []string{"abcd", "efgh", "ij"}},
+		{name: "hard cut with overlap", text: "abcdefghij", size: 4, overlap: 2, want: []string{"abcd", "cdef", "efgh", "ghij"}},
+		{name: "overlap not less than size ignored", text: "abcdef", size: 3, overlap: 3, want: []string{"abc", "def"}},
+		{name: "negative overlap ignored", text: "abcdef", size: 3, overlap: -1, want: []string{"abc", "def"}},
+		{name: "cjk counted by rune", text: "一二三四五六七八九十", size: 4, overlap: 1, want: []string{"一二三四", "四五六七", "七八九十"}},
+		{name: "cut after sentence", text: "第一句。第二句。第三句。", size: 6, want: []string{"第一句。", "第二句。", "第三句。"}},
+		{name: "paragraph preferred over sentence", text: "ab。\n\ncd。ef", size: 8, want: []string{"ab。", "cd。ef"}},
+		{name: "break point too early", text: "a。bcdefghij", size: 5, want: []string{"a。bcd", "efghi", "j"}},
+		{name: "normalize line endings", text: "a\r\n\r\n\r\n\r\nb  \r\nc", size: 100, want: []string{"a\n\nb\nc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Chunk(tt.text, tt.size, tt.overlap)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunk_Boundaries(t *testing.T) {
+	// 中英文混排的长文本，检查分块大小和覆盖范围
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		sb.WriteString("函数 f(x) 在点 x0 处连续，当且仅当极限等于函数值。Limit exists, ")
+		if i%7 == 0 {
+			sb.WriteString("\n\n")
+		}
+	}
+	text := sb.String()
+
+	for _, tc := range []struct{ size, overlap int }{{50, 0}, {50, 10}, {128, 32}, {7, 6}} {
+		chunks := Chunk(text, tc.size, tc.overlap)
+		if len(chunks) == 0 {
+			t.Fatalf("size %d: no chunks", tc.size)
+		}
+		for i, c := range chunks {
+			if n := utf8.RuneCountInString(c); n > tc.size {
+				t.Errorf("size %d overlap %d: chunk %d has %d runes", tc.size, tc.overlap, i, n)
+			}
+			if !utf8.ValidString(c) {
+				t.Errorf("size %d: chunk %d is not valid UTF-8", tc.size, i)
+			}
+		}
+		if tc.overlap == 0 {
+			// 不重叠时拼接结果只少了分块两端的空白
+			strip := func(s string) string { return strings.Join(strings.Fields(s), "") }
+			if strip(strings.Join(chunks, "")) != strip(text) {
+				t.Errorf("size %d: chunks do not cover the text", tc.size)
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "a\r\nb\rc", want: "a\nb\nc"},
+		{in: "行尾空白 \t　\n下一行", want: "行尾空白\n下一行"},
+		{in: "a\n\n\n\n\nb", want: "a\n\nb"},
+		{in: "\n\n  a  \n\n", want: "a"},
+	}
+
+	for _, tt := range tests {
+		if got := normalize(tt.in); got != tt.want {
+			t.Errorf("normalize(%q): got %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
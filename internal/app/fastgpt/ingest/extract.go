@@ -0,0 +1,196 @@
+package ingest
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	// ErrUnsupportedType 不支持的文件类型
+	ErrUnsupportedType = errors.New("不支持的文件类型")
+	// ErrNoText 文件中没有可提取的文本
+	ErrNoText = errors.New("文件中没有可提取的文本")
+)
+
+// FileType 根据文件名返回支持的文件类型，不支持时返回空字符串
+func FileType(fileName string) string {
+	switch ext := strings.ToLower(path.Ext(fileName)); ext {
+	case ".pdf", ".docx", ".pptx", ".xlsx", ".md", ".txt":
+		return ext[1:]
+	case ".markdown":
+		return "md"
+	}
+	return ""
+}
+
+// ExtractText 提取文件中的纯文本
+func ExtractText(fileType string, data []byte) (string, error) {
+	var (
+		text string
+		err  error
+	)
+	switch fileType {
+	case "md", "txt":
+		if !utf8.Valid(data) {
+			return "", errors.New("文本文件必须是 UTF-8 编码")
+		}
+		text = string(data)
+	case "docx":
+		text, err = extractDocx(data)
+	case "pptx":
+		text, err = extractPptx(data)
+	case "xlsx":
+		text, err = extractXlsx(data)
+	case "pdf":
+		text, err = extractPDF(data)
+	default:
+		return "", ErrUnsupportedType
+	}
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// extractDocx 读取 word/document.xml 中的段落
+func extractDocx(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("读取 docx 失败: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			return extractXMLText(f, "t", "p")
+		}
+	}
+	return "", errors.New("docx 中缺少 word/document.xml")
+}
+
+// extractPptx 按页码顺序读取每页幻灯片中的文本
+func extractPptx(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("读取 pptx 失败: %w", err)
+	}
+
+	type slide struct {
+		num  int
+		file *zip.File
+	}
+	var slides []slide
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, "ppt/slides/slide")
+		if !ok || path.Dir(f.Name) != "ppt/slides" {
+			continue
+		}
+		num, err := strconv.Atoi(strings.TrimSuffix(name, ".xml"))
+		if err != nil {
+			continue
+		}
+		slides = append(slides, slide{num: num, file: f})
+	}
+	sort.Slice(slides, func(i, j int) bool { return slides[i].num < slides[j].num })
+
+	pages := make([]string, 0, len(slides))
+	for _, s := range slides {
+		text, err := extractXMLText(s.file, "t", "p")
+		if err != nil {
+			return "", err
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			pages = append(pages, text)
+		}
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
+
+// extractXMLText 提取 OOXML 中 textTag 元素的文本，每个 paraTag 结束时换行
+func extractXMLText(f *zip.File, textTag, paraTag string) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	var sb strings.Builder
+	inText := false
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("解析 %s 失败: %w", f.Name, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case textTag:
+				inText = true
+			case "tab":
+				sb.WriteByte('\t')
+			case "br":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case textTag:
+				inText = false
+			case paraTag:
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// extractXlsx 每个工作表一段，单元格以制表符分隔
+func extractXlsx(data []byte) (string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("读取 xlsx 失败: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var sheets []string
+	for _, name := range f.GetSheetList() {
+		rows, err := f.GetRows(name)
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		sb.WriteString(name)
+		sb.WriteByte('\n')
+		for _, row := range rows {
+			line := strings.TrimSpace(strings.Join(row, "\t"))
+			if line == "" {
+				continue
+			}
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+		sheets = append(sheets, sb.String())
+	}
+	return strings.Join(sheets, "\n"), nil
+}
@@ -0,0 +1,286 @@
+package ingest
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// zipFile 按给定的文件名和内容生成 zip，用于构造 docx、pptx
+func zipFile(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func slideXML(paragraphs ...string) string {
+	var sb strings.Builder
+	sb.WriteString(`<p:sld xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"><p:cSld><p:spTree><p:sp><p:txBody>`)
+	for _, p := range paragraphs {
+		fmt.Fprintf(&sb, `<a:p><a:r><a:t>%s</a:t></a:r></a:p>`, p)
+	}
+	sb.WriteString(`</p:txBody></p:sp></p:spTree></p:cSld></p:sld>`)
+	return sb.String()
+}
+
+func TestFileType(t *testing.T) {
+	tests := map[string]string{
+		"讲义.PDF":      "pdf",
+		"a.docx":      "docx",
+		"a.pptx":      "pptx",
+		"a.xlsx":      "xlsx",
+		"README.md":   "md",
+		"a.markdown":  "md",
+		"notes.txt":   "txt",
+		"a.doc":       "",
+		"noext":       "",
+		"a.tar.gz":    "",
+		"dir.pdf/a.x": "",
+	}
+	for name, want := range tests {
+		if got := FileType(name); got != want {
+			t.Errorf("FileType(%q): got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExtractDocx(t *testing.T) {
+	doc := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		`<w:p><w:r><w:t>第一段</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>Hello</w:t><w:tab/><w:t xml:space="preserve">World </w:t></w:r><w:r><w:br/><w:t>换行</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:rPr><w:b/></w:rPr><w:t>&lt;加粗&gt;</w:t></w:r></w:p>` +
+		`</w:body></w:document>`
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "paragraphs",
+			data: zipFile(t, map[string]string{"word/document.xml": doc, "word/styles.xml": `<w:styles/>`}),
+			want: "第一段\nHello\tWorld \n换行\n<加粗>\n",
+		},
+		{name: "missing document", data: zipFile(t, map[string]string{"word/styles.xml": `<w:styles/>`}), wantErr: true},
+		{name: "not a zip", data: []byte("plain text"), wantErr: true},
+		{name: "broken xml", data: zipFile(t, map[string]string{"word/document.xml": `<w:document><w:p>`}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractDocx(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err: got %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractPptx(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "slides in page order",
+			files: map[string]string{
+				"ppt/slides/slide10.xml":            slideXML("第十页"),
+				"ppt/slides/slide2.xml":             slideXML("第二页", "要点"),
+				"ppt/slides/slide1.xml":             slideXML("标题"),
+				"ppt/slides/_rels/slide1.xml.rels":  `<Relationships/>`,
+				"ppt/slideLayouts/slideLayout1.xml": slideXML("版式"),
+			},
+			want: "标题\n\n第二页\n要点\n\n第十页",
+		},
+		{
+			name: "empty slide skipped",
+			files: map[string]string{
+				"ppt/slides/slide1.xml": slideXML("一"),
+				"ppt/slides/slide2.xml": slideXML(),
+				"ppt/slides/slide3.xml": slideXML("三"),
+			},
+			want: "一\n\n三",
+		},
+		{name: "no slides", files: map[string]string{"ppt/presentation.xml": `<p:presentation/>`}, want: ""},
+		{name: "broken slide", files: map[string]string{"ppt/slides/slide1.xml": `<p:sld><a:p>`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractPptx(zipFile(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err: got %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractXlsx(t *testing.T) {
+	f := excelize.NewFile()
+	defer func() {
+		_ = f.Close()
+	}()
+	cells := map[string]interface{}{"A1": "姓名", "B1": "成绩", "A2": "张三", "B2": 90, "A4": "李四", "C4": "缺考"}
+	for cell, v := range cells {
+		if err := f.SetCellValue("Sheet1", cell, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := f.NewSheet("空表"); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := extractXlsx(buf.Bytes())
+	if err != nil {
+		t.Fatalf("extractXlsx failed: %v", err)
+	}
+	want := "Sheet1\n姓名\t成绩\n张三\t90\n李四\t\t缺考\n\n空表\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := extractXlsx([]byte("not a workbook")); err == nil {
+		t.Error("invalid workbook accepted")
+	}
+}
+
+func TestExtractPDF(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []string
+		wantErr bool
+	}{
+		{name: "standard font", data: buildPDF(simplePDFObjects("BT /F1 12 Tf (Hello PDF) Tj ET")), want: []string{"Hello PDF"}},
+		{name: "cid font with tounicode", data: buildPDF(cidPDFObjects("BT /F1 12 Tf <00010002> Tj ET")), want: []string{"中文"}},
+		{name: "not a pdf", data: []byte("hello"), wantErr: true},
+		{name: "truncated", data: []byte("%PDF-1.4\n1 0 obj\n<<"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractPDF(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err: got %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("got %q, want it to contain %q", got, w)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		data     []byte
+		want     string
+		err      error
+	}{
+		{name: "txt", fileType: "txt", data: []byte("你好"), want: "你好"},
+		{name: "md", fileType: "md", data: []byte("# 标题"), want: "# 标题"},
+		{name: "invalid utf8", fileType: "txt", data: []byte{0xff, 0xfe}},
+		{name: "blank", fileType: "txt", data: []byte(" \n "), err: ErrNoText},
+		{name: "unsupported", fileType: "doc", data: []byte("x"), err: ErrUnsupportedType},
+		{name: "pdf without text", fileType: "pdf", data: buildPDF(simplePDFObjects("0 0 m 10 10 l S")), err: ErrNoText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractText(tt.fileType, tt.data)
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("err: got %v, want %v", err, tt.err)
+			}
+			if tt.want == "" && err == nil {
+				t.Fatalf("expected error, got %q", got)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// buildPDF 按顺序编号对象并生成交叉引用表，第一个对象为 Catalog
+func buildPDF(objects []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func pdfStream(content string) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content)
+}
+
+// simplePDFObjects 使用标准 Helvetica 字体的单页 PDF
+func simplePDFObjects(content string) []string {
+	return []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+		pdfStream(content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+}
+
+// cidPDFObjects 使用 Identity-H 编码的 CID 字体，字形编号通过 ToUnicode 映射为中文
+func cidPDFObjects(content string) []string {
+	cmap := "/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n" +
+		"2 beginbfchar\n<0001> <4E2D>\n<0002> <6587>\nendbfchar\n" +
+		"endcmap\nend\nend"
+	return []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+		pdfStream(content),
+		"<< /Type /Font /Subtype /Type0 /BaseFont /SimSun /Encoding /Identity-H /DescendantFonts [6 0 R] /ToUnicode 7 0 R >>",
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /SimSun /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> >>",
+		pdfStream(cmap),
+	}
+}
@@ -0,0 +1,87 @@
+package ingest
+
+import (
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	jobs "HelpStudent/internal/app/jobs/service"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JobType 文件导入的后台任务类型
+const JobType = "fastgpt.ingest"
+
+type jobPayload struct {
+	IngestJobId string `json:"ingestJobId"`
+}
+
+// Enqueue 将导入任务放入后台任务队列
+func Enqueue(ctx context.Context, job *model.IngestJob) error {
+	j, err := jobs.Enqueue(ctx, JobType, jobPayload{IngestJobId: job.ID}, jobs.WithCreatedBy(job.CreatedBy))
+	if err != nil {
+		return err
+	}
+	job.JobId = j.ID
+	return dao.Ingest.UpdateJob(ctx, job.ID, map[string]interface{}{"job_id": j.ID})
+}
+
+// Handle 执行导入任务，重试或进程重启后从已推送的分块继续
+func Handle(ctx context.Context, task *jobs.Task) error {
+	var payload jobPayload
+	if err := task.Decode(&payload); err != nil {
+		return err
+	}
+	job, err := dao.Ingest.GetJob(ctx, payload.IngestJobId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	if job.Status == model.IngestSucceeded {
+		return nil
+	}
+
+	// 任务进度与推送在同一事务中保存，推送成功后导入记录可能来不及更新，以任务进度为准
+	if task.Done > job.PushedChunks {
+		job.PushedChunks = task.Done
+	}
+	err = Run(ctx, job, func(done, total int, push func() error) error {
+		return task.Checkpoint(ctx, done, total, fmt.Sprintf("已推送 %d/%d 块", done, total), push)
+	})
+	if err == nil {
+		task.SetResult(map[string]interface{}{
+			"collectionId": job.CollectionId,
+			"totalChunks":  job.TotalChunks,
+		})
+		return nil
+	}
+
+	// 状态更新不受 ctx 取消影响
+	store := context.WithoutCancel(ctx)
+	switch {
+	case errors.Is(err, jobs.ErrCanceled) || ctx.Err() != nil && task.CancelRequested(store):
+		finish(store, job, model.IngestCanceled, "")
+	case ctx.Err() != nil:
+		// 进程退出，重启后继续
+	case jobs.IsPermanent(err) || task.Attempts >= task.MaxAttempts:
+		finish(store, job, model.IngestFailed, err.Error())
+	default:
+		_ = dao.Ingest.UpdateJob(store, job.ID, map[string]interface{}{"error": err.Error()})
+	}
+	return err
+}
+
+// finish 记录导入任务的最终状态
+func finish(ctx context.Context, job *model.IngestJob, status, reason string) {
+	now := time.Now()
+	_ = dao.Ingest.UpdateJob(ctx, job.ID, map[string]interface{}{
+		"status":      status,
+		"error":       reason,
+		"finished_at": &now,
+	})
+}
@@ -0,0 +1,49 @@
+package ingest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// extractPDF 按页提取 PDF 中的文本，字体带 ToUnicode 映射的 CID 字体（多数中文 PDF）也能正确解码
+// 扫描件没有文本层，提取结果为空，会返回 ErrNoText，需要先做 OCR 或转为 docx 再上传
+func extractPDF(data []byte) (text string, err error) {
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return "", errors.New("不是有效的 PDF 文件")
+	}
+	// 解析库遇到损坏的文件会 panic
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("解析 PDF 失败: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		if errors.Is(err, pdf.ErrInvalidPassword) {
+			return "", errors.New("不支持加密的 PDF 文件")
+		}
+		return "", fmt.Errorf("解析 PDF 失败: %w", err)
+	}
+
+	var sb strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		content, err := page.GetPlainText(nil)
+		if err != nil {
+			return "", fmt.Errorf("解析 PDF 第 %d 页失败: %w", i, err)
+		}
+		if strings.TrimSpace(content) != "" {
+			sb.WriteString(content)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,63 @@
+package ingest
+
+import (
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"HelpStudent/internal/app/fastgpt/service"
+	jobModel "HelpStudent/internal/app/jobs/model"
+	jobs "HelpStudent/internal/app/jobs/service"
+	"context"
+	"fmt"
+)
+
+// PushDataJobType 批量推送数据的后台任务类型
+const PushDataJobType = "fastgpt.pushData"
+
+type pushDataPayload struct {
+	AppId   string              `json:"appId"`
+	Request sdk.PushDataRequest `json:"request"`
+}
+
+// EnqueuePushData 将大批量的数据推送放入后台任务队列，按 BatchSize 分批推送
+func EnqueuePushData(ctx context.Context, appId, createdBy string, req sdk.PushDataRequest) (*jobModel.Job, error) {
+	return jobs.Enqueue(ctx, PushDataJobType, pushDataPayload{AppId: appId, Request: req}, jobs.WithCreatedBy(createdBy))
+}
+
+// HandlePushData 分批推送数据，task.Done 记录已推送条数并与推送在同一事务中保存，重试时从该位置继续
+func HandlePushData(ctx context.Context, task *jobs.Task) error {
+	var payload pushDataPayload
+	if err := task.Decode(&payload); err != nil {
+		return err
+	}
+	client, err := sdk.NewAppClient(ctx, payload.AppId)
+	if err != nil {
+		return err
+	}
+
+	data := payload.Request.Data
+	batch := Settings().BatchSize
+	inserted := 0
+	for i := task.Done; i < len(data); i += batch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		j := min(i+batch, len(data))
+		req := payload.Request
+		req.Data = data[i:j]
+		push := func() error {
+			result, err := client.PushData(req)
+			if err != nil {
+				return fmt.Errorf("推送第 %d-%d 条失败: %w", i+1, j, err)
+			}
+			inserted += result.InsertLen
+			return nil
+		}
+		if err := task.Checkpoint(ctx, j, len(data), fmt.Sprintf("已推送 %d/%d 条", j, len(data)), push); err != nil {
+			return err
+		}
+	}
+
+	// 知识库内容已变化
+	service.InvalidateResponseCache(ctx, payload.AppId)
+	task.SetResult(map[string]interface{}{"insertLen": inserted})
+	return nil
+}
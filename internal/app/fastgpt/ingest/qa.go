@@ -0,0 +1,166 @@
+package ingest
+
+import (
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"HelpStudent/internal/app/fastgpt/service"
+	"HelpStudent/pkg/utils/sheet"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// MaxQARows 单个文件最多导入的问答条数
+	MaxQARows = 5000
+	// maxQALength 问题或答案的最大字符数
+	maxQALength = 8000
+)
+
+// ErrNoQuestionColumn 表头中没有问题列
+var ErrNoQuestionColumn = errors.New("缺少问题列（列名应为：问题/question/q）")
+
+// QARow 表格中的一条问答，Line 为表格中的行号（从 1 开始，含表头）
+type QARow struct {
+	Line    int      `json:"line"`
+	Q       string   `json:"q"`
+	A       string   `json:"a"`
+	Indexes []string `json:"indexes,omitempty"`
+}
+
+// ParseQA 解析问答表格，返回通过校验的行和未通过校验的行的错误说明
+// 表头需要问题列，答案列可选，列名以“索引”或 index 开头的列作为自定义索引
+func ParseQA(fileName string, data []byte) ([]QARow, []string, error) {
+	rows, err := sheet.ReadRows(fileName, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+
+	header := rows[0]
+	qCol := sheet.FindColumn(header, "问题", "question", "q")
+	if qCol == -1 {
+		return nil, nil, ErrNoQuestionColumn
+	}
+	aCol := sheet.FindColumn(header, "答案", "回答", "answer", "a")
+	var indexCols []int
+	for idx, cell := range header {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		if strings.HasPrefix(cell, "索引") || strings.HasPrefix(cell, "index") {
+			indexCols = append(indexCols, idx)
+		}
+	}
+
+	var (
+		items []QARow
+		errs  []string
+		seen  = make(map[string]int)
+	)
+	for i := 1; i < len(rows); i++ {
+		row, line := rows[i], i+1
+		item := QARow{Line: line, Q: sheet.Cell(row, qCol), A: sheet.Cell(row, aCol)}
+		for _, col := range indexCols {
+			if text := sheet.Cell(row, col); text != "" {
+				item.Indexes = append(item.Indexes, text)
+			}
+		}
+		// 跳过空行
+		if item.Q == "" && item.A == "" && len(item.Indexes) == 0 {
+			continue
+		}
+
+		switch {
+		case item.Q == "":
+			errs = append(errs, fmt.Sprintf("第 %d 行：问题为空", line))
+		case utf8.RuneCountInString(item.Q) > maxQALength:
+			errs = append(errs, fmt.Sprintf("第 %d 行：问题超过 %d 字", line, maxQALength))
+		case utf8.RuneCountInString(item.A) > maxQALength:
+			errs = append(errs, fmt.Sprintf("第 %d 行：答案超过 %d 字", line, maxQALength))
+		case seen[item.Q] > 0:
+			errs = append(errs, fmt.Sprintf("第 %d 行：与第 %d 行问题重复", line, seen[item.Q]))
+		default:
+			seen[item.Q] = line
+			items = append(items, item)
+		}
+	}
+	return items, errs, nil
+}
+
+// PushQA 将问答分批推送到集合，返回成功条数和失败行的说明
+// 整批推送失败时该批所有行记为失败，FastGPT 返回的重复、超长和出错数据按问题对应到行
+func PushQA(ctx context.Context, appId, collectionId, trainingType string, items []QARow) (int, []string, error) {
+	client, err := sdk.NewAppClient(ctx, appId)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var (
+		success int
+		errs    []string
+		batch   = Settings().BatchSize
+	)
+	for i := 0; i < len(items); i += batch {
+		if err := ctx.Err(); err != nil {
+			return success, errs, err
+		}
+		j := min(i+batch, len(items))
+		lines := make(map[string]int, j-i)
+		data := make([]sdk.DataItem, 0, j-i)
+		for _, item := range items[i:j] {
+			lines[item.Q] = item.Line
+			data = append(data, qaDataItem(item))
+		}
+
+		result, err := client.PushData(sdk.PushDataRequest{
+			CollectionId: collectionId,
+			TrainingType: trainingType,
+			Data:         data,
+		})
+		if err != nil {
+			for _, item := range items[i:j] {
+				errs = append(errs, fmt.Sprintf("第 %d 行：推送失败: %v", item.Line, err))
+			}
+			continue
+		}
+
+		rejected := rejectedLines(lines, result.Repeat, "与知识库已有数据重复")
+		rejected = append(rejected, rejectedLines(lines, result.OverToken, "超出 token 限制")...)
+		rejected = append(rejected, rejectedLines(lines, result.Error, "FastGPT 处理失败")...)
+		success += j - i - len(rejected)
+		errs = append(errs, rejected...)
+	}
+
+	if success > 0 {
+		// 知识库内容已变化
+		service.InvalidateResponseCache(ctx, appId)
+	}
+	return success, errs, nil
+}
+
+func qaDataItem(item QARow) sdk.DataItem {
+	data := sdk.DataItem{Q: item.Q, A: item.A}
+	for _, text := range item.Indexes {
+		data.Indexes = append(data.Indexes, map[string]interface{}{"text": text})
+	}
+	return data
+}
+
+// rejectedLines 将 FastGPT 拒绝的数据按问题对应到表格行号
+func rejectedLines(lines map[string]int, rejected []json.RawMessage, reason string) []string {
+	errs := make([]string, 0, len(rejected))
+	for _, raw := range rejected {
+		q := gjson.GetBytes(raw, "q").String()
+		if line, ok := lines[q]; ok {
+			errs = append(errs, fmt.Sprintf("第 %d 行：%s", line, reason))
+		} else {
+			errs = append(errs, fmt.Sprintf("问题“%s”：%s", q, reason))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,121 @@
+package ingest
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/core/fileServer"
+	"HelpStudent/core/logx"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	"HelpStudent/internal/app/fastgpt/sdk"
+	"HelpStudent/internal/app/fastgpt/service"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Settings 返回导入配置，未配置的项使用默认值
+func Settings() config.Ingest {
+	cfg := config.GetConfig().FastGPT.Ingest
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = 50
+	}
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = 800
+	}
+	if cfg.ChunkOverlap < 0 {
+		cfg.ChunkOverlap = 0
+	} else if cfg.ChunkOverlap == 0 {
+		cfg.ChunkOverlap = 100
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	return cfg
+}
+
+// Store 将原始文件保存到文件服务，返回保存路径
+func Store(appId, fileName string, data []byte) (string, error) {
+	key := path.Join("fastgpt", "ingest", appId, strings.ToLower(ulid.Make().String())+path.Ext(fileName))
+	if _, err := fileServer.Client(Settings().FileServer).UploadFile(data, key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Run 分块并推送到 FastGPT，每批通过 checkpoint 推送并保存进度，已推送的分块不会重复推送
+// checkpoint 需要保证 push 成功时 done 与之一起保存，恢复执行时从 job.PushedChunks 继续
+func Run(ctx context.Context, job *model.IngestJob, checkpoint func(done, total int, push func() error) error) error {
+	chunks := Chunk(job.Content, job.ChunkSize, job.ChunkOverlap)
+	job.TotalChunks = len(chunks)
+	if err := dao.Ingest.UpdateJob(ctx, job.ID, map[string]interface{}{
+		"status":       model.IngestRunning,
+		"total_chunks": job.TotalChunks,
+	}); err != nil {
+		return err
+	}
+
+	client, err := sdk.NewAppClient(ctx, job.AppId)
+	if err != nil {
+		return err
+	}
+
+	if job.CollectionId == "" {
+		job.CollectionId, err = client.CreateCollection(sdk.CreateCollectionRequest{
+			DatasetId: job.DatasetId,
+			Name:      job.FileName,
+			Metadata:  map[string]interface{}{"ingestJobId": job.ID, "storageKey": job.StorageKey},
+		})
+		if err != nil {
+			return fmt.Errorf("创建集合失败: %w", err)
+		}
+		if err := dao.Ingest.UpdateJob(ctx, job.ID, map[string]interface{}{"collection_id": job.CollectionId}); err != nil {
+			return err
+		}
+	}
+
+	batch := Settings().BatchSize
+	for i := job.PushedChunks; i < len(chunks); i += batch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		j := min(i+batch, len(chunks))
+		data := make([]sdk.DataItem, 0, j-i)
+		for _, chunk := range chunks[i:j] {
+			data = append(data, sdk.DataItem{Q: chunk})
+		}
+		push := func() error {
+			if _, err := client.PushData(sdk.PushDataRequest{
+				CollectionId: job.CollectionId,
+				TrainingType: job.TrainingType,
+				Data:         data,
+			}); err != nil {
+				return fmt.Errorf("推送第 %d-%d 块失败: %w", i+1, j, err)
+			}
+			return nil
+		}
+		if err := checkpoint(j, len(chunks), push); err != nil {
+			return err
+		}
+		job.PushedChunks = j
+		if err := dao.Ingest.UpdateJob(ctx, job.ID, map[string]interface{}{"pushed_chunks": j}); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if err := dao.Ingest.UpdateJob(ctx, job.ID, map[string]interface{}{
+		"status":      model.IngestSucceeded,
+		"error":       "",
+		"finished_at": &now,
+	}); err != nil {
+		return err
+	}
+	// 知识库内容已变化
+	service.InvalidateResponseCache(ctx, job.AppId)
+	logx.SystemLogger.Infof("ingest job %s finished: %s, %d chunks", job.ID, job.FileName, job.TotalChunks)
+	return nil
+}
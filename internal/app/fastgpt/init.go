@@ -1,10 +1,16 @@
 package fastgpt
 
 import (
+	"HelpStudent/config"
 	"HelpStudent/core/kernel"
+	"HelpStudent/core/logx"
 	"HelpStudent/internal/app"
 	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/eval"
+	"HelpStudent/internal/app/fastgpt/ingest"
 	"HelpStudent/internal/app/fastgpt/router"
+	"HelpStudent/internal/app/fastgpt/service"
+	jobs "HelpStudent/internal/app/jobs/service"
 	"context"
 	"sync"
 )
@@ -25,10 +31,23 @@ func (p *Fastgpt) PreInit(engine *kernel.Engine) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	// 主密钥缺失时无法保存应用 API Key，启动时直接失败
+	return service.ValidateKeyEncryption()
 }
 
 func (p *Fastgpt) Init(engine *kernel.Engine) error {
+	// 配置文件变更时重建 FastGPT 后端
+	onChange := p.OnConfigChange()
+	engine.ConfigListener = append(engine.ConfigListener, func(*config.GlobalConfig) {
+		if err := onChange(engine); err != nil {
+			logx.SystemLogger.Errorf("fastgpt reload config failed: %v", err)
+		}
+	})
+
+	// 后台任务类型
+	jobs.Register(ingest.JobType, ingest.Handle)
+	jobs.Register(ingest.PushDataJobType, ingest.HandlePushData)
+	jobs.Register(eval.JobType, eval.Handle)
 	return nil
 }
 
@@ -58,7 +77,8 @@ func (p *Fastgpt) Stop(wg *sync.WaitGroup, ctx context.Context) error {
 
 func (p *Fastgpt) OnConfigChange() func(*kernel.Engine) error {
 	return func(engine *kernel.Engine) error {
-
+		service.ReloadBackends(config.GetConfig().FastGPT)
+		logx.SystemLogger.Info("fastgpt backends reloaded")
 		return nil
 	}
 }
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PersonalAPIKey 用户个人 API Key，用于 OpenAI 兼容接口，只保存哈希
+type PersonalAPIKey struct {
+	model.Base
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	UserId     string         `gorm:"type:char(26);not null;index;comment:用户ID"`
+	StaffId    string         `gorm:"type:varchar(50);not null;index;comment:学号"`
+	Name       string         `gorm:"type:varchar(100);comment:名称"`
+	KeyHash    string         `gorm:"type:char(64);not null;uniqueIndex;comment:Key 的 SHA-256"`
+	KeyPrefix  string         `gorm:"type:varchar(20);comment:Key 前缀，仅用于展示"`
+	LastUsedAt *time.Time     `gorm:"comment:最后使用时间"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChatSession 本地保存的聊天会话，未删除的会话按用户、应用、chatId 唯一
+type ChatSession struct {
+	model.Base
+	UserId        string         `gorm:"type:char(26);not null;uniqueIndex:idx_chat_session_active,where:deleted_at IS NULL;comment:用户ID"`
+	StaffId       string         `gorm:"type:varchar(19);index;comment:学号"`
+	AppId         string         `gorm:"type:char(26);not null;uniqueIndex:idx_chat_session_active,where:deleted_at IS NULL;index;comment:FastgptApp 主键"`
+	ChatId        string         `gorm:"type:varchar(100);not null;uniqueIndex:idx_chat_session_active,where:deleted_at IS NULL;comment:FastGPT 会话ID"`
+	Title         string         `gorm:"type:varchar(200);comment:会话标题"`
+	LastMessageAt time.Time      `gorm:"index;comment:最后一条消息时间"`
+	Pinned        bool           `gorm:"not null;default:false;comment:是否置顶"`
+	Archived      bool           `gorm:"not null;default:false;index;comment:是否归档"`
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+// ChatMessage 本地保存的聊天消息
+type ChatMessage struct {
+	model.Base
+	SessionId string `gorm:"type:char(26);not null;index;comment:ChatSession 主键"`
+	UserId    string `gorm:"type:char(26);not null;index;comment:用户ID"`
+	AppId     string `gorm:"type:char(26);not null;index;comment:FastgptApp 主键"`
+	ChatId    string `gorm:"type:varchar(100);not null;index;comment:FastGPT 会话ID"`
+	Role      string `gorm:"type:varchar(20);not null;comment:消息角色 user/assistant"`
+	Content   string `gorm:"type:text;comment:消息内容"`
+	Stream    bool   `gorm:"comment:是否来自流式接口"`
+}
+
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+	ChatRoleSystem    = "system"
+)
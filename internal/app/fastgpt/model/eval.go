@@ -0,0 +1,84 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// EvalPending 等待执行
+	EvalPending = "pending"
+	// EvalRunning 正在检索
+	EvalRunning = "running"
+	// EvalSucceeded 全部问题检索完成
+	EvalSucceeded = "succeeded"
+	// EvalFailed 执行失败，Error 中保存原因
+	EvalFailed = "failed"
+	// EvalCanceled 已取消
+	EvalCanceled = "canceled"
+)
+
+// EvalSet 知识库检索评测集，每个问题标注应当命中的集合或数据
+type EvalSet struct {
+	model.Base
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	AppId       string         `gorm:"type:char(26);not null;index;comment:FastgptApp 主键"`
+	DatasetId   string         `gorm:"type:varchar(100);not null;index;comment:FastGPT 知识库ID"`
+	Name        string         `gorm:"type:varchar(100);not null;comment:评测集名称"`
+	Description string         `gorm:"type:text;comment:说明"`
+	CreatedBy   string         `gorm:"type:varchar(50);comment:创建者学号"`
+}
+
+// EvalCase 评测问题，命中任一期望的集合或数据即视为相关
+type EvalCase struct {
+	model.Base
+	SetId                 string                      `gorm:"type:char(26);not null;index;comment:评测集ID"`
+	Question              string                      `gorm:"type:text;not null;comment:问题"`
+	ExpectedCollectionIds datatypes.JSONSlice[string] `gorm:"comment:期望命中的集合ID"`
+	ExpectedDataIds       datatypes.JSONSlice[string] `gorm:"comment:期望命中的数据ID"`
+}
+
+// EvalRun 一次评测运行，保存检索参数、汇总指标和每个问题的结果
+type EvalRun struct {
+	model.Base
+	SetId      string                              `gorm:"type:char(26);not null;index;comment:评测集ID"`
+	AppId      string                              `gorm:"type:char(26);not null;comment:FastgptApp 主键"`
+	DatasetId  string                              `gorm:"type:varchar(100);not null;comment:FastGPT 知识库ID"`
+	SearchMode string                              `gorm:"type:varchar(30);comment:检索模式"`
+	Similarity float64                             `gorm:"comment:最低相似度"`
+	Limit      int                                 `gorm:"comment:引用上限（token）"`
+	K          int                                 `gorm:"not null;comment:计算 recall@k 的 k"`
+	JobId      string                              `gorm:"type:char(26);comment:后台任务ID"`
+	Status     string                              `gorm:"type:varchar(20);not null;index;comment:运行状态"`
+	Cases      int                                 `gorm:"not null;default:0;comment:问题数"`
+	Recall     float64                             `gorm:"not null;default:0;comment:平均 recall@k"`
+	MRR        float64                             `gorm:"column:mrr;not null;default:0;comment:平均倒数排名"`
+	Results    datatypes.JSONSlice[EvalCaseResult] `gorm:"comment:每个问题的检索结果"`
+	Error      string                              `gorm:"type:text;comment:失败原因"`
+	CreatedBy  string                              `gorm:"type:varchar(50);comment:创建者学号"`
+	FinishedAt *time.Time                          `gorm:"comment:结束时间"`
+}
+
+// EvalCaseResult 单个问题的检索结果
+type EvalCaseResult struct {
+	CaseId                string    `json:"caseId"`
+	Question              string    `json:"question"`
+	ExpectedCollectionIds []string  `json:"expectedCollectionIds"`
+	ExpectedDataIds       []string  `json:"expectedDataIds"`
+	Hits                  []EvalHit `json:"hits"`
+	Missing               []string  `json:"missing"` // 前 k 条中没有命中的期望集合或数据
+	Recall                float64   `json:"recall"`
+	ReciprocalRank        float64   `json:"reciprocalRank"`
+}
+
+// EvalHit 检索返回的一条数据，Rank 从 1 开始
+type EvalHit struct {
+	Rank         int    `json:"rank"`
+	DataId       string `json:"dataId"`
+	CollectionId string `json:"collectionId"`
+	SourceName   string `json:"sourceName"`
+	Relevant     bool   `json:"relevant"`
+}
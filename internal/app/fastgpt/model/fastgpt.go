@@ -8,6 +8,9 @@ import (
 
 // 数据库模型
 
+// AppKeyActionReveal 查看 API Key 明文
+const AppKeyActionReveal = "reveal"
+
 // FastgptApp FastGPT 应用配置
 type FastgptApp struct {
 	model.Base
@@ -15,7 +18,22 @@ type FastgptApp struct {
 	AppName     string         `gorm:"uniqueIndex:idx_app_name;not null;type:varchar(200);comment:应用名称"`
 	AppId       string         `gorm:"type:varchar(200);comment:FastGPT 应用ID"`
 	ShareId     string         `gorm:"type:varchar(100);comment:FastGPT分享链接ID"`
-	APIKey      string         `gorm:"not null;type:varchar(200);comment:FastGPT API密钥"`
+	Backend     string         `gorm:"type:varchar(50);not null;default:'';comment:FastGPT 后端名称，为空使用默认后端"`
+	APIKey      string         `gorm:"not null;type:text;comment:FastGPT API密钥（信封加密）"`
+	CacheTTL    int            `gorm:"not null;default:0;comment:相同问题的回答缓存秒数，0 表示不缓存"`
 	Description string         `gorm:"type:text;comment:应用描述"`
 	CreatedBy   string         `gorm:"type:varchar(50);comment:创建者"`
+
+	// 聊天附件限制
+	UploadMaxSize int    `gorm:"not null;default:0;comment:单个附件大小上限（MB），0 使用全局配置，-1 禁止上传"`
+	UploadTypes   string `gorm:"type:varchar(500);not null;default:'';comment:允许上传的附件类型，逗号分隔，为空使用全局配置"`
+}
+
+// AppKeyAudit 查看应用 API Key 明文的审计记录
+type AppKeyAudit struct {
+	model.Base
+	AppId    string `gorm:"type:char(26);not null;index;comment:FastgptApp 主键"`
+	StaffId  string `gorm:"type:varchar(50);not null;comment:操作人学号"`
+	Action   string `gorm:"type:varchar(20);not null;comment:操作类型"`
+	ClientIP string `gorm:"type:varchar(64);comment:客户端IP"`
 }
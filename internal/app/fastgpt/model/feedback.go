@@ -0,0 +1,23 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+)
+
+const (
+	FeedbackLike    = "like"
+	FeedbackDislike = "dislike"
+)
+
+// ChatFeedback 用户对某条回答的评价，同一用户对同一条回答只保留最后一次评价
+type ChatFeedback struct {
+	model.Base
+	UserId    string `gorm:"type:char(26);not null;comment:用户ID"`
+	StaffId   string `gorm:"type:varchar(50);not null;uniqueIndex:idx_chat_feedback;comment:学号"`
+	AppId     string `gorm:"type:char(26);not null;uniqueIndex:idx_chat_feedback;index;comment:FastgptApp 主键"`
+	ChatId    string `gorm:"type:varchar(100);not null;uniqueIndex:idx_chat_feedback;comment:FastGPT 会话ID"`
+	DataId    string `gorm:"type:varchar(100);not null;uniqueIndex:idx_chat_feedback;comment:FastGPT 消息ID"`
+	Rating    string `gorm:"type:varchar(10);not null;index;comment:评价 like/dislike"`
+	Reason    string `gorm:"type:text;comment:评价原因"`
+	Forwarded bool   `gorm:"not null;default:false;comment:是否已同步到 FastGPT"`
+}
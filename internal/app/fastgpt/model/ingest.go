@@ -0,0 +1,42 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+	"time"
+)
+
+const (
+	// IngestPending 等待处理
+	IngestPending = "pending"
+	// IngestRunning 正在推送
+	IngestRunning = "running"
+	// IngestSucceeded 全部推送完成
+	IngestSucceeded = "succeeded"
+	// IngestFailed 处理失败，Error 中保存原因
+	IngestFailed = "failed"
+	// IngestCanceled 已取消
+	IngestCanceled = "canceled"
+)
+
+// IngestJob 课程文件导入知识库的任务
+type IngestJob struct {
+	model.Base
+	AppId        string     `gorm:"type:char(26);not null;index;comment:FastgptApp 主键"`
+	DatasetId    string     `gorm:"type:varchar(100);not null;comment:FastGPT 知识库ID"`
+	CollectionId string     `gorm:"type:varchar(100);comment:FastGPT 集合ID"`
+	FileName     string     `gorm:"type:varchar(255);not null;comment:原始文件名"`
+	FileType     string     `gorm:"type:varchar(10);not null;comment:文件类型"`
+	FileSize     int64      `gorm:"not null;comment:文件大小（字节）"`
+	StorageKey   string     `gorm:"type:varchar(500);comment:原始文件在文件服务中的路径"`
+	ChunkSize    int        `gorm:"not null;comment:分块字符数"`
+	ChunkOverlap int        `gorm:"not null;comment:相邻分块重叠字符数"`
+	Content      string     `gorm:"type:text;comment:提取出的文本，任务中断后据此继续推送" json:"-"`
+	JobId        string     `gorm:"type:char(26);comment:后台任务ID"`
+	TrainingType string     `gorm:"type:varchar(20);not null;comment:FastGPT 训练模式"`
+	Status       string     `gorm:"type:varchar(20);not null;index;comment:任务状态"`
+	TotalChunks  int        `gorm:"not null;default:0;comment:分块总数"`
+	PushedChunks int        `gorm:"not null;default:0;comment:已推送分块数"`
+	Error        string     `gorm:"type:text;comment:失败原因"`
+	CreatedBy    string     `gorm:"type:varchar(50);comment:创建者学号"`
+	FinishedAt   *time.Time `gorm:"comment:结束时间"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// ModerationBlock 命中后拒绝提问或中止回答
+	ModerationBlock = "block"
+	// ModerationMask 命中后将敏感词替换为 *
+	ModerationMask = "mask"
+	// ModerationLog 命中后只记录事件
+	ModerationLog = "log"
+
+	// ModerationPrompt 检查用户提问
+	ModerationPrompt = "prompt"
+	// ModerationAnswer 检查助手回答
+	ModerationAnswer = "answer"
+	// ModerationBoth 同时检查提问和回答
+	ModerationBoth = "both"
+)
+
+// ModerationList 管理员维护的敏感词表
+type ModerationList struct {
+	model.Base
+	DeletedAt gorm.DeletedAt `gorm:"uniqueIndex:idx_moderation_list_name"`
+	Name      string         `gorm:"type:varchar(100);not null;uniqueIndex:idx_moderation_list_name;comment:词表名称"`
+	Action    string         `gorm:"type:varchar(10);not null;comment:命中后的处理 block/mask/log"`
+	Scope     string         `gorm:"type:varchar(10);not null;default:'both';comment:检查范围 prompt/answer/both"`
+	Enabled   bool           `gorm:"not null;default:true;comment:是否启用"`
+	CreatedBy string         `gorm:"type:varchar(50);comment:创建者"`
+}
+
+// ModerationWord 词表中的敏感词
+type ModerationWord struct {
+	model.Base
+	ListId string `gorm:"type:char(26);not null;uniqueIndex:idx_moderation_word;comment:词表ID"`
+	Word   string `gorm:"type:varchar(200);not null;uniqueIndex:idx_moderation_word;comment:敏感词"`
+}
+
+// ModerationEvent 敏感词命中记录
+type ModerationEvent struct {
+	model.Base
+	UserId    string `gorm:"type:char(26);comment:用户ID"`
+	StaffId   string `gorm:"type:varchar(50);index;comment:学号"`
+	AppId     string `gorm:"type:char(26);index;comment:FastgptApp 主键"`
+	ListId    string `gorm:"type:char(26);not null;index;comment:词表ID"`
+	ListName  string `gorm:"type:varchar(100);comment:词表名称"`
+	Action    string `gorm:"type:varchar(10);not null;index;comment:处理方式"`
+	Direction string `gorm:"type:varchar(10);not null;comment:prompt 或 answer"`
+	Terms     string `gorm:"type:text;comment:命中的敏感词，逗号分隔"`
+	Excerpt   string `gorm:"type:text;comment:命中的原文片段"`
+}
+
+// AppliesTo 检查词表是否作用于指定方向
+func (l *ModerationList) AppliesTo(direction string) bool {
+	return l.Scope == ModerationBoth || l.Scope == direction
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+)
+
+const (
+	// PromptModeSystem 作为 system 消息放在对话最前面
+	PromptModeSystem = "system"
+	// PromptModeVariables 写入对话变量，由 FastGPT 应用在提示词中引用
+	PromptModeVariables = "variables"
+)
+
+// PromptTemplate 应用的提示词模板，每次保存生成新版本，版本号最大的生效，内容为空表示停用
+type PromptTemplate struct {
+	model.Base
+	AppId       string `gorm:"type:char(26);not null;uniqueIndex:idx_prompt_version;comment:FastgptApp 主键"`
+	Version     int    `gorm:"not null;uniqueIndex:idx_prompt_version;comment:版本号"`
+	Mode        string `gorm:"type:varchar(10);not null;comment:注入方式 system/variables"`
+	VariableKey string `gorm:"type:varchar(100);comment:variables 方式写入的变量名"`
+	Content     string `gorm:"type:text;comment:模板内容"`
+	Note        string `gorm:"type:varchar(255);comment:修改说明"`
+	CreatedBy   string `gorm:"type:varchar(50);comment:创建者"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+)
+
+const (
+	QuotaScopeUser = "user"
+	QuotaScopeApp  = "app"
+)
+
+// ChatQuota 聊天配额配置，各项为 0 表示不限制
+// Scope 为 user 时 TargetId 为学号，为 app 时 TargetId 为 FastgptApp 主键
+type ChatQuota struct {
+	model.Base
+	Scope             string `gorm:"type:varchar(10);not null;uniqueIndex:idx_chat_quota;comment:配额范围 user/app"`
+	TargetId          string `gorm:"type:varchar(26);not null;uniqueIndex:idx_chat_quota;comment:学号或应用ID"`
+	RequestsPerMinute int    `gorm:"not null;default:0;comment:每分钟请求数"`
+	MessagesPerDay    int    `gorm:"not null;default:0;comment:每日消息数"`
+	TokensPerMonth    int64  `gorm:"not null;default:0;comment:每月 token 数"`
+	CreatedBy         string `gorm:"type:varchar(50);comment:创建者"`
+}
+
+// ChatUsage 按月累计的聊天用量
+type ChatUsage struct {
+	model.Base
+	UserId   string `gorm:"type:char(26);not null;uniqueIndex:idx_chat_usage;comment:用户ID"`
+	AppId    string `gorm:"type:char(26);not null;uniqueIndex:idx_chat_usage;index;comment:FastgptApp 主键"`
+	Period   string `gorm:"type:varchar(7);not null;uniqueIndex:idx_chat_usage;comment:统计月份 2006-01"`
+	Messages int64  `gorm:"not null;default:0;comment:消息数"`
+	Tokens   int64  `gorm:"not null;default:0;comment:token 数"`
+}
+
+// ChatDailyCounter 按天累计的消息数，用于每日消息配额，重启和多实例部署下保持一致
+// Scope 为 user 时 TargetId 为用户ID，为 app 时 TargetId 为 FastgptApp 主键
+type ChatDailyCounter struct {
+	model.Base
+	Scope    string `gorm:"type:varchar(10);not null;uniqueIndex:idx_chat_daily_counter;comment:配额范围 user/app"`
+	TargetId string `gorm:"type:varchar(26);not null;uniqueIndex:idx_chat_daily_counter;comment:用户ID或应用ID"`
+	Day      string `gorm:"type:varchar(8);not null;uniqueIndex:idx_chat_daily_counter;index;comment:日期 20060102"`
+	Count    int64  `gorm:"not null;default:0;comment:消息数"`
+}
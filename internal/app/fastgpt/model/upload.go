@@ -0,0 +1,15 @@
+package model
+
+import "HelpStudent/internal/model"
+
+// ChatUpload 聊天附件，只有上传者可以在同一应用的对话中引用
+type ChatUpload struct {
+	model.Base
+	AppId       string `gorm:"type:char(26);not null;index;comment:FastgptApp 主键"`
+	UserId      string `gorm:"type:char(26);not null;index;comment:上传者用户ID"`
+	StaffId     string `gorm:"type:varchar(19);comment:上传者学号"`
+	FileName    string `gorm:"type:varchar(255);comment:原始文件名"`
+	ContentType string `gorm:"type:varchar(100);not null;comment:文件类型"`
+	Size        int64  `gorm:"not null;comment:文件大小（字节）"`
+	StorageKey  string `gorm:"type:varchar(500);not null;comment:文件服务中的路径"`
+}
@@ -17,41 +17,112 @@ func AppFastgptInit(e *flamego.Flame) {
 	e.Group("/api", func() {
 		// ![](/api/system/img/6893942d1d6b742a7c4aac92.jpeg)
 		e.Get("/system/img/{imageId}", handler.HandleGetImage)
-
-		// 外链删除聊天历史
-		e.Delete("/core/chat/delHistory", web.Authorization, handler.HandleOutLinkDelHistory)
+		// 聊天附件，需要签名
+		e.Get("/chat/files/{id}", handler.HandleGetAttachment)
 	})
+
+	// 直接转发的 FastGPT 接口，见 proxyRoutes
+	registerProxyRoutes(e)
+
+	// OpenAI 兼容接口（使用个人 API Key 鉴权）
+	e.Group("/v1", func() {
+		e.Get("/models", handler.HandleOpenAIListModels)
+		e.Post("/chat/completions", binding.JSON(dto.OpenAIChatCompletionRequest{}), handler.HandleOpenAIChatCompletion)
+	}, handler.APIKeyAuthorization)
+
 	e.Group("/fastgpt", func() {
+		// 聊天附件上传
+		e.Post("/chat/upload", handler.HandleUploadAttachment)
+
+		// 会话导出
+		e.Get("/chat/export", handler.HandleExportChat)
+		e.Get("/chat/export/app", handler.HandleExportAppChats)
+
+		// 图片签名链接
+		e.Post("/images/sign", binding.JSON(dto.SignImagesRequest{}), handler.HandleSignImages)
+
 		// Chat 接口 - 非流式
 		e.Post("/v1/chat/completions", binding.JSON(dto.ChatCompletionRequest{}), handler.HandleChatCompletion)
 		// Chat 接口 - 流式输出（使用 flamego/sse）
 		e.Post("/v1/chat/completions/stream", binding.JSON(dto.ChatCompletionRequest{}), sse.Bind(dto.SSEMessage{}), handler.HandleStreamChatCompletion)
 
-		// Chat History 接口
-		e.Group("/core/chat", func() {
-			e.Post("/history/getHistories", binding.JSON(dto.GetHistoriesRequest{}), handler.HandleGetHistories)
-			e.Post("/history/updateHistory", binding.JSON(dto.UpdateHistoryRequest{}), handler.HandleUpdateHistory)
-			e.Post("/getPaginationRecords", binding.JSON(dto.GetPaginationRecordsRequest{}), handler.HandleGetPaginationRecords)
-			e.Post("/quote/getCollectionQuote", binding.JSON(dto.GetCollectionQuoteRequest{}), handler.HandleGetCollectionQuote)
-			e.Get("/outLink/init", handler.HandleOutLinkInit)
+		// 我的会话管理接口
+		e.Group("/sessions", func() {
+			e.Post("/list", binding.JSON(dto.GetSessionListRequest{}), handler.HandleGetSessionList)
+			e.Post("/rename", binding.JSON(dto.RenameSessionRequest{}), handler.HandleRenameSession)
+			e.Post("/pin", binding.JSON(dto.PinSessionRequest{}), handler.HandlePinSession)
+			e.Post("/archive", binding.JSON(dto.ArchiveSessionRequest{}), handler.HandleArchiveSession)
+			e.Post("/delete", binding.JSON(dto.SessionIdRequest{}), handler.HandleDeleteSession)
+			e.Post("/search", binding.JSON(dto.SearchSessionsRequest{}), handler.HandleSearchSessions)
 		})
 
-		// Dataset 接口
-		e.Group("/core/dataset", func() {
-			e.Post("/create", binding.JSON(dto.DatasetCreateRequest{}), handler.HandleCreateDataset)
-			e.Post("/list", binding.JSON(dto.DatasetListRequest{}), handler.HandleListDatasets)
-			e.Get("/detail", handler.HandleGetDatasetDetail)
-			e.Delete("/delete", handler.HandleDeleteDataset)
-
-			// Collection 接口
-			e.Group("/collection", func() {
-				e.Post("/create/text", binding.JSON(dto.CreateCollectionTextRequest{}), handler.HandleCreateCollectionText)
-				e.Post("/create/link", binding.JSON(dto.CreateCollectionLinkRequest{}), handler.HandleCreateCollectionLink)
-			})
-
-			// Data 接口
-			e.Post("/data/pushData", binding.JSON(dto.PushDataRequest{}), handler.HandlePushData)
-			e.Post("/searchTest", binding.JSON(dto.SearchTestRequest{}), handler.HandleSearchTest)
+		// 聊天配额管理接口
+		e.Group("/quota", func() {
+			e.Post("/set", binding.JSON(dto.SetQuotaRequest{}), handler.HandleSetQuota)
+			e.Post("/list", binding.JSON(dto.GetQuotaListRequest{}), handler.HandleGetQuotaList)
+			e.Post("/delete", binding.JSON(dto.DeleteQuotaRequest{}), handler.HandleDeleteQuota)
+		})
+
+		// 回答评价接口
+		e.Group("/feedback", func() {
+			e.Post("/submit", binding.JSON(dto.SubmitFeedbackRequest{}), handler.HandleSubmitFeedback)
+			e.Post("/list", binding.JSON(dto.GetFeedbackListRequest{}), handler.HandleGetFeedbackList)
+			e.Get("/export", handler.HandleExportFeedback)
+		})
+
+		// 敏感词管理接口
+		e.Group("/moderation", func() {
+			e.Get("/lists", handler.HandleGetModerationLists)
+			e.Post("/lists/create", binding.JSON(dto.CreateModerationListRequest{}), handler.HandleCreateModerationList)
+			e.Post("/lists/update", binding.JSON(dto.UpdateModerationListRequest{}), handler.HandleUpdateModerationList)
+			e.Post("/lists/delete", binding.JSON(dto.ModerationListIdRequest{}), handler.HandleDeleteModerationList)
+			e.Post("/words", binding.JSON(dto.ModerationListIdRequest{}), handler.HandleGetModerationWords)
+			e.Post("/words/add", binding.JSON(dto.ModerationWordsRequest{}), handler.HandleAddModerationWords)
+			e.Post("/words/delete", binding.JSON(dto.ModerationWordsRequest{}), handler.HandleDeleteModerationWords)
+			e.Post("/events", binding.JSON(dto.GetModerationEventsRequest{}), handler.HandleGetModerationEvents)
+			e.Post("/reload", handler.HandleReloadModeration)
+		})
+
+		// 课程文件导入知识库接口
+		e.Group("/ingest", func() {
+			e.Post("/upload", handler.HandleUploadCourseFile)
+			e.Get("/jobs/detail", handler.HandleGetIngestJob)
+			e.Post("/jobs/list", binding.JSON(dto.GetIngestJobListRequest{}), handler.HandleGetIngestJobList)
+		})
+		// 提示词模板接口
+		e.Group("/prompts", func() {
+			e.Post("/detail", binding.JSON(dto.PromptAppRequest{}), handler.HandleGetPromptTemplate)
+			e.Post("/save", binding.JSON(dto.SavePromptTemplateRequest{}), handler.HandleSavePromptTemplate)
+			e.Post("/rollback", binding.JSON(dto.RollbackPromptTemplateRequest{}), handler.HandleRollbackPromptTemplate)
+			e.Post("/preview", binding.JSON(dto.PreviewPromptTemplateRequest{}), handler.HandlePreviewPromptTemplate)
+		})
+
+		// 知识库检索评测接口
+		e.Group("/eval", func() {
+			e.Post("/sets", binding.JSON(dto.GetEvalSetsRequest{}), handler.HandleGetEvalSets)
+			e.Post("/sets/create", binding.JSON(dto.CreateEvalSetRequest{}), handler.HandleCreateEvalSet)
+			e.Post("/sets/update", binding.JSON(dto.UpdateEvalSetRequest{}), handler.HandleUpdateEvalSet)
+			e.Post("/sets/delete", binding.JSON(dto.EvalSetIdRequest{}), handler.HandleDeleteEvalSet)
+			e.Post("/cases", binding.JSON(dto.EvalSetIdRequest{}), handler.HandleGetEvalCases)
+			e.Post("/cases/add", binding.JSON(dto.AddEvalCasesRequest{}), handler.HandleAddEvalCases)
+			e.Post("/cases/update", binding.JSON(dto.UpdateEvalCaseRequest{}), handler.HandleUpdateEvalCase)
+			e.Post("/cases/delete", binding.JSON(dto.DeleteEvalCasesRequest{}), handler.HandleDeleteEvalCases)
+			e.Post("/runs/create", binding.JSON(dto.CreateEvalRunRequest{}), handler.HandleCreateEvalRun)
+			e.Post("/runs/list", binding.JSON(dto.GetEvalRunsRequest{}), handler.HandleGetEvalRuns)
+			e.Get("/runs/detail", handler.HandleGetEvalRun)
+			e.Post("/runs/compare", binding.JSON(dto.CompareEvalRunsRequest{}), handler.HandleCompareEvalRuns)
+		})
+
+		// 从 Excel/CSV 批量导入问答
+		e.Post("/core/dataset/data/importQA", handler.HandleImportQA)
+		// 大批量数据推送，后台分批执行
+		e.Post("/core/dataset/data/pushData/async", binding.JSON(dto.PushDataRequest{}), handler.HandlePushDataAsync)
+
+		// 个人 API Key 管理接口
+		e.Group("/keys", func() {
+			e.Post("/create", binding.JSON(dto.CreateAPIKeyRequest{}), handler.HandleCreateAPIKey)
+			e.Get("/list", handler.HandleGetAPIKeyList)
+			e.Post("/delete", binding.JSON(dto.DeleteAPIKeyRequest{}), handler.HandleDeleteAPIKey)
 		})
 
 		// App 管理接口
@@ -60,6 +131,8 @@ func AppFastgptInit(e *flamego.Flame) {
 			e.Post("/list", binding.JSON(dto.GetAppListRequest{}), handler.HandleGetAppList)
 			e.Post("/update", binding.JSON(dto.UpdateAppRequest{}), handler.HandleUpdateApp)
 			e.Post("/delete", binding.JSON(dto.DeleteAppRequest{}), handler.HandleDeleteApp)
+			e.Post("/revealKey", binding.JSON(dto.RevealAppKeyRequest{}), handler.HandleRevealAppKey)
+			e.Post("/clearCache", binding.JSON(dto.ClearAppCacheRequest{}), handler.HandleClearAppCache)
 		})
 	}, web.Authorization)
 }
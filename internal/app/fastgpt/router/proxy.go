@@ -0,0 +1,230 @@
+package router
+
+import (
+	"HelpStudent/core/middleware/web"
+	"HelpStudent/internal/app/fastgpt/dto"
+	handler "HelpStudent/internal/app/fastgpt/handler/v1"
+	"net/http"
+
+	"github.com/flamego/flamego"
+)
+
+// proxyRoutes 直接转发到 FastGPT 的路由表，新增 FastGPT 接口只需在此添加一项
+var proxyRoutes = []handler.ProxyRoute{
+	// Chat History 接口
+	{
+		Method:      http.MethodPost,
+		Path:        "/fastgpt/core/chat/history/getHistories",
+		Upstream:    "/core/chat/getHistories",
+		Request:     dto.GetHistoriesRequest{},
+		AppIdField:  "fastgptAppId",
+		InjectAppId: "appId",
+		Scope:       handler.ScopeHistories,
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/fastgpt/core/chat/history/updateHistory",
+		Upstream:    "/core/chat/history/updateHistory",
+		Request:     dto.UpdateHistoryRequest{},
+		AppIdField:  "appId",
+		InjectAppId: "appId",
+		OnSuccess:   handler.SyncUpdatedHistory,
+		Scope:       handler.ScopeChat,
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/fastgpt/core/chat/getPaginationRecords",
+		Upstream:    "/core/chat/getPaginationRecords",
+		Request:     dto.GetPaginationRecordsRequest{},
+		AppIdField:  "fastgptAppId",
+		InjectAppId: "appId",
+		Scope:       handler.ScopeChat,
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/fastgpt/core/chat/quote/getCollectionQuote",
+		Upstream:    "/core/chat/quote/getCollectionQuote",
+		Request:     dto.GetCollectionQuoteRequest{},
+		AppIdField:  "fastgptAppId",
+		InjectAppId: "appId",
+		Scope:       handler.ScopeChat,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/fastgpt/core/chat/outLink/init",
+		Upstream:   "/core/chat/outLink/init",
+		AppIdField: "shareId",
+		ByShareId:  true,
+	},
+	// 外链删除聊天历史
+	{
+		Method:      http.MethodDelete,
+		Path:        "/api/core/chat/delHistory",
+		Upstream:    "/core/chat/delHistory",
+		Required:    []string{"shareId", "chatId"},
+		AppIdField:  "fastgptAppId",
+		AppIdAlias:  []string{"FastgptAppId"},
+		InjectAppId: "appId",
+		OnSuccess:   handler.SyncDeletedHistory,
+		Scope:       handler.ScopeChat,
+	},
+
+	// Dataset 接口
+	{
+		Method:     http.MethodPost,
+		Path:       "/fastgpt/core/dataset/create",
+		Upstream:   "/core/dataset/create",
+		Request:    dto.DatasetCreateRequest{},
+		AppIdField: "fastgptAppId",
+		Role:       handler.RoleManager,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/fastgpt/core/dataset/list",
+		Upstream:   "/core/dataset/list",
+		Request:    dto.DatasetListRequest{},
+		AppIdField: "fastgptAppId",
+		Role:       handler.RoleManager,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/fastgpt/core/dataset/detail",
+		Upstream:   "/core/dataset/detail",
+		Required:   []string{"id"},
+		AppIdField: "fastgptAppId",
+		Role:       handler.RoleManager,
+	},
+	{
+		Method:          http.MethodDelete,
+		Path:            "/fastgpt/core/dataset/delete",
+		Upstream:        "/core/dataset/delete",
+		Required:        []string{"id"},
+		AppIdField:      "fastgptAppId",
+		Role:            handler.RoleManager,
+		InvalidateCache: true,
+	},
+
+	// Collection 接口
+	{
+		Method:     http.MethodPost,
+		Path:       "/fastgpt/core/dataset/collection/list",
+		Upstream:   "/core/dataset/collection/listV2",
+		Request:    dto.CollectionListRequest{},
+		AppIdField: "fastgptAppId",
+		Role:       handler.RoleManager,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/fastgpt/core/dataset/collection/detail",
+		Upstream:   "/core/dataset/collection/detail",
+		Required:   []string{"id"},
+		AppIdField: "fastgptAppId",
+		Role:       handler.RoleManager,
+	},
+	{
+		Method:          http.MethodPut,
+		Path:            "/fastgpt/core/dataset/collection/update",
+		Upstream:        "/core/dataset/collection/update",
+		Request:         dto.UpdateCollectionRequest{},
+		AppIdField:      "fastgptAppId",
+		Role:            handler.RoleManager,
+		InvalidateCache: true,
+	},
+	{
+		Method:          http.MethodDelete,
+		Path:            "/fastgpt/core/dataset/collection/delete",
+		Upstream:        "/core/dataset/collection/delete",
+		Required:        []string{"id"},
+		AppIdField:      "fastgptAppId",
+		Role:            handler.RoleManager,
+		InvalidateCache: true,
+	},
+	{
+		Method:          http.MethodPost,
+		Path:            "/fastgpt/core/dataset/collection/retrain",
+		Upstream:        "/core/dataset/collection/create/reTrainingCollection",
+		Request:         dto.RetrainCollectionRequest{},
+		AppIdField:      "fastgptAppId",
+		Role:            handler.RoleManager,
+		InvalidateCache: true,
+	},
+	{
+		Method:          http.MethodPost,
+		Path:            "/fastgpt/core/dataset/collection/create/text",
+		Upstream:        "/core/dataset/collection/create/text",
+		Request:         dto.CreateCollectionTextRequest{},
+		AppIdField:      "fastgptAppId",
+		Role:            handler.RoleManager,
+		InvalidateCache: true,
+	},
+	{
+		Method:          http.MethodPost,
+		Path:            "/fastgpt/core/dataset/collection/create/link",
+		Upstream:        "/core/dataset/collection/create/link",
+		Request:         dto.CreateCollectionLinkRequest{},
+		AppIdField:      "fastgptAppId",
+		Role:            handler.RoleManager,
+		InvalidateCache: true,
+	},
+
+	// Data 接口
+	{
+		Method:          http.MethodPost,
+		Path:            "/fastgpt/core/dataset/data/pushData",
+		Upstream:        "/core/dataset/data/pushData",
+		Request:         dto.PushDataRequest{},
+		AppIdField:      "fastgptAppId",
+		Role:            handler.RoleManager,
+		InvalidateCache: true,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/fastgpt/core/dataset/data/list",
+		Upstream:   "/core/dataset/data/v2/list",
+		Request:    dto.DataListRequest{},
+		AppIdField: "fastgptAppId",
+		Role:       handler.RoleManager,
+	},
+	{
+		Method:     http.MethodGet,
+		Path:       "/fastgpt/core/dataset/data/detail",
+		Upstream:   "/core/dataset/data/detail",
+		Required:   []string{"id"},
+		AppIdField: "fastgptAppId",
+		Role:       handler.RoleManager,
+	},
+	{
+		Method:          http.MethodPut,
+		Path:            "/fastgpt/core/dataset/data/update",
+		Upstream:        "/core/dataset/data/update",
+		Request:         dto.UpdateDataRequest{},
+		AppIdField:      "fastgptAppId",
+		Role:            handler.RoleManager,
+		InvalidateCache: true,
+	},
+	{
+		Method:          http.MethodDelete,
+		Path:            "/fastgpt/core/dataset/data/delete",
+		Upstream:        "/core/dataset/data/delete",
+		Required:        []string{"id"},
+		AppIdField:      "fastgptAppId",
+		Role:            handler.RoleManager,
+		InvalidateCache: true,
+	},
+	{
+		Method:     http.MethodPost,
+		Path:       "/fastgpt/core/dataset/searchTest",
+		Upstream:   "/core/dataset/searchTest",
+		Request:    dto.SearchTestRequest{},
+		AppIdField: "fastgptAppId",
+		Role:       handler.RoleManager,
+	},
+}
+
+// registerProxyRoutes 按路由表注册转发接口（需要登录）
+func registerProxyRoutes(e *flamego.Flame) {
+	for _, route := range proxyRoutes {
+		handlers := append([]flamego.Handler{web.Authorization}, route.Handlers()...)
+		e.Route(route.Method, route.Path, handlers)
+	}
+}
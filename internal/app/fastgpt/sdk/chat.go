@@ -0,0 +1,347 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Message 对话消息，Content 可以是字符串或多模态数组
+type Message struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// ChatCompletionRequest 对话请求
+type ChatCompletionRequest struct {
+	ChatId     string                 `json:"chatId,omitempty"`
+	Stream     bool                   `json:"stream"`
+	Detail     bool                   `json:"detail"`
+	Variables  map[string]interface{} `json:"variables,omitempty"`
+	Messages   []Message              `json:"messages"`
+	CustomUid  string                 `json:"customUid,omitempty"`
+	ShareId    string                 `json:"shareId,omitempty"`
+	OutLinkUid string                 `json:"outLinkUid,omitempty"`
+}
+
+// Usage token 用量
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// ChatChoice 对话结果
+type ChatChoice struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// ChatCompletionResponse 非流式对话响应
+type ChatCompletionResponse struct {
+	ID           string                 `json:"id"`
+	Model        string                 `json:"model"`
+	Usage        *Usage                 `json:"usage,omitempty"`
+	Choices      []ChatChoice           `json:"choices"`
+	ResponseData json.RawMessage        `json:"responseData,omitempty"`
+	NewVariables map[string]interface{} `json:"newVariables,omitempty"`
+}
+
+// Answer 返回第一个回答的文本
+func (r *ChatCompletionResponse) Answer() string {
+	if len(r.Choices) == 0 {
+		return ""
+	}
+	if s, ok := r.Choices[0].Message.Content.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// Clone 深拷贝回答，缓存的回答被多个请求共享，返回前复制一份
+func (r *ChatCompletionResponse) Clone() *ChatCompletionResponse {
+	b, err := json.Marshal(r)
+	if err != nil {
+		c := *r
+		return &c
+	}
+	var c ChatCompletionResponse
+	if err := json.Unmarshal(b, &c); err != nil {
+		c = *r
+	}
+	return &c
+}
+
+// ChatCompletions 非流式对话，ctx 结束时中止请求
+func (c *Client) ChatCompletions(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	req.Stream = false
+	respBody, statusCode, err := c.raw.WithContext(ctx).ForwardRequest(http.MethodPost, "/v1/chat/completions", req)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, decodeError(respBody, statusCode)
+	}
+	var resp ChatCompletionResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &resp, nil
+}
+
+// StreamChatCompletions 流式对话，返回原始响应供调用方按 SSE 读取
+// 非 200 时关闭响应并返回 *APIError
+func (c *Client) StreamChatCompletions(ctx context.Context, req ChatCompletionRequest) (*http.Response, error) {
+	req.Stream = true
+	resp, err := c.raw.ForwardStreamRequest(ctx, http.MethodPost, "/v1/chat/completions", req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var body json.RawMessage
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return nil, decodeError(body, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// GetHistoriesRequest 获取会话列表请求
+type GetHistoriesRequest struct {
+	AppId      string `json:"appId"`
+	Offset     int    `json:"offset"`
+	PageSize   int    `json:"pageSize"`
+	Source     string `json:"source,omitempty"`
+	ShareId    string `json:"shareId,omitempty"`
+	OutLinkUid string `json:"outLinkUid,omitempty"`
+}
+
+// ChatHistory 会话
+type ChatHistory struct {
+	ChatId      string `json:"chatId"`
+	AppId       string `json:"appId"`
+	Title       string `json:"title"`
+	CustomTitle string `json:"customTitle,omitempty"`
+	Top         bool   `json:"top"`
+	UpdateTime  string `json:"updateTime"`
+}
+
+// HistoriesResult 会话列表
+type HistoriesResult struct {
+	List  []ChatHistory `json:"list"`
+	Total int           `json:"total"`
+}
+
+// UnmarshalJSON 兼容旧版本 FastGPT 直接返回数组的格式
+func (r *HistoriesResult) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &r.List); err != nil {
+			return err
+		}
+		r.Total = len(r.List)
+		return nil
+	}
+	type alias HistoriesResult
+	return json.Unmarshal(data, (*alias)(r))
+}
+
+// GetHistories 获取会话列表
+func (c *Client) GetHistories(req GetHistoriesRequest) (*HistoriesResult, error) {
+	var result HistoriesResult
+	if err := c.post("/core/chat/getHistories", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateHistoryRequest 修改会话请求
+type UpdateHistoryRequest struct {
+	AppId       string `json:"appId"`
+	ChatId      string `json:"chatId"`
+	CustomTitle string `json:"customTitle,omitempty"`
+	Top         *bool  `json:"top,omitempty"`
+	ShareId     string `json:"shareId,omitempty"`
+	OutLinkUid  string `json:"outLinkUid,omitempty"`
+}
+
+// UpdateHistory 修改会话标题或置顶
+func (c *Client) UpdateHistory(req UpdateHistoryRequest) error {
+	return c.post("/core/chat/history/updateHistory", req, nil)
+}
+
+// DelHistoryRequest 删除会话请求
+type DelHistoryRequest struct {
+	AppId      string
+	ChatId     string
+	ShareId    string
+	OutLinkUid string
+}
+
+// DelHistory 删除会话
+func (c *Client) DelHistory(req DelHistoryRequest) error {
+	params := map[string]string{
+		"appId":  req.AppId,
+		"chatId": req.ChatId,
+	}
+	if req.ShareId != "" {
+		params["shareId"] = req.ShareId
+	}
+	if req.OutLinkUid != "" {
+		params["outLinkUid"] = req.OutLinkUid
+	}
+	return c.query(http.MethodDelete, "/core/chat/delHistory", params, nil)
+}
+
+// UpdateUserFeedbackRequest 更新回答评价请求
+// UserGoodFeedback 和 UserBadFeedback 都为 nil 时清除评价
+type UpdateUserFeedbackRequest struct {
+	AppId            string  `json:"appId"`
+	ChatId           string  `json:"chatId"`
+	DataId           string  `json:"dataId"`
+	UserGoodFeedback *string `json:"userGoodFeedback,omitempty"`
+	UserBadFeedback  *string `json:"userBadFeedback,omitempty"`
+	ShareId          string  `json:"shareId,omitempty"`
+	OutLinkUid       string  `json:"outLinkUid,omitempty"`
+}
+
+// UpdateUserFeedback 更新回答评价
+func (c *Client) UpdateUserFeedback(req UpdateUserFeedbackRequest) error {
+	return c.post("/core/chat/feedback/updateUserFeedback", req, nil)
+}
+
+// GetPaginationRecordsRequest 获取会话记录请求
+type GetPaginationRecordsRequest struct {
+	AppId               string `json:"appId"`
+	ChatId              string `json:"chatId"`
+	Offset              int    `json:"offset"`
+	PageSize            int    `json:"pageSize"`
+	LoadCustomFeedbacks bool   `json:"loadCustomFeedbacks"`
+}
+
+// ChatRecord 会话中的一条记录，Value 为 FastGPT 原始的消息内容数组
+type ChatRecord struct {
+	ID               string          `json:"_id"`
+	DataId           string          `json:"dataId"`
+	Obj              string          `json:"obj"`
+	Value            json.RawMessage `json:"value"`
+	CustomFeedbacks  []string        `json:"customFeedbacks,omitempty"`
+	UserGoodFeedback string          `json:"userGoodFeedback,omitempty"`
+	UserBadFeedback  string          `json:"userBadFeedback,omitempty"`
+	TotalQuoteList   json.RawMessage `json:"totalQuoteList,omitempty"`
+	ResponseData     json.RawMessage `json:"responseData,omitempty"`
+	DurationSeconds  float64         `json:"durationSeconds,omitempty"`
+	Time             string          `json:"time,omitempty"`
+}
+
+// PaginationRecordsResult 会话记录分页结果
+type PaginationRecordsResult struct {
+	List  []ChatRecord `json:"list"`
+	Total int          `json:"total"`
+}
+
+// UnmarshalJSON 兼容直接返回数组的格式
+func (r *PaginationRecordsResult) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &r.List); err != nil {
+			return err
+		}
+		r.Total = len(r.List)
+		return nil
+	}
+	type alias PaginationRecordsResult
+	return json.Unmarshal(data, (*alias)(r))
+}
+
+// GetPaginationRecords 分页获取会话记录
+func (c *Client) GetPaginationRecords(req GetPaginationRecordsRequest) (*PaginationRecordsResult, error) {
+	var result PaginationRecordsResult
+	if err := c.post("/core/chat/getPaginationRecords", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetCollectionQuoteRequest 获取引用上下文请求
+type GetCollectionQuoteRequest struct {
+	InitialId      string `json:"initialId,omitempty"`
+	InitialIndex   int    `json:"initialIndex,omitempty"`
+	PageSize       int    `json:"pageSize"`
+	CollectionId   string `json:"collectionId"`
+	ChatItemDataId string `json:"chatItemDataId,omitempty"`
+	ChatId         string `json:"chatId,omitempty"`
+	AppId          string `json:"appId"`
+	ShareId        string `json:"shareId,omitempty"`
+	OutLinkUid     string `json:"outLinkUid,omitempty"`
+}
+
+// CollectionQuoteResult 引用上下文
+type CollectionQuoteResult struct {
+	List        []json.RawMessage `json:"list"`
+	HasMorePrev bool              `json:"hasMorePrev"`
+	HasMoreNext bool              `json:"hasMoreNext"`
+}
+
+// GetCollectionQuote 获取引用所在集合的上下文
+func (c *Client) GetCollectionQuote(req GetCollectionQuoteRequest) (*CollectionQuoteResult, error) {
+	var result CollectionQuoteResult
+	if err := c.post("/core/chat/quote/getCollectionQuote", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// OutLinkInitRequest 外链会话初始化请求
+type OutLinkInitRequest struct {
+	ChatId     string
+	ShareId    string
+	OutLinkUid string
+}
+
+// OutLinkInitResult 外链会话初始化结果，App 保留 FastGPT 原始结构
+type OutLinkInitResult struct {
+	ChatId     string                 `json:"chatId"`
+	AppId      string                 `json:"appId"`
+	Title      string                 `json:"title,omitempty"`
+	UserAvatar string                 `json:"userAvatar,omitempty"`
+	Variables  map[string]interface{} `json:"variables,omitempty"`
+	App        json.RawMessage        `json:"app,omitempty"`
+}
+
+// OutLinkInit 外链会话初始化
+func (c *Client) OutLinkInit(req OutLinkInitRequest) (*OutLinkInitResult, error) {
+	params := map[string]string{
+		"shareId": req.ShareId,
+	}
+	if req.ChatId != "" {
+		params["chatId"] = req.ChatId
+	}
+	if req.OutLinkUid != "" {
+		params["outLinkUid"] = req.OutLinkUid
+	}
+	var result OutLinkInitResult
+	if err := c.query(http.MethodGet, "/core/chat/outLink/init", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TotalTokens 返回本次对话的 token 用量
+// 优先使用 usage.total_tokens，没有时累加 detail 模式下各节点的 tokens
+func (r *ChatCompletionResponse) TotalTokens() int64 {
+	if r.Usage != nil && r.Usage.TotalTokens > 0 {
+		return r.Usage.TotalTokens
+	}
+	var nodes []struct {
+		Tokens int64 `json:"tokens"`
+	}
+	if len(r.ResponseData) == 0 || json.Unmarshal(r.ResponseData, &nodes) != nil {
+		return 0
+	}
+	var total int64
+	for _, node := range nodes {
+		total += node.Tokens
+	}
+	return total
+}
@@ -0,0 +1,155 @@
+package sdk
+
+import (
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Client 类型化的 FastGPT 客户端，请求和响应均为 Go 结构体
+type Client struct {
+	raw *service.FastGPTClient
+}
+
+// NewClient 基于 FastGPTClient 创建类型化客户端
+func NewClient(raw *service.FastGPTClient) *Client {
+	return &Client{raw: raw}
+}
+
+// NewAppClient 根据应用主键创建客户端，用于后台任务等拿不到请求中应用信息的场景
+func NewAppClient(ctx context.Context, appId string) (*Client, error) {
+	app, err := dao.FastgptApp.GetAppByPrimaryID(ctx, appId)
+	if err != nil {
+		return nil, fmt.Errorf("应用不存在: %w", err)
+	}
+	apiKey, err := service.DecryptAPIKey(app.ID, app.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := service.NewFastGPTClient(app.Backend, apiKey, service.WithBreaker(app.ID))
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(raw), nil
+}
+
+// WithContext 返回绑定请求 context 的客户端，客户端断开后中止请求和重试
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{raw: c.raw.WithContext(ctx)}
+}
+
+// Raw 返回底层的 FastGPTClient
+func (c *Client) Raw() *service.FastGPTClient {
+	return c.raw
+}
+
+// APIError FastGPT 返回的错误
+type APIError struct {
+	HTTPStatus int    // HTTP 状态码
+	Code       int    // FastGPT 业务码
+	StatusText string // FastGPT 错误标识
+	Message    string // FastGPT 错误信息
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("fastgpt api error: status=%d, code=%d, message=%s", e.HTTPStatus, e.Code, e.Message)
+}
+
+// envelope FastGPT 通用响应结构
+type envelope struct {
+	Code       int             `json:"code"`
+	StatusText string          `json:"statusText"`
+	Message    string          `json:"message"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// openAIError OpenAI 兼容接口的错误结构
+type openAIError struct {
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+	} `json:"error"`
+}
+
+// post 以 JSON 方式请求，并将 data 解析到 out
+func (c *Client) post(path string, body interface{}, out interface{}) error {
+	respBody, statusCode, err := c.raw.ForwardRequest(http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+	return decodeEnvelope(respBody, statusCode, out)
+}
+
+// query 以查询参数方式请求，并将 data 解析到 out
+func (c *Client) query(method, path string, params map[string]string, out interface{}) error {
+	respBody, statusCode, err := c.raw.ForwardRequestWithQuery(method, path, params)
+	if err != nil {
+		return err
+	}
+	return decodeEnvelope(respBody, statusCode, out)
+}
+
+// decodeEnvelope 解析 FastGPT 通用响应，失败时返回 *APIError
+func decodeEnvelope(respBody []byte, statusCode int, out interface{}) error {
+	var env envelope
+	if err := json.Unmarshal(respBody, &env); err != nil {
+		if statusCode != http.StatusOK {
+			return &APIError{HTTPStatus: statusCode, Message: string(respBody)}
+		}
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if statusCode != http.StatusOK || (env.Code != 0 && env.Code != http.StatusOK) {
+		return &APIError{
+			HTTPStatus: statusCode,
+			Code:       env.Code,
+			StatusText: env.StatusText,
+			Message:    env.Message,
+		}
+	}
+	if out == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("decode response data: %w", err)
+	}
+	return nil
+}
+
+// decodeError 解析非 200 响应中的错误信息，兼容通用结构和 OpenAI 结构
+func decodeError(respBody []byte, statusCode int) error {
+	var oe openAIError
+	if err := json.Unmarshal(respBody, &oe); err == nil && oe.Error != nil {
+		return &APIError{HTTPStatus: statusCode, StatusText: oe.Error.Type, Message: oe.Error.Message}
+	}
+	if err := decodeEnvelope(respBody, statusCode, nil); err != nil {
+		return err
+	}
+	return &APIError{HTTPStatus: statusCode, Message: string(respBody)}
+}
+
+// Do 发起任意 FastGPT 接口请求并返回未解析的 data，用于通用转发
+// body 不为 nil 时以 JSON 发送，否则以 params 作为查询参数
+func (c *Client) Do(method, path string, body interface{}, params map[string]string) (json.RawMessage, error) {
+	var (
+		respBody   []byte
+		statusCode int
+		err        error
+	)
+	if body != nil {
+		respBody, statusCode, err = c.raw.ForwardRequest(method, path, body)
+	} else {
+		respBody, statusCode, err = c.raw.ForwardRequestWithQuery(method, path, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var data json.RawMessage
+	if err := decodeEnvelope(respBody, statusCode, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
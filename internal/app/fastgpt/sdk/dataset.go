@@ -0,0 +1,208 @@
+package sdk
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// CreateDatasetRequest 创建知识库请求
+type CreateDatasetRequest struct {
+	ParentId    *string `json:"parentId"`
+	Type        string  `json:"type,omitempty"`
+	Name        string  `json:"name"`
+	Intro       string  `json:"intro,omitempty"`
+	Avatar      string  `json:"avatar,omitempty"`
+	VectorModel string  `json:"vectorModel,omitempty"`
+	AgentModel  string  `json:"agentModel,omitempty"`
+}
+
+// CreateDataset 创建知识库，返回知识库ID
+func (c *Client) CreateDataset(req CreateDatasetRequest) (string, error) {
+	var id string
+	if err := c.post("/core/dataset/create", req, &id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// ListDatasetsRequest 知识库列表请求
+type ListDatasetsRequest struct {
+	ParentId *string `json:"parentId"`
+}
+
+// Dataset 知识库，模型和权限字段保留 FastGPT 原始结构
+type Dataset struct {
+	ID          string          `json:"_id"`
+	ParentId    *string         `json:"parentId"`
+	Name        string          `json:"name"`
+	Intro       string          `json:"intro"`
+	Avatar      string          `json:"avatar"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status,omitempty"`
+	VectorModel json.RawMessage `json:"vectorModel,omitempty"`
+	AgentModel  json.RawMessage `json:"agentModel,omitempty"`
+	Permission  json.RawMessage `json:"permission,omitempty"`
+	UpdateTime  string          `json:"updateTime,omitempty"`
+}
+
+// ListDatasets 获取知识库列表
+func (c *Client) ListDatasets(req ListDatasetsRequest) ([]Dataset, error) {
+	var datasets []Dataset
+	if err := c.post("/core/dataset/list", req, &datasets); err != nil {
+		return nil, err
+	}
+	return datasets, nil
+}
+
+// GetDataset 获取知识库详情
+func (c *Client) GetDataset(id string) (*Dataset, error) {
+	var dataset Dataset
+	if err := c.query(http.MethodGet, "/core/dataset/detail", map[string]string{"id": id}, &dataset); err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// DeleteDataset 删除知识库
+func (c *Client) DeleteDataset(id string) error {
+	return c.query(http.MethodDelete, "/core/dataset/delete", map[string]string{"id": id}, nil)
+}
+
+// CreateCollectionTextRequest 从文本创建集合请求
+type CreateCollectionTextRequest struct {
+	Text             string                 `json:"text"`
+	DatasetId        string                 `json:"datasetId"`
+	ParentId         *string                `json:"parentId,omitempty"`
+	Name             string                 `json:"name"`
+	TrainingType     string                 `json:"trainingType"`
+	ChunkSettingMode string                 `json:"chunkSettingMode,omitempty"`
+	ChunkSize        int                    `json:"chunkSize,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// CreateCollectionLinkRequest 从链接创建集合请求
+type CreateCollectionLinkRequest struct {
+	Link         string                 `json:"link"`
+	DatasetId    string                 `json:"datasetId"`
+	ParentId     *string                `json:"parentId,omitempty"`
+	TrainingType string                 `json:"trainingType"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// CreateCollectionResult 创建集合结果
+type CreateCollectionResult struct {
+	CollectionId string          `json:"collectionId"`
+	Results      json.RawMessage `json:"results,omitempty"`
+}
+
+// CreateCollectionText 从文本创建集合
+func (c *Client) CreateCollectionText(req CreateCollectionTextRequest) (*CreateCollectionResult, error) {
+	var result CreateCollectionResult
+	if err := c.post("/core/dataset/collection/create/text", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateCollectionLink 从链接创建集合
+func (c *Client) CreateCollectionLink(req CreateCollectionLinkRequest) (*CreateCollectionResult, error) {
+	var result CreateCollectionResult
+	if err := c.post("/core/dataset/collection/create/link", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateCollectionRequest 创建空集合请求，数据随后通过 PushData 写入
+type CreateCollectionRequest struct {
+	DatasetId string                 `json:"datasetId"`
+	ParentId  *string                `json:"parentId,omitempty"`
+	Name      string                 `json:"name"`
+	Type      string                 `json:"type"` // virtual 表示手动录入数据的集合
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// CreateCollection 创建空集合，返回集合ID
+func (c *Client) CreateCollection(req CreateCollectionRequest) (string, error) {
+	if req.Type == "" {
+		req.Type = "virtual"
+	}
+	var id string
+	if err := c.post("/core/dataset/collection/create", req, &id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// DataItem 推送的一条数据
+type DataItem struct {
+	Q       string                   `json:"q"`
+	A       string                   `json:"a,omitempty"`
+	Indexes []map[string]interface{} `json:"indexes,omitempty"`
+}
+
+// PushDataRequest 推送数据请求
+type PushDataRequest struct {
+	CollectionId string     `json:"collectionId"`
+	TrainingType string     `json:"trainingType,omitempty"`
+	Prompt       string     `json:"prompt,omitempty"`
+	Data         []DataItem `json:"data"`
+}
+
+// PushDataResult 推送数据结果
+type PushDataResult struct {
+	InsertLen int               `json:"insertLen"`
+	OverToken []json.RawMessage `json:"overToken,omitempty"`
+	Repeat    []json.RawMessage `json:"repeat,omitempty"`
+	Error     []json.RawMessage `json:"error,omitempty"`
+}
+
+// PushData 向集合推送数据
+func (c *Client) PushData(req PushDataRequest) (*PushDataResult, error) {
+	var result PushDataResult
+	if err := c.post("/core/dataset/data/pushData", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SearchTestRequest 搜索测试请求
+type SearchTestRequest struct {
+	DatasetId  string  `json:"datasetId"`
+	Text       string  `json:"text"`
+	Limit      int     `json:"limit,omitempty"`
+	Similarity float64 `json:"similarity,omitempty"`
+	SearchMode string  `json:"searchMode,omitempty"`
+}
+
+// SearchResultItem 搜索结果
+type SearchResultItem struct {
+	ID           string          `json:"id"`
+	DatasetId    string          `json:"datasetId"`
+	CollectionId string          `json:"collectionId"`
+	SourceName   string          `json:"sourceName"`
+	SourceId     string          `json:"sourceId,omitempty"`
+	Q            string          `json:"q"`
+	A            string          `json:"a"`
+	ChunkIndex   int             `json:"chunkIndex"`
+	Score        json.RawMessage `json:"score,omitempty"`
+}
+
+// SearchTestResult 搜索测试结果
+type SearchTestResult struct {
+	List        []SearchResultItem `json:"list"`
+	Duration    string             `json:"duration"`
+	SearchMode  string             `json:"searchMode"`
+	Limit       int                `json:"limit"`
+	Similarity  float64            `json:"similarity"`
+	UsingReRank bool               `json:"usingReRank"`
+}
+
+// SearchTest 知识库搜索测试
+func (c *Client) SearchTest(req SearchTestRequest) (*SearchTestResult, error) {
+	var result SearchTestResult
+	if err := c.post("/core/dataset/searchTest", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
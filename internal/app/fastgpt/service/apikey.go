@@ -0,0 +1,66 @@
+package service
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix 个人 API Key 的固定前缀
+const APIKeyPrefix = "sk-hs-"
+
+// ErrInvalidAPIKey API Key 无效或已删除
+var ErrInvalidAPIKey = errors.New("无效的 API Key")
+
+// HashAPIKey 计算 API Key 的哈希，数据库只保存哈希
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey 为用户生成新的个人 API Key，明文只在创建时返回一次
+func CreateAPIKey(ctx context.Context, authInfo auth.Info, name string) (string, *model.PersonalAPIKey, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	plain := APIKeyPrefix + hex.EncodeToString(buf)
+
+	key := &model.PersonalAPIKey{
+		UserId:    authInfo.Uid,
+		StaffId:   authInfo.StaffId,
+		Name:      name,
+		KeyHash:   HashAPIKey(plain),
+		KeyPrefix: plain[:len(APIKeyPrefix)+4],
+	}
+	if err := dao.APIKey.CreateKey(ctx, key); err != nil {
+		return "", nil, err
+	}
+	return plain, key, nil
+}
+
+// AuthenticateAPIKey 校验个人 API Key，返回其所属用户的身份信息
+func AuthenticateAPIKey(ctx context.Context, plain string) (auth.Info, error) {
+	if !strings.HasPrefix(plain, APIKeyPrefix) {
+		return auth.Info{}, ErrInvalidAPIKey
+	}
+	key, err := dao.APIKey.GetKeyByHash(ctx, HashAPIKey(plain))
+	if err != nil {
+		return auth.Info{}, err
+	}
+	if key == nil {
+		return auth.Info{}, ErrInvalidAPIKey
+	}
+	if err := dao.APIKey.TouchKey(ctx, key.ID, time.Now()); err != nil {
+		logx.SystemLogger.CtxError(ctx, "update api key last used failed", err)
+	}
+	return auth.Info{Uid: key.UserId, StaffId: key.StaffId}, nil
+}
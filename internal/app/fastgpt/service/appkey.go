@@ -0,0 +1,129 @@
+package service
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/core/auth"
+	"HelpStudent/core/cryptox"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// appKeyring 根据配置构建主密钥环
+func appKeyring() (*cryptox.Keyring, error) {
+	cfg := config.GetConfig().FastGPT.KeyEncryption
+	keys := make(map[int]string, len(cfg.MasterKeys))
+	for _, k := range cfg.MasterKeys {
+		keys[k.Version] = k.Key
+	}
+	return cryptox.NewKeyring(cfg.CurrentVersion, keys)
+}
+
+// ValidateKeyEncryption 启动时检查主密钥配置，避免到创建应用时才发现无法加密
+func ValidateKeyEncryption() error {
+	keyring, err := appKeyring()
+	if err != nil {
+		return err
+	}
+	if err := keyring.Validate(); err != nil {
+		return fmt.Errorf("FastGPT.KeyEncryption 未配置当前版本 %d 的主密钥: %w", keyring.CurrentVersion(), err)
+	}
+	return nil
+}
+
+// EncryptAPIKey 使用当前版本的主密钥加密应用 API Key，应用主键作为附加数据，密文复制到其他应用后无法解密
+func EncryptAPIKey(appId, plain string) (string, error) {
+	keyring, err := appKeyring()
+	if err != nil {
+		return "", err
+	}
+	return keyring.Encrypt(plain, []byte(appId))
+}
+
+// DecryptAPIKey 解密应用 API Key，尚未迁移的明文原样返回
+func DecryptAPIKey(appId, stored string) (string, error) {
+	if !cryptox.IsEncrypted(stored) {
+		return stored, nil
+	}
+	keyring, err := appKeyring()
+	if err != nil {
+		return "", err
+	}
+	return keyring.Decrypt(stored, []byte(appId))
+}
+
+// MaskAPIKey 脱敏展示 API Key，例如 fastgpt-****abcd
+func MaskAPIKey(plain string) string {
+	prefix := ""
+	if i := strings.Index(plain, "-"); i > 0 {
+		prefix = plain[:i+1]
+	}
+	if len(plain)-len(prefix) <= 4 {
+		return prefix + "****"
+	}
+	return prefix + "****" + plain[len(plain)-4:]
+}
+
+// RevealAPIKey 管理员查看应用 API Key 明文，每次查看都会记录审计日志
+func RevealAPIKey(ctx context.Context, authInfo auth.Info, appId, clientIP string) (string, error) {
+	app, err := dao.FastgptApp.GetAppByPrimaryID(ctx, appId)
+	if err != nil {
+		return "", err
+	}
+	plain, err := DecryptAPIKey(app.ID, app.APIKey)
+	if err != nil {
+		return "", err
+	}
+	audit := &model.AppKeyAudit{
+		AppId:    app.ID,
+		StaffId:  authInfo.StaffId,
+		Action:   model.AppKeyActionReveal,
+		ClientIP: clientIP,
+	}
+	// 审计写入失败时不返回明文
+	if err := dao.FastgptApp.CreateKeyAudit(ctx, audit); err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// MigrateAPIKeys 加密所有明文 API Key，并将旧版本主密钥或未绑定应用主键的数据转为当前格式
+// 返回更新的行数
+func MigrateAPIKeys(ctx context.Context) (int, error) {
+	keyring, err := appKeyring()
+	if err != nil {
+		return 0, err
+	}
+
+	apps, err := dao.FastgptApp.ListAPIKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	migrated := 0
+	for _, app := range apps {
+		plain := app.APIKey
+		if cryptox.IsEncrypted(app.APIKey) {
+			version, err := cryptox.Version(app.APIKey)
+			if err != nil {
+				return migrated, fmt.Errorf("app %s: %w", app.ID, err)
+			}
+			if version == keyring.CurrentVersion() && cryptox.IsBound(app.APIKey) {
+				continue
+			}
+			if plain, err = keyring.Decrypt(app.APIKey, []byte(app.ID)); err != nil {
+				return migrated, fmt.Errorf("app %s: %w", app.ID, err)
+			}
+		}
+		sealed, err := keyring.Encrypt(plain, []byte(app.ID))
+		if err != nil {
+			return migrated, fmt.Errorf("app %s: %w", app.ID, err)
+		}
+		if err := dao.FastgptApp.UpdateAPIKey(ctx, app.ID, sealed); err != nil {
+			return migrated, fmt.Errorf("app %s: %w", app.ID, err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/core/auth"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	managerDAO "HelpStudent/internal/app/managers/dao"
+	subjectDAO "HelpStudent/internal/app/subject/dao"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrAppNotFound 应用不存在
+	ErrAppNotFound = errors.New("应用不存在或已禁用")
+	// ErrAppForbidden 用户无权使用该应用
+	ErrAppForbidden = errors.New("无权使用该应用")
+)
+
+// CanUseApp 检查用户是否可以使用指定应用
+// 管理员可以使用所有应用，普通用户需要选修了与 AppName 同名的科目
+func CanUseApp(authInfo auth.Info, app *model.FastgptApp) (bool, error) {
+	if authInfo.StaffId == "" {
+		return false, nil
+	}
+	if managerDAO.Managers.IsManager(authInfo.StaffId) {
+		return true, nil
+	}
+	return subjectDAO.Subject.HasUserSubject(authInfo.StaffId, app.AppName)
+}
+
+// AuthorizeApp 根据主键获取应用并校验用户权限
+func AuthorizeApp(authInfo auth.Info, fastgptAppId string) (*model.FastgptApp, error) {
+	if fastgptAppId == "" {
+		return nil, ErrAppNotFound
+	}
+	app, err := dao.FastgptApp.GetAppByID(fastgptAppId)
+	if err != nil {
+		return nil, ErrAppNotFound
+	}
+	return authorize(authInfo, app)
+}
+
+// AuthorizeAppByShareID 根据 ShareID 获取应用并校验用户权限
+func AuthorizeAppByShareID(authInfo auth.Info, shareId string) (*model.FastgptApp, error) {
+	app, err := dao.FastgptApp.GetAppByShareID(shareId)
+	if err != nil {
+		return nil, ErrAppNotFound
+	}
+	return authorize(authInfo, app)
+}
+
+// AuthorizeAppByModel 根据 OpenAI 请求中的 model 获取应用并校验用户权限
+// model 可以是 AppName，也可以是配置中的别名
+func AuthorizeAppByModel(authInfo auth.Info, modelName string) (*model.FastgptApp, error) {
+	if modelName == "" {
+		return nil, ErrAppNotFound
+	}
+	appName := modelName
+	for alias, name := range config.GetConfig().FastGPT.ModelAliases {
+		if strings.EqualFold(alias, modelName) {
+			appName = name
+			break
+		}
+	}
+	app, err := dao.FastgptApp.GetAppByName(appName)
+	if err != nil {
+		return nil, ErrAppNotFound
+	}
+	return authorize(authInfo, app)
+}
+
+func authorize(authInfo auth.Info, app *model.FastgptApp) (*model.FastgptApp, error) {
+	ok, err := CanUseApp(authInfo, app)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAppForbidden
+	}
+	// 返回的应用携带解密后的 API Key，供调用 FastGPT 使用
+	if app.APIKey, err = DecryptAPIKey(app.ID, app.APIKey); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
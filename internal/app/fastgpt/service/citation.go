@@ -0,0 +1,121 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// citationSnippetLen 引用片段的最大字符数
+const citationSnippetLen = 300
+
+// Citation 归一化后的回答引用，SourceLink 仅在来源为网页链接时返回
+type Citation struct {
+	Index          int     `json:"index"`
+	DataId         string  `json:"dataId"`
+	DatasetId      string  `json:"datasetId"`
+	CollectionId   string  `json:"collectionId"`
+	CollectionName string  `json:"collectionName"`
+	SourceLink     string  `json:"sourceLink,omitempty"`
+	ChunkIndex     int64   `json:"chunkIndex"`
+	Snippet        string  `json:"snippet"`
+	Score          float64 `json:"score"`
+	ScoreType      string  `json:"scoreType,omitempty"`
+}
+
+// CitationCollector 从流式响应的 flowResponses 中收集引用
+// 上游的 event 名不会转发，这里按内容识别节点数组，缓存回放时同样适用
+type CitationCollector struct {
+	citations []Citation
+	seen      map[string]bool
+}
+
+// Feed 处理一个 data 块
+func (c *CitationCollector) Feed(data string) {
+	if data == "" || data[0] != '[' || !gjson.Valid(data) {
+		return
+	}
+	c.collect(gjson.Parse(data))
+}
+
+// collect 收集节点的 quoteList，插件和工具调用的子节点同样处理
+func (c *CitationCollector) collect(nodes gjson.Result) {
+	for _, node := range nodes.Array() {
+		for _, q := range node.Get("quoteList").Array() {
+			c.add(q)
+		}
+		for _, key := range []string{"pluginDetail", "toolDetail", "childrenResponses"} {
+			if children := node.Get(key); children.IsArray() {
+				c.collect(children)
+			}
+		}
+	}
+}
+
+func (c *CitationCollector) add(q gjson.Result) {
+	id := q.Get("id").String()
+	if c.seen == nil {
+		c.seen = make(map[string]bool)
+	}
+	if id != "" {
+		if c.seen[id] {
+			return
+		}
+		c.seen[id] = true
+	}
+
+	cite := Citation{
+		Index:          len(c.citations) + 1,
+		DataId:         id,
+		DatasetId:      q.Get("datasetId").String(),
+		CollectionId:   q.Get("collectionId").String(),
+		CollectionName: q.Get("sourceName").String(),
+		ChunkIndex:     q.Get("chunkIndex").Int(),
+		Snippet:        citationSnippet(q.Get("q").String(), q.Get("a").String()),
+	}
+	if source := q.Get("sourceId").String(); strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		cite.SourceLink = source
+	}
+	cite.Score, cite.ScoreType = citationScore(q.Get("score"))
+	c.citations = append(c.citations, cite)
+}
+
+// Citations 返回收集到的引用，没有引用时返回空数组
+func (c *CitationCollector) Citations() []Citation {
+	if c.citations == nil {
+		return []Citation{}
+	}
+	return c.citations
+}
+
+// citationScore 优先使用重排得分，其次是向量检索得分，都没有时取第一项
+// 旧版本 FastGPT 的 score 为数字
+func citationScore(score gjson.Result) (float64, string) {
+	if score.Type == gjson.Number {
+		return score.Float(), ""
+	}
+	items := score.Array()
+	for _, t := range []string{"reRank", "embedding"} {
+		for _, s := range items {
+			if s.Get("type").String() == t {
+				return s.Get("value").Float(), t
+			}
+		}
+	}
+	if len(items) > 0 {
+		return items[0].Get("value").Float(), items[0].Get("type").String()
+	}
+	return 0, ""
+}
+
+func citationSnippet(q, a string) string {
+	text := strings.TrimSpace(q)
+	if a = strings.TrimSpace(a); a != "" {
+		text += "\n" + a
+	}
+	runes := []rune(text)
+	if len(runes) > citationSnippetLen {
+		return string(runes[:citationSnippetLen]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCitationCollector(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []Citation
+	}{
+		{
+			name:   "no flow responses",
+			chunks: []string{`{"choices":[{"delta":{"content":"你好"}}]}`, "[DONE]"},
+			want:   []Citation{},
+		},
+		{
+			name: "normalized fields",
+			chunks: []string{`[{"moduleType":"datasetSearchNode","quoteList":[` +
+				`{"id":"d1","datasetId":"ds1","collectionId":"c1","sourceName":"讲义.pdf","sourceId":"file1","chunkIndex":3,"q":" 极限的定义 ","a":"ε-δ 语言","score":[{"type":"embedding","value":0.8},{"type":"reRank","value":0.9}]},` +
+				`{"id":"d2","datasetId":"ds1","collectionId":"c2","sourceName":"百科","sourceId":"https://example.com/limit","chunkIndex":0,"q":"网页内容","score":0.75}` +
+				`]}]`},
+			want: []Citation{
+				{Index: 1, DataId: "d1", DatasetId: "ds1", CollectionId: "c1", CollectionName: "讲义.pdf", ChunkIndex: 3, Snippet: "极限的定义\nε-δ 语言", Score: 0.9, ScoreType: "reRank"},
+				{Index: 2, DataId: "d2", DatasetId: "ds1", CollectionId: "c2", CollectionName: "百科", SourceLink: "https://example.com/limit", Snippet: "网页内容", Score: 0.75},
+			},
+		},
+		{
+			name: "dedup across nodes and chunks",
+			chunks: []string{
+				`[{"quoteList":[{"id":"d1","q":"a"},{"id":"d1","q":"a"}]},{"quoteList":[{"id":"d2","q":"b"}]}]`,
+				`[{"quoteList":[{"id":"d2","q":"b"},{"id":"d3","q":"c"}]}]`,
+			},
+			want: []Citation{
+				{Index: 1, DataId: "d1", Snippet: "a"},
+				{Index: 2, DataId: "d2", Snippet: "b"},
+				{Index: 3, DataId: "d3", Snippet: "c"},
+			},
+		},
+		{
+			name:   "missing fields",
+			chunks: []string{`[{"quoteList":[{},{"q":"没有 id"},{"id":"d1","score":[]}]}]`},
+			want: []Citation{
+				{Index: 1},
+				{Index: 2, Snippet: "没有 id"},
+				{Index: 3, DataId: "d1"},
+			},
+		},
+		{
+			name:   "nested plugin and tool nodes",
+			chunks: []string{`[{"pluginDetail":[{"quoteList":[{"id":"p1","q":"插件"}]}]},{"toolDetail":[{"childrenResponses":[{"quoteList":[{"id":"t1","q":"工具"}]}]}]}]`},
+			want: []Citation{
+				{Index: 1, DataId: "p1", Snippet: "插件"},
+				{Index: 2, DataId: "t1", Snippet: "工具"},
+			},
+		},
+		{
+			name:   "first score type when no preferred type",
+			chunks: []string{`[{"quoteList":[{"id":"d1","score":[{"type":"fullText","value":2.5},{"type":"rrf","value":0.1}]}]}]`},
+			want:   []Citation{{Index: 1, DataId: "d1", Score: 2.5, ScoreType: "fullText"}},
+		},
+		{
+			name:   "invalid data ignored",
+			chunks: []string{"", "[DONE]", `[{"quoteList":`, `{"quoteList":[{"id":"x"}]}`},
+			want:   []Citation{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c CitationCollector
+			for _, data := range tt.chunks {
+				c.Feed(data)
+			}
+			got := c.Citations()
+			if got == nil {
+				t.Fatal("Citations returned nil")
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d citations %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("citation %d: got %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCitationSnippet(t *testing.T) {
+	long := strings.Repeat("字", citationSnippetLen+10)
+	tests := []struct {
+		name string
+		q, a string
+		want string
+	}{
+		{name: "question only", q: " 问题 ", want: "问题"},
+		{name: "with answer", q: "问题", a: " 答案 ", want: "问题\n答案"},
+		{name: "empty", want: ""},
+		{name: "truncated by rune", q: long, want: strings.Repeat("字", citationSnippetLen) + "…"},
+		{name: "exact length kept", q: long[:citationSnippetLen*3], want: strings.Repeat("字", citationSnippetLen)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := citationSnippet(tt.q, tt.a); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package service
 
 import (
+	"HelpStudent/config"
+	"HelpStudent/core/breaker"
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -15,94 +20,192 @@ type FastGPTClient struct {
 	BaseURL string
 	APIKey  string
 	Client  *http.Client
+
+	// ctx 请求 context，取消后中止请求并停止重试
+	ctx context.Context
+
+	// breakerName 熔断器名称，同名客户端共享一个熔断器
+	breakerName      string
+	maxRetries       int
+	retryBackoff     time.Duration
+	firstByteTimeout time.Duration
+	headers          map[string]string
+	stream           *http.Client
+}
+
+// ClientOption 自定义 FastGPTClient
+type ClientOption func(*FastGPTClient)
+
+// WithBreaker 使用指定名称的熔断器，一般按应用区分
+func WithBreaker(name string) ClientOption {
+	return func(c *FastGPTClient) {
+		c.breakerName = "fastgpt:" + name
+	}
+}
+
+// NewFastGPTClient 创建访问指定后端的 FastGPT 客户端，backendName 为空时使用默认后端
+func NewFastGPTClient(backendName, apiKey string, opts ...ClientOption) (*FastGPTClient, error) {
+	b, err := getBackend(backendName)
+	if err != nil {
+		return nil, err
+	}
+	cfg := config.GetConfig().FastGPT
+	c := &FastGPTClient{
+		BaseURL:          b.baseURL,
+		APIKey:           apiKey,
+		Client:           b.client,
+		ctx:              context.Background(),
+		breakerName:      "fastgpt:" + b.baseURL,
+		maxRetries:       cfg.MaxRetries,
+		retryBackoff:     durationOr(cfg.RetryBackoff, defaultRetryBackoff),
+		firstByteTimeout: b.firstByteTimeout,
+		headers:          b.headers,
+		stream:           b.stream,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// WithContext 返回绑定请求 context 的客户端副本，客户端断开后不再等待重试
+func (c *FastGPTClient) WithContext(ctx context.Context) *FastGPTClient {
+	cp := *c
+	cp.ctx = ctx
+	return &cp
 }
 
-// NewFastGPTClient 创建 FastGPT 客户端
-func NewFastGPTClient(baseURL, apiKey string) *FastGPTClient {
-	return &FastGPTClient{
-		BaseURL: baseURL,
-		APIKey:  apiKey,
-		Client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// callerCanceled 调用方取消（如用户离开页面）不代表上游故障，不计入熔断失败
+func callerCanceled(ctx context.Context) breaker.Acceptable {
+	return func(err error) bool {
+		return err == nil || (errors.Is(err, context.Canceled) && ctx.Err() != nil)
 	}
 }
 
+// setHeaders 设置后端附加请求头和鉴权头
+func (c *FastGPTClient) setHeaders(req *http.Request) {
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+}
+
 // ForwardRequest 转发请求到 FastGPT
 func (c *FastGPTClient) ForwardRequest(method, path string, body interface{}) ([]byte, int, error) {
-	var reqBody io.Reader
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, 0, fmt.Errorf("marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonData)
 	}
 
-	url := c.BaseURL + path
-	req, err := http.NewRequest(method, url, reqBody)
-	if err != nil {
-		return nil, 0, fmt.Errorf("create request: %w", err)
-	}
+	return c.send(method == http.MethodGet, func() (*http.Request, error) {
+		var reqBody io.Reader
+		if jsonData != nil {
+			reqBody = bytes.NewReader(jsonData)
+		}
+		req, err := http.NewRequestWithContext(c.ctx, method, c.BaseURL+path, reqBody)
+		if err != nil {
+			return nil, err
+		}
 
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		// 设置请求头
+		req.Header.Set("Content-Type", "application/json")
+		c.setHeaders(req)
+		return req, nil
+	})
+}
 
-	// 发送请求
-	resp, err := c.Client.Do(req)
-	if err != nil {
-		return nil, 0, fmt.Errorf("send request: %w", err)
-	}
-	defer resp.Body.Close()
+// ForwardRequestWithQuery 带查询参数转发请求到 FastGPT
+func (c *FastGPTClient) ForwardRequestWithQuery(method, path string, queryParams map[string]string) ([]byte, int, error) {
+	return c.send(method == http.MethodGet, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(c.ctx, method, c.BaseURL+path, nil)
+		if err != nil {
+			return nil, err
+		}
 
-	// 读取响应
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, resp.StatusCode, fmt.Errorf("read response: %w", err)
-	}
+		// 添加查询参数
+		if len(queryParams) > 0 {
+			q := req.URL.Query()
+			for key, value := range queryParams {
+				q.Add(key, value)
+			}
+			req.URL.RawQuery = q.Encode()
+		}
 
-	return respBody, resp.StatusCode, nil
+		// 设置请求头
+		c.setHeaders(req)
+		return req, nil
+	})
 }
 
-// ForwardRequestWithQuery 带查询参数转发请求到 FastGPT
-func (c *FastGPTClient) ForwardRequestWithQuery(method, path string, queryParams map[string]string) ([]byte, int, error) {
-	url := c.BaseURL + path
-	req, err := http.NewRequest(method, url, nil)
-	if err != nil {
-		return nil, 0, fmt.Errorf("create request: %w", err)
+// send 经过熔断器发送请求，幂等请求在网络错误或 5xx 时按指数退避重试
+// 最终仍为 5xx 时返回响应内容和状态码，由调用方决定如何处理
+func (c *FastGPTClient) send(idempotent bool, newRequest func() (*http.Request, error)) ([]byte, int, error) {
+	attempts := 1
+	if idempotent && c.maxRetries > 0 {
+		attempts += c.maxRetries
 	}
 
-	// 添加查询参数
-	if len(queryParams) > 0 {
-		q := req.URL.Query()
-		for key, value := range queryParams {
-			q.Add(key, value)
+	var (
+		respBody   []byte
+		statusCode int
+		err        error
+	)
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			timer := time.NewTimer(c.retryBackoff << (i - 1))
+			select {
+			case <-c.ctx.Done():
+				timer.Stop()
+				return nil, 0, c.ctx.Err()
+			case <-timer.C:
+			}
 		}
-		req.URL.RawQuery = q.Encode()
-	}
 
-	// 设置请求头
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		err = breaker.GetBreaker(c.breakerName).DoWithAcceptable(func() error {
+			req, err := newRequest()
+			if err != nil {
+				return fmt.Errorf("create request: %w", err)
+			}
 
-	// 发送请求
-	resp, err := c.Client.Do(req)
-	if err != nil {
-		return nil, 0, fmt.Errorf("send request: %w", err)
-	}
-	defer resp.Body.Close()
+			// 发送请求
+			resp, err := c.Client.Do(req)
+			if err != nil {
+				return fmt.Errorf("send request: %w", err)
+			}
+			defer resp.Body.Close()
 
-	// 读取响应
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, resp.StatusCode, fmt.Errorf("read response: %w", err)
-	}
+			// 读取响应
+			statusCode = resp.StatusCode
+			respBody, err = io.ReadAll(resp.Body)
+			if err != nil {
+				return fmt.Errorf("read response: %w", err)
+			}
+			if statusCode >= http.StatusInternalServerError {
+				return &upstreamStatusError{statusCode: statusCode}
+			}
+			return nil
+		}, callerCanceled(c.ctx))
 
-	return respBody, resp.StatusCode, nil
+		var statusErr *upstreamStatusError
+		switch {
+		case err == nil:
+			return respBody, statusCode, nil
+		case errors.Is(err, breaker.ErrServiceUnavailable), c.ctx.Err() != nil:
+			return nil, 0, err
+		case errors.As(err, &statusErr):
+			err = nil
+		}
+	}
+	return respBody, statusCode, err
 }
 
 // ForwardStreamRequest 转发流式请求到 FastGPT，返回响应对象用于流式读取
-func (c *FastGPTClient) ForwardStreamRequest(method, path string, body interface{}) (*http.Response, error) {
+// 只限制等待响应头的时间，响应体读取不设超时；ctx 取消时立即中断上游读取
+func (c *FastGPTClient) ForwardStreamRequest(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonData, err := json.Marshal(body)
@@ -112,34 +215,84 @@ func (c *FastGPTClient) ForwardStreamRequest(method, path string, body interface
 		reqBody = bytes.NewBuffer(jsonData)
 	}
 
-	url := c.BaseURL + path
-	req, err := http.NewRequest(method, url, reqBody)
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reqBody)
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	// 设置请求头
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	c.setHeaders(req)
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
 
-	// 流式客户端配置
-	streamClient := &http.Client{
-		Timeout: 0, // 流式请求不设置超时
-		Transport: &http.Transport{
-			DisableCompression: true, // 禁用压缩，确保数据实时到达
-		},
-	}
-	resp, err := streamClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
-	}
+	var resp *http.Response
+	err = breaker.GetBreaker(c.breakerName).DoWithAcceptable(func() error {
+		// 超过首字节超时仍未收到响应头则取消请求
+		timer := time.AfterFunc(c.firstByteTimeout, cancel)
+		defer timer.Stop()
+
+		var err error
+		resp, err = c.stream.Do(req)
+		if err != nil {
+			return fmt.Errorf("send request: %w", err)
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return &upstreamStatusError{statusCode: resp.StatusCode}
+		}
+		return nil
+	}, callerCanceled(parent)) // 首字节超时只取消派生的 context，仍计入失败
 
+	var statusErr *upstreamStatusError
+	if err != nil && !errors.As(err, &statusErr) {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
 	return resp, nil
 }
 
+// maxSSELineSize 单行 SSE 数据的上限，detail 模式的 flowResponses 带有全部引用内容，远超 bufio 默认的 64KB
+const maxSSELineSize = 16 << 20
+
+// NewSSEScanner 创建按行读取 SSE 响应的 Scanner
+func NewSSEScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxSSELineSize)
+	return scanner
+}
+
+// ScanChatStream 逐个读取 FastGPT 流式响应的 data 块及其 event 名，fn 返回 false 时停止读取
+func ScanChatStream(r io.Reader, fn func(event, data string) bool) error {
+	var event string
+	scanner := NewSSEScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// 空行结束一个事件
+		if len(strings.TrimSpace(line)) == 0 {
+			event = ""
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "event:"); ok {
+			event = strings.TrimSpace(name)
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if !fn(event, data) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
 // StreamReader 流式读取器
 type StreamReader struct {
 	scanner *bufio.Scanner
@@ -149,7 +302,7 @@ type StreamReader struct {
 // NewStreamReader 创建流式读取器
 func NewStreamReader(resp *http.Response) *StreamReader {
 	return &StreamReader{
-		scanner: bufio.NewScanner(resp.Body),
+		scanner: NewSSEScanner(resp.Body),
 		resp:    resp,
 	}
 }
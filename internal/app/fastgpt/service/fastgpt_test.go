@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(url string) *FastGPTClient {
+	return &FastGPTClient{
+		BaseURL:      url,
+		Client:       http.DefaultClient,
+		ctx:          context.Background(),
+		breakerName:  "fastgpt:test:" + url,
+		maxRetries:   3,
+		retryBackoff: 10 * time.Millisecond,
+	}
+}
+
+func TestFastGPTClient_Retry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":200}`))
+	}))
+	defer srv.Close()
+
+	body, status, err := newTestClient(srv.URL).ForwardRequestWithQuery(http.MethodGet, "/x", nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if status != http.StatusOK || string(body) != `{"code":200}` {
+		t.Errorf("got %d %s", status, body)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls: got %d, want 3", calls.Load())
+	}
+}
+
+func TestFastGPTClient_RetryStopsOnCancel(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	c.retryBackoff = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, _, err := c.WithContext(ctx).ForwardRequestWithQuery(http.MethodGet, "/x", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err: got %v, want context.Canceled", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("retry backoff ignored context cancel")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls: got %d, want 1", calls.Load())
+	}
+}
+
+func TestCallerCanceled(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "success", ctx: context.Background(), err: nil, want: true},
+		{name: "caller canceled", ctx: canceled, err: context.Canceled, want: true},
+		{name: "upstream error", ctx: context.Background(), err: errors.New("connection refused"), want: false},
+		{name: "derived timeout cancel", ctx: context.Background(), err: context.Canceled, want: false},
+		{name: "other error after cancel", ctx: canceled, err: &upstreamStatusError{statusCode: 502}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callerCanceled(tt.ctx)(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSSEScanner_LongLine(t *testing.T) {
+	// detail 模式的 flowResponses 可能有数 MB
+	long := "data: [" + strings.Repeat(`{"q":"引用"},`, 200000) + "{}]"
+	scanner := NewSSEScanner(strings.NewReader("data: [DONE]\n\n" + long + "\n"))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(lines) != 3 || lines[2] != long {
+		t.Errorf("got %d lines", len(lines))
+	}
+}
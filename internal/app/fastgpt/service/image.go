@@ -0,0 +1,331 @@
+package service
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrImageSignature   = errors.New("图片链接无效或已过期")
+	ErrImageTooLarge    = errors.New("图片过大")
+	ErrImageType        = errors.New("不支持的图片类型")
+	ErrImageUnavailable = errors.New("图片获取失败")
+)
+
+var defaultImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// ImageSettings 返回图片代理配置，未配置的项使用默认值
+func ImageSettings() config.Image {
+	cfg := config.GetConfig().FastGPT.Image
+	if cfg.SignKey == "" {
+		cfg.SignKey = config.GetConfig().Auth.Secret
+	}
+	if cfg.URLTTL <= 0 {
+		cfg.URLTTL = time.Hour
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 10
+	}
+	if cfg.MemoryCache == 0 {
+		cfg.MemoryCache = 64
+	}
+	if cfg.DiskTTL <= 0 {
+		cfg.DiskTTL = 7 * 24 * time.Hour
+	}
+	if len(cfg.AllowTypes) == 0 {
+		cfg.AllowTypes = defaultImageTypes
+	}
+	return cfg
+}
+
+// ImageBackends 返回查找图片时依次尝试的后端
+// 能根据 fastgptAppId 或 shareId 找到应用时只使用应用的后端，否则先默认后端再其它后端
+func ImageBackends(fastgptAppId, shareId string) []string {
+	if fastgptAppId != "" {
+		if app, err := dao.FastgptApp.GetAppByID(fastgptAppId); err == nil {
+			return []string{app.Backend}
+		}
+	}
+	if shareId != "" {
+		if app, err := dao.FastgptApp.GetAppByShareID(shareId); err == nil {
+			return []string{app.Backend}
+		}
+	}
+	return BackendNames()
+}
+
+// SignImageURL 生成带签名的图片链接，过期时间按有效期取整，同一时段内相同图片的链接不变，便于浏览器缓存
+// 链接的实际有效期在 URLTTL 到 2*URLTTL 之间
+func SignImageURL(imageId, fastgptAppId string, now time.Time) string {
+	ttl := int64(ImageSettings().URLTTL / time.Second)
+	if ttl <= 0 {
+		ttl = 1
+	}
+	exp := (now.Unix()/ttl + 2) * ttl
+	q := url.Values{}
+	if fastgptAppId != "" {
+		q.Set("fastgptAppId", fastgptAppId)
+	}
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", imageSignature(imageId, fastgptAppId, exp))
+	return "/api/system/img/" + url.PathEscape(imageId) + "?" + q.Encode()
+}
+
+// VerifyImageSignature 校验图片链接的签名和过期时间
+func VerifyImageSignature(imageId, fastgptAppId, exp, sig string, now time.Time) error {
+	expAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || sig == "" || now.Unix() > expAt {
+		return ErrImageSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(imageSignature(imageId, fastgptAppId, expAt))) {
+		return ErrImageSignature
+	}
+	return nil
+}
+
+func imageSignature(imageId, fastgptAppId string, exp int64) string {
+	return signFields(imageId, fastgptAppId, strconv.FormatInt(exp, 10))
+}
+
+// signFields 计算链接签名，密钥见 Image.SignKey
+func signFields(fields ...string) string {
+	mac := hmac.New(sha256.New, []byte(ImageSettings().SignKey))
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Image 代理返回的图片
+type Image struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+}
+
+// GetImage 依次从内存、磁盘缓存和后端获取图片，只返回允许的类型和大小
+func GetImage(ctx context.Context, backends []string, imageId, userAgent string) (*Image, error) {
+	cfg := ImageSettings()
+	for _, name := range backends {
+		key := name + "/" + imageId
+		if img, ok := imageMemory.get(key); ok {
+			return img, nil
+		}
+		if img, ok := loadDiskImage(cfg, key); ok {
+			imageMemory.add(key, img, cfg.MemoryCache<<20)
+			return img, nil
+		}
+	}
+
+	var lastErr error = ErrImageUnavailable
+	for _, name := range backends {
+		img, err := fetchImage(ctx, cfg, name, imageId, userAgent)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		key := name + "/" + imageId
+		imageMemory.add(key, img, cfg.MemoryCache<<20)
+		saveDiskImage(cfg, key, img)
+		return img, nil
+	}
+	return nil, lastErr
+}
+
+// fetchImage 从指定后端获取图片并校验类型和大小
+func fetchImage(ctx context.Context, cfg config.Image, backendName, imageId, userAgent string) (*Image, error) {
+	b, err := getBackend(backendName)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+"/system/img/"+url.PathEscape(imageId), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range b.headers {
+		req.Header.Set(key, value)
+	}
+	// 设置请求头，保持与原始请求一致
+	req.Header.Set("Accept", "image/*")
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImageUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrImageUnavailable, resp.StatusCode)
+	}
+
+	maxSize := cfg.MaxSize << 20
+	if resp.ContentLength > maxSize {
+		return nil, ErrImageTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImageUnavailable, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrImageTooLarge
+	}
+
+	contentType := imageContentType(cfg.AllowTypes, resp.Header.Get("Content-Type"), data)
+	if contentType == "" {
+		return nil, ErrImageType
+	}
+	return newImage(data, contentType), nil
+}
+
+// imageContentType 以内容识别出的类型为准，识别不出时才使用声明的类型，不在允许列表中返回空
+func imageContentType(allow []string, declared string, data []byte) string {
+	allowed := func(t string) bool {
+		for _, a := range allow {
+			if strings.EqualFold(a, t) {
+				return true
+			}
+		}
+		return false
+	}
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if allowed(sniffed) {
+		return sniffed
+	}
+	if strings.HasPrefix(sniffed, "text/html") {
+		return ""
+	}
+	declared, _, _ = mime.ParseMediaType(declared)
+	if allowed(declared) {
+		return declared
+	}
+	return ""
+}
+
+func newImage(data []byte, contentType string) *Image {
+	sum := sha256.Sum256(data)
+	return &Image{
+		Data:        data,
+		ContentType: contentType,
+		ETag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+}
+
+// diskImagePath 磁盘缓存文件路径，同名的 .type 文件保存图片类型
+func diskImagePath(cfg config.Image, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(cfg.CacheDir, name[:2], name)
+}
+
+func loadDiskImage(cfg config.Image, key string) (*Image, bool) {
+	if cfg.CacheDir == "" {
+		return nil, false
+	}
+	p := diskImagePath(cfg, key)
+	info, err := os.Stat(p)
+	if err != nil || time.Since(info.ModTime()) > cfg.DiskTTL {
+		return nil, false
+	}
+	contentType, err := os.ReadFile(p + ".type")
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	// 允许列表可能已修改
+	ct := imageContentType(cfg.AllowTypes, string(contentType), data)
+	if ct == "" {
+		return nil, false
+	}
+	return newImage(data, ct), true
+}
+
+// saveDiskImage 写入磁盘缓存，失败时忽略；过期文件不会自动删除，可按修改时间定期清理
+func saveDiskImage(cfg config.Image, key string, img *Image) {
+	if cfg.CacheDir == "" {
+		return
+	}
+	p := diskImagePath(cfg, key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return
+	}
+	// 先写临时文件再改名，避免并发读到不完整的内容
+	tmp := fmt.Sprintf("%s.%d.tmp", p, time.Now().UnixNano())
+	if err := os.WriteFile(tmp, img.Data, 0644); err != nil {
+		return
+	}
+	if err := os.WriteFile(p+".type", []byte(img.ContentType), 0644); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// imageLRU 按总字节数淘汰的内存缓存
+type imageLRU struct {
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	size  int64
+}
+
+type imageEntry struct {
+	key string
+	img *Image
+}
+
+var imageMemory = &imageLRU{ll: list.New(), items: make(map[string]*list.Element)}
+
+func (c *imageLRU) get(key string) (*Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*imageEntry).img, true
+	}
+	return nil, false
+}
+
+// add 加入缓存并淘汰最久未使用的图片直到不超过 capacity，超过 capacity 的单张图片不缓存
+func (c *imageLRU) add(key string, img *Image, capacity int64) {
+	size := int64(len(img.Data))
+	if capacity <= 0 || size > capacity {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.size -= int64(len(e.Value.(*imageEntry).img.Data))
+		e.Value.(*imageEntry).img = img
+		c.ll.MoveToFront(e)
+	} else {
+		c.items[key] = c.ll.PushFront(&imageEntry{key: key, img: img})
+	}
+	c.size += size
+	for c.size > capacity {
+		e := c.ll.Back()
+		entry := e.Value.(*imageEntry)
+		c.ll.Remove(e)
+		delete(c.items, entry.key)
+		c.size -= int64(len(entry.img.Data))
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"HelpStudent/config"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useImageConfig 使用只包含签名配置的测试配置，签名有效期为 1h，测试结束后恢复原配置
+func useImageConfig(t *testing.T) {
+	t.Helper()
+	cfg := &config.GlobalConfig{}
+	cfg.Auth.Secret = "test-secret"
+	cfg.FastGPT.Image.URLTTL = time.Hour
+
+	prev := config.GetConfig()
+	config.SetConfig(cfg)
+	t.Cleanup(func() { config.SetConfig(prev) })
+}
+
+// parseImageURL 从签名链接中取出图片 ID 和查询参数
+func parseImageURL(t *testing.T, link string) (string, url.Values) {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("parse %q failed: %v", link, err)
+	}
+	id, err := url.PathUnescape(strings.TrimPrefix(u.EscapedPath(), "/api/system/img/"))
+	if err != nil {
+		t.Fatalf("unescape %q failed: %v", u.EscapedPath(), err)
+	}
+	return id, u.Query()
+}
+
+func TestVerifyImageSignature(t *testing.T) {
+	useImageConfig(t)
+	now := time.Date(2026, 3, 2, 10, 20, 0, 0, time.UTC)
+	imageId, q := parseImageURL(t, SignImageURL("img/a b.png", "app1", now))
+	appId := q.Get("fastgptAppId")
+	exp, sig := q.Get("exp"), q.Get("sig")
+	expAt, _ := strconv.ParseInt(exp, 10, 64)
+
+	tests := []struct {
+		name    string
+		imageId string
+		appId   string
+		exp     string
+		sig     string
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", imageId: imageId, appId: appId, exp: exp, sig: sig, now: now},
+		{name: "at expiry", imageId: imageId, appId: appId, exp: exp, sig: sig, now: time.Unix(expAt, 0)},
+		{name: "expired", imageId: imageId, appId: appId, exp: exp, sig: sig, now: time.Unix(expAt+1, 0), wantErr: true},
+		{name: "other image", imageId: "img/other.png", appId: appId, exp: exp, sig: sig, now: now, wantErr: true},
+		{name: "other app", imageId: imageId, appId: "app2", exp: exp, sig: sig, now: now, wantErr: true},
+		{name: "app removed", imageId: imageId, appId: "", exp: exp, sig: sig, now: now, wantErr: true},
+		{name: "extended exp", imageId: imageId, appId: appId, exp: strconv.FormatInt(expAt+3600, 10), sig: sig, now: now, wantErr: true},
+		{name: "sig of other exp", imageId: imageId, appId: appId, exp: exp, sig: imageSignature(imageId, appId, expAt-3600), now: now, wantErr: true},
+		{name: "empty sig", imageId: imageId, appId: appId, exp: exp, sig: "", now: now, wantErr: true},
+		{name: "bad exp", imageId: imageId, appId: appId, exp: "soon", sig: sig, now: now, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyImageSignature(tt.imageId, tt.appId, tt.exp, tt.sig, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err: got %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && err != ErrImageSignature {
+				t.Errorf("err: got %v, want ErrImageSignature", err)
+			}
+		})
+	}
+}
+
+func TestSignImageURL(t *testing.T) {
+	useImageConfig(t)
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		a, b      time.Time
+		wantEqual bool
+	}{
+		{name: "same period", a: start, b: start.Add(59 * time.Minute), wantEqual: true},
+		{name: "next period", a: start.Add(59 * time.Minute), b: start.Add(time.Hour), wantEqual: false},
+		{name: "same instant", a: start.Add(30 * time.Minute), b: start.Add(30 * time.Minute), wantEqual: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := SignImageURL("img1", "app1", tt.a), SignImageURL("img1", "app1", tt.b)
+			if (a == b) != tt.wantEqual {
+				t.Errorf("got %q and %q, wantEqual %v", a, b, tt.wantEqual)
+			}
+			// 实际有效期在 URLTTL 到 2*URLTTL 之间
+			for _, now := range []time.Time{tt.a, tt.b} {
+				_, q := parseImageURL(t, SignImageURL("img1", "app1", now))
+				exp, _ := strconv.ParseInt(q.Get("exp"), 10, 64)
+				if left := time.Unix(exp, 0).Sub(now); left <= time.Hour || left > 2*time.Hour {
+					t.Errorf("ttl at %v: got %v", now, left)
+				}
+			}
+		})
+	}
+
+	// 不带应用 ID 的链接不包含 fastgptAppId 参数，也能通过校验
+	id, q := parseImageURL(t, SignImageURL("img1", "", start))
+	if q.Has("fastgptAppId") {
+		t.Errorf("unexpected fastgptAppId in %v", q)
+	}
+	if err := VerifyImageSignature(id, "", q.Get("exp"), q.Get("sig"), start); err != nil {
+		t.Errorf("verify without app: %v", err)
+	}
+}
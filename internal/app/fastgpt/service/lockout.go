@@ -0,0 +1,49 @@
+package service
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/internal/app/fastgpt/model"
+	managerDAO "HelpStudent/internal/app/managers/dao"
+	subjectService "HelpStudent/internal/app/subject/service"
+	"context"
+	"time"
+)
+
+// LockoutCode 科目处于考试/作业限制时段的错误码
+const LockoutCode = 403015
+
+// LockoutError 科目当前禁止提问
+type LockoutError struct {
+	Until   time.Time
+	Message string
+}
+
+func (e *LockoutError) Error() string {
+	return e.Message
+}
+
+// CheckLockout 检查应用对应科目的限制时段，block 时返回 LockoutError，restrict 时返回需要注入的提示词
+// 管理员不受限制，便于在考试期间检查应用
+func CheckLockout(ctx context.Context, authInfo auth.Info, app *model.FastgptApp) (string, error) {
+	if managerDAO.Managers.IsManager(authInfo.StaffId) {
+		return "", nil
+	}
+	status, err := subjectService.GetLockoutStatus(ctx, app.AppName, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if status.Blocked {
+		msg := status.Message
+		if msg == "" {
+			msg = "该科目当前处于考试/作业时段，暂不可使用"
+			if !status.Until.IsZero() {
+				msg += "，恢复时间 " + status.Until.Format("2006-01-02 15:04:05")
+			}
+		}
+		return "", &LockoutError{Until: status.Until, Message: msg}
+	}
+	if status.Restricted {
+		return status.Prompt, nil
+	}
+	return "", nil
+}
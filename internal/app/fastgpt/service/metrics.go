@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var streamAborted metric.Int64Counter
+
+func init() {
+	var err error
+	streamAborted, err = otel.Meter("HelpStudent/fastgpt").Int64Counter(
+		"fastgpt.stream.aborted",
+		metric.WithDescription("客户端断开导致提前终止的 FastGPT 流式请求数"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+// RecordStreamAborted 记录一次因客户端断开而中止的流式请求
+func RecordStreamAborted(ctx context.Context, appId string) {
+	if streamAborted == nil {
+		return
+	}
+	streamAborted.Add(context.WithoutCancel(ctx), 1, metric.WithAttributes(attribute.String("app_id", appId)))
+}
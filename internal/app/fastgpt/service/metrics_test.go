@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRecordStreamAborted(t *testing.T) {
+	// 指标在 init 中创建，注册 MeterProvider 后应转发到新的 provider
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	RecordStreamAborted(ctx, "app1")
+	RecordStreamAborted(ctx, "app1")
+	RecordStreamAborted(ctx, "app2")
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	got := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "fastgpt.stream.aborted" {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("unexpected data type %T", m.Data)
+			}
+			for _, dp := range sum.DataPoints {
+				app, _ := dp.Attributes.Value(attribute.Key("app_id"))
+				got[app.AsString()] = dp.Value
+			}
+		}
+	}
+	if got["app1"] != 2 || got["app2"] != 1 {
+		t.Errorf("aborted streams: got %v, want app1=2 app2=1", got)
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/stringx"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// moderationTTL 词表缓存有效期，管理员修改后会立即失效
+const moderationTTL = time.Minute
+
+// moderationRule 编译后的词表
+type moderationRule struct {
+	list    model.ModerationList
+	trie    stringx.Trie
+	maxRune int // 最长敏感词的字符数，用于流式检测时保留跨块的尾部
+}
+
+var moderationCache struct {
+	sync.Mutex
+	rules    []moderationRule
+	loadedAt time.Time
+}
+
+// ReloadModeration 使词表缓存失效，下次检查时重新从数据库加载
+func ReloadModeration() {
+	moderationCache.Lock()
+	moderationCache.rules = nil
+	moderationCache.loadedAt = time.Time{}
+	moderationCache.Unlock()
+}
+
+// moderationRules 获取已启用的词表，缓存过期时重新加载
+func moderationRules(ctx context.Context) ([]moderationRule, error) {
+	moderationCache.Lock()
+	defer moderationCache.Unlock()
+	if !moderationCache.loadedAt.IsZero() && time.Since(moderationCache.loadedAt) < moderationTTL {
+		return moderationCache.rules, nil
+	}
+
+	lists, err := dao.Moderation.ListLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]moderationRule, 0, len(lists))
+	for _, list := range lists {
+		if !list.Enabled {
+			continue
+		}
+		words, err := dao.Moderation.GetWords(ctx, list.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(words) == 0 {
+			continue
+		}
+		rules = append(rules, newModerationRule(list, words))
+	}
+	moderationCache.rules = rules
+	moderationCache.loadedAt = time.Now()
+	return rules, nil
+}
+
+// newModerationRule 编译词表
+func newModerationRule(list model.ModerationList, words []string) moderationRule {
+	rule := moderationRule{list: list, trie: stringx.NewTrie(words)}
+	for _, w := range words {
+		if n := utf8.RuneCountInString(w); n > rule.maxRune {
+			rule.maxRune = n
+		}
+	}
+	return rule
+}
+
+// ModerationResult 敏感词检查结果
+type ModerationResult struct {
+	Blocked bool     // 命中 block 词表
+	Text    string   // mask 词表处理后的文本
+	Terms   []string // 命中的敏感词
+}
+
+// Moderate 使用作用于 direction 的词表检查文本，命中即记录事件
+// 加载词表失败时放行并记录日志，避免数据库异常导致对话不可用
+func Moderate(ctx context.Context, authInfo auth.Info, appId, direction, text string) ModerationResult {
+	result := ModerationResult{Text: text}
+	if text == "" {
+		return result
+	}
+	rules, err := moderationRules(ctx)
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, "load moderation lists failed", err)
+		return result
+	}
+	for _, rule := range rules {
+		if !rule.list.AppliesTo(direction) {
+			continue
+		}
+		terms := rule.trie.FindKeywords(result.Text)
+		if len(terms) == 0 {
+			continue
+		}
+		result.apply(rule, terms)
+		recordModeration(ctx, authInfo, appId, direction, rule.list, terms, text)
+	}
+	return result
+}
+
+// apply 根据词表的处理方式更新结果
+func (r *ModerationResult) apply(rule moderationRule, terms []string) {
+	r.Terms = append(r.Terms, terms...)
+	switch rule.list.Action {
+	case model.ModerationBlock:
+		r.Blocked = true
+	case model.ModerationMask:
+		r.Text, _, _ = rule.trie.Filter(r.Text)
+	}
+}
+
+// recordModeration 记录命中事件，写入失败只记录日志
+func recordModeration(ctx context.Context, authInfo auth.Info, appId, direction string, list model.ModerationList, terms []string, text string) {
+	event := &model.ModerationEvent{
+		UserId:    authInfo.Uid,
+		StaffId:   authInfo.StaffId,
+		AppId:     appId,
+		ListId:    list.ID,
+		ListName:  list.Name,
+		Action:    list.Action,
+		Direction: direction,
+		Terms:     strings.Join(terms, ","),
+		Excerpt:   stringx.FirstN(text, 200, "..."),
+	}
+	if err := dao.Moderation.CreateEvent(context.WithoutCancel(ctx), event); err != nil {
+		logx.SystemLogger.CtxError(ctx, "record moderation event failed", err)
+	}
+}
+
+// AnswerModerator 检查流式回答，每块末尾最长敏感词减一个字符的部分暂不输出，
+// 与下一块合并检查后再输出，跨块的敏感词也能被 mask 替换，回答结束时调用 Flush 取出剩余部分
+type AnswerModerator struct {
+	rules   []moderationRule
+	hold    int    // 暂不输出的字符数
+	pending []rune // 已检查但尚未输出的尾部
+	record  func(rule moderationRule, terms []string, excerpt string)
+}
+
+// NewAnswerModerator 创建流式回答检查器
+func NewAnswerModerator(ctx context.Context, authInfo auth.Info, appId string) *AnswerModerator {
+	var rules []moderationRule
+	all, err := moderationRules(ctx)
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, "load moderation lists failed", err)
+	}
+	for _, rule := range all {
+		if rule.list.AppliesTo(model.ModerationAnswer) {
+			rules = append(rules, rule)
+		}
+	}
+	return newAnswerModerator(rules, func(rule moderationRule, terms []string, excerpt string) {
+		recordModeration(ctx, authInfo, appId, model.ModerationAnswer, rule.list, terms, excerpt)
+	})
+}
+
+func newAnswerModerator(rules []moderationRule, record func(moderationRule, []string, string)) *AnswerModerator {
+	m := &AnswerModerator{rules: rules, record: record}
+	for _, rule := range rules {
+		if rule.maxRune-1 > m.hold {
+			m.hold = rule.maxRune - 1
+		}
+	}
+	return m
+}
+
+// Feed 检查一个回答增量，返回可以输出的部分，可能为空
+func (m *AnswerModerator) Feed(delta string) ModerationResult {
+	result := ModerationResult{Text: delta}
+	if delta == "" || len(m.rules) == 0 {
+		return result
+	}
+	held := string(m.pending)
+	result.Text = held + delta
+	for _, rule := range m.rules {
+		// 只统计新出现的敏感词，已在尾部命中过的不重复记录
+		seen := make(map[string]bool)
+		for _, term := range rule.trie.FindKeywords(held) {
+			seen[term] = true
+		}
+		var terms []string
+		for _, term := range rule.trie.FindKeywords(result.Text) {
+			if !seen[term] {
+				terms = append(terms, term)
+			}
+		}
+		if len(terms) == 0 {
+			continue
+		}
+		excerpt := result.Text
+		result.apply(rule, terms)
+		m.record(rule, terms, excerpt)
+	}
+
+	window := []rune(result.Text)
+	cut := len(window) - m.hold
+	if cut < 0 {
+		cut = 0
+	}
+	m.pending = window[cut:]
+	result.Text = string(window[:cut])
+	return result
+}
+
+// Flush 回答结束时返回尚未输出的尾部，尾部已在 Feed 中检查过
+func (m *AnswerModerator) Flush() string {
+	rest := string(m.pending)
+	m.pending = nil
+	return rest
+}
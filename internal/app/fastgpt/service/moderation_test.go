@@ -0,0 +1,144 @@
+package service
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// hit 测试中记录的一次命中
+type hit struct {
+	action string
+	terms  string
+}
+
+func newTestModerator(rules ...moderationRule) (*AnswerModerator, *[]hit) {
+	var hits []hit
+	m := newAnswerModerator(rules, func(rule moderationRule, terms []string, _ string) {
+		sort.Strings(terms)
+		hits = append(hits, hit{action: rule.list.Action, terms: strings.Join(terms, ",")})
+	})
+	return m, &hits
+}
+
+func testRule(action string, words ...string) moderationRule {
+	return newModerationRule(model.ModerationList{Name: action, Action: action}, words)
+}
+
+// feedAll 依次输入各块并在结束时 Flush，返回拼接后的输出和每块的输出
+func feedAll(m *AnswerModerator, chunks []string) (string, []string, bool) {
+	var out strings.Builder
+	var parts []string
+	for _, c := range chunks {
+		result := m.Feed(c)
+		if result.Blocked {
+			return out.String(), parts, true
+		}
+		parts = append(parts, result.Text)
+		out.WriteString(result.Text)
+	}
+	rest := m.Flush()
+	parts = append(parts, rest)
+	out.WriteString(rest)
+	return out.String(), parts, false
+}
+
+func TestAnswerModerator_Mask(t *testing.T) {
+	tests := []struct {
+		name     string
+		words    []string
+		chunks   []string
+		want     string
+		wantHits []string
+	}{
+		{name: "no term", words: []string{"作弊"}, chunks: []string{"认真", "复习"}, want: "认真复习", wantHits: nil},
+		{name: "term inside chunk", words: []string{"作弊"}, chunks: []string{"不要作弊哦"}, want: "不要**哦", wantHits: []string{"作弊"}},
+		{name: "term split across chunks", words: []string{"作弊"}, chunks: []string{"考试作", "弊是不对的"}, want: "考试**是不对的", wantHits: []string{"作弊"}},
+		{name: "term split across three chunks", words: []string{"考试作弊"}, chunks: []string{"不要考", "试", "作弊"}, want: "不要****", wantHits: []string{"考试作弊"}},
+		{name: "term at end of stream", words: []string{"作弊"}, chunks: []string{"不要", "作", "弊"}, want: "不要**", wantHits: []string{"作弊"}},
+		{name: "single rune chunks", words: []string{"abc"}, chunks: []string{"x", "a", "b", "c", "y"}, want: "x***y", wantHits: []string{"abc"}},
+		{name: "empty chunks", words: []string{"作弊"}, chunks: []string{"", "作", "", "弊", ""}, want: "**", wantHits: []string{"作弊"}},
+		{name: "same term twice", words: []string{"作弊"}, chunks: []string{"作弊", "和作", "弊"}, want: "**和**", wantHits: []string{"作弊", "作弊"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, hits := newTestModerator(testRule(model.ModerationMask, tt.words...))
+			got, _, blocked := feedAll(m, tt.chunks)
+			if blocked {
+				t.Fatal("mask rule blocked the answer")
+			}
+			if got != tt.want {
+				t.Errorf("output: got %q, want %q", got, tt.want)
+			}
+			if len(*hits) != len(tt.wantHits) {
+				t.Fatalf("hits: got %v, want %v", *hits, tt.wantHits)
+			}
+			for i, h := range *hits {
+				if h.terms != tt.wantHits[i] {
+					t.Errorf("hit %d: got %q, want %q", i, h.terms, tt.wantHits[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAnswerModerator_HoldBack(t *testing.T) {
+	// 最长敏感词 3 个字符，每块最多暂存 2 个字符
+	m, _ := newTestModerator(testRule(model.ModerationMask, "ab", "xyz"))
+	_, parts, _ := feedAll(m, []string{"12345", "6", "78"})
+	want := []string{"123", "4", "56", "78"}
+	if strings.Join(parts, "|") != strings.Join(want, "|") {
+		t.Errorf("parts: got %q, want %q", parts, want)
+	}
+
+	// 没有词表时原样输出，不暂存
+	m, _ = newTestModerator()
+	_, parts, _ = feedAll(m, []string{"12345", "6"})
+	want = []string{"12345", "6", ""}
+	if strings.Join(parts, "|") != strings.Join(want, "|") {
+		t.Errorf("parts without rules: got %q, want %q", parts, want)
+	}
+}
+
+func TestAnswerModerator_Block(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   bool
+	}{
+		{name: "clean", chunks: []string{"正常", "回答"}, want: false},
+		{name: "inside chunk", chunks: []string{"这是违禁词"}, want: true},
+		{name: "split across chunks", chunks: []string{"这是违", "禁", "词"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestModerator(testRule(model.ModerationBlock, "违禁词"))
+			if _, _, blocked := feedAll(m, tt.chunks); blocked != tt.want {
+				t.Errorf("blocked: got %v, want %v", blocked, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnswerModerator_MultipleRules(t *testing.T) {
+	m, hits := newTestModerator(
+		testRule(model.ModerationLog, "答案"),
+		testRule(model.ModerationMask, "作弊"),
+	)
+	got, _, _ := feedAll(m, []string{"作弊拿到答", "案"})
+	if got != "**拿到答案" {
+		t.Errorf("output: got %q", got)
+	}
+	want := []hit{{model.ModerationMask, "作弊"}, {model.ModerationLog, "答案"}}
+	if len(*hits) != len(want) {
+		t.Fatalf("hits: got %v, want %v", *hits, want)
+	}
+	for i := range want {
+		if (*hits)[i] != want[i] {
+			t.Errorf("hit %d: got %v, want %v", i, (*hits)[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// promptTTL 模板缓存有效期，保存或回滚后会立即失效
+const promptTTL = time.Minute
+
+// PromptPlaceholders 模板中可以使用的变量
+var PromptPlaceholders = []string{"studentName", "staffId", "course", "date"}
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_]\w*)\s*\}\}`)
+
+type cachedPrompt struct {
+	tpl      *model.PromptTemplate // 为 nil 表示没有生效的模板
+	loadedAt time.Time
+}
+
+var promptCache sync.Map // appId -> cachedPrompt
+
+// ReloadPromptTemplate 使应用的模板缓存失效
+func ReloadPromptTemplate(appId string) {
+	promptCache.Delete(appId)
+}
+
+// ValidatePromptTemplate 检查注入方式和模板中的变量
+func ValidatePromptTemplate(mode, variableKey, content string) error {
+	switch mode {
+	case model.PromptModeSystem:
+	case model.PromptModeVariables:
+		if variableKey == "" {
+			return errors.New("variables 方式需要指定变量名")
+		}
+	default:
+		return errors.New("注入方式应为 system 或 variables")
+	}
+	known := make(map[string]bool, len(PromptPlaceholders))
+	for _, name := range PromptPlaceholders {
+		known[name] = true
+	}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		if !known[m[1]] {
+			return fmt.Errorf("不支持的变量 {{%s}}", m[1])
+		}
+	}
+	return nil
+}
+
+// RenderPromptTemplate 用当前用户和应用的信息替换模板中的变量
+func RenderPromptTemplate(content string, authInfo auth.Info, app *model.FastgptApp, now time.Time) string {
+	values := map[string]string{
+		"studentName": authInfo.Name,
+		"staffId":     authInfo.StaffId,
+		"course":      app.AppName,
+		"date":        now.Format("2006-01-02"),
+	}
+	return placeholderPattern.ReplaceAllStringFunc(content, func(s string) string {
+		name := placeholderPattern.FindStringSubmatch(s)[1]
+		if v, ok := values[name]; ok {
+			return v
+		}
+		return s
+	})
+}
+
+// ActivePromptTemplate 获取应用当前生效的模板，没有模板或模板已停用时返回 nil
+func ActivePromptTemplate(ctx context.Context, appId string) (*model.PromptTemplate, error) {
+	if v, ok := promptCache.Load(appId); ok {
+		cached := v.(cachedPrompt)
+		if time.Since(cached.loadedAt) < promptTTL {
+			return cached.tpl, nil
+		}
+	}
+
+	tpl, err := dao.Prompt.GetActive(ctx, appId)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		tpl = nil
+	case err != nil:
+		return nil, err
+	case tpl.Content == "":
+		tpl = nil
+	}
+	promptCache.Store(appId, cachedPrompt{tpl: tpl, loadedAt: time.Now()})
+	return tpl, nil
+}
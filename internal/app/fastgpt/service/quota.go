@@ -0,0 +1,311 @@
+package service
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/core/auth"
+	"HelpStudent/core/cache"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/store/rds"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	managerDAO "HelpStudent/internal/app/managers/dao"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// quotaConfCacheSeconds 配额配置在内存中的缓存时间
+	quotaConfCacheSeconds = 60
+	// monthTokensCacheSeconds 月度 token 热计数的缓存时间，过期后从数据库重新加载
+	monthTokensCacheSeconds = 3600
+)
+
+// 超出配额的错误码，HTTP 状态码为 429
+const (
+	QuotaCodeUserRPM    = 429001
+	QuotaCodeUserDaily  = 429002
+	QuotaCodeUserTokens = 429003
+	QuotaCodeAppRPM     = 429011
+	QuotaCodeAppDaily   = 429012
+	QuotaCodeAppTokens  = 429013
+)
+
+// QuotaError 超出配额
+type QuotaError struct {
+	Code    int
+	Message string
+}
+
+func (e *QuotaError) Error() string {
+	return e.Message
+}
+
+// limits 某个范围下实际生效的配额
+type limits struct {
+	scope string
+	id    string
+	quota model.ChatQuota
+}
+
+// CheckQuota 检查用户和应用的配额，通过后占用一次请求和一条消息的额度
+// 管理员不受单用户配额限制，但仍计入应用配额
+func CheckQuota(ctx context.Context, authInfo auth.Info, app *model.FastgptApp) error {
+	now := time.Now()
+	var checks []limits
+
+	if !managerDAO.Managers.IsManager(authInfo.StaffId) {
+		q, err := effectiveQuota(ctx, model.QuotaScopeUser, authInfo.StaffId)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, limits{scope: model.QuotaScopeUser, id: authInfo.Uid, quota: q})
+	}
+	q, err := effectiveQuota(ctx, model.QuotaScopeApp, app.ID)
+	if err != nil {
+		return err
+	}
+	checks = append(checks, limits{scope: model.QuotaScopeApp, id: app.ID, quota: q})
+
+	// token 用量在回答结束后才知道，只检查不占用
+	for _, l := range checks {
+		if err := l.checkTokens(ctx, now); err != nil {
+			return err
+		}
+	}
+	var steps []quotaStep
+	for _, l := range checks {
+		steps = append(steps, l.steps(now)...)
+	}
+	return reserve(ctx, steps)
+}
+
+// quotaStep 一项需要占用的计数额度
+type quotaStep struct {
+	take    func(ctx context.Context) (bool, error) // 占用一次额度，已达上限时不占用并返回 false
+	release func(ctx context.Context)               // 归还已占用的额度
+	err     *QuotaError                             // 已达上限时返回的错误
+}
+
+// reserve 依次占用各项额度，任一项已达上限或出错时归还之前占用的额度
+func reserve(ctx context.Context, steps []quotaStep) error {
+	for i, s := range steps {
+		ok, err := s.take(ctx)
+		if err == nil && ok {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			steps[j].release(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		return s.err
+	}
+	return nil
+}
+
+// steps 返回该范围下需要占用的计数额度，未配置的项不占用
+// 每分钟请求数是短窗口的限流，使用内存计数；每日消息数需要跨重启和多实例累计，保存在数据库
+func (l limits) steps(now time.Time) []quotaStep {
+	var steps []quotaStep
+	if l.quota.RequestsPerMinute > 0 {
+		key := rpmKey(l.scope, l.id, now)
+		limit := int64(l.quota.RequestsPerMinute)
+		steps = append(steps, quotaStep{
+			take: func(ctx context.Context) (bool, error) {
+				return takeCounter(ctx, key, limit, 60), nil
+			},
+			release: func(ctx context.Context) {
+				// 窗口已过期时不再归还，避免留下没有过期时间的负数计数
+				if exist, _ := cache.ExistsCtx(ctx, key); exist {
+					_, _ = cache.IncrByCtx(ctx, key, -1)
+				}
+			},
+			err: l.limitErr(quotaRPM),
+		})
+	}
+	if l.quota.MessagesPerDay > 0 {
+		day := dailyWindow(now)
+		limit := int64(l.quota.MessagesPerDay)
+		steps = append(steps, quotaStep{
+			take: func(ctx context.Context) (bool, error) {
+				return dao.Quota.TakeDailyCounter(ctx, l.scope, l.id, day, limit)
+			},
+			release: func(ctx context.Context) {
+				if err := dao.Quota.ReleaseDailyCounter(context.WithoutCancel(ctx), l.scope, l.id, day); err != nil {
+					logx.SystemLogger.CtxError(ctx, "release daily quota failed", err)
+				}
+			},
+			err: l.limitErr(quotaDaily),
+		})
+	}
+	return steps
+}
+
+func (l limits) checkTokens(ctx context.Context, now time.Time) error {
+	if l.quota.TokensPerMonth <= 0 {
+		return nil
+	}
+	used, err := monthTokens(ctx, l.scope, l.id, now)
+	if err != nil {
+		return err
+	}
+	if used >= l.quota.TokensPerMonth {
+		return l.limitErr(quotaTokens)
+	}
+	return nil
+}
+
+const (
+	quotaRPM = iota
+	quotaDaily
+	quotaTokens
+)
+
+// limitErr 返回某项配额已达上限的错误
+func (l limits) limitErr(kind int) *QuotaError {
+	isUser := l.scope == model.QuotaScopeUser
+	switch kind {
+	case quotaRPM:
+		if isUser {
+			return &QuotaError{Code: QuotaCodeUserRPM, Message: "请求过于频繁，请稍后再试"}
+		}
+		return &QuotaError{Code: QuotaCodeAppRPM, Message: "该应用当前请求过多，请稍后再试"}
+	case quotaDaily:
+		if isUser {
+			return &QuotaError{Code: QuotaCodeUserDaily, Message: fmt.Sprintf("今日消息数已达上限（%d 条）", l.quota.MessagesPerDay)}
+		}
+		return &QuotaError{Code: QuotaCodeAppDaily, Message: "该应用今日消息数已达上限"}
+	default:
+		if isUser {
+			return &QuotaError{Code: QuotaCodeUserTokens, Message: "本月 token 用量已达上限"}
+		}
+		return &QuotaError{Code: QuotaCodeAppTokens, Message: "该应用本月 token 用量已达上限"}
+	}
+}
+
+// RecordUsage 记录一次聊天的 token 用量，失败只记录日志
+func RecordUsage(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, tokens int64) {
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+	if err := dao.Quota.AddUsage(ctx, authInfo.Uid, app.ID, period(now), 1, tokens); err != nil {
+		logx.SystemLogger.CtxError(ctx, "record chat usage failed", err)
+		return
+	}
+	if tokens <= 0 {
+		return
+	}
+	// 热计数只在已加载时累加，未加载时下次会从数据库读取最新值
+	for _, key := range []string{
+		monthTokensKey(model.QuotaScopeUser, authInfo.Uid, now),
+		monthTokensKey(model.QuotaScopeApp, app.ID, now),
+	} {
+		if exist, _ := cache.ExistsCtx(ctx, key); exist {
+			_, _ = cache.IncrByCtx(ctx, key, tokens)
+		}
+	}
+}
+
+// InvalidateQuota 配额配置变更后清除缓存
+func InvalidateQuota(ctx context.Context, scope, targetId string) {
+	_, _ = cache.DelCtx(ctx, rds.Key("quota", "conf", scope, targetId))
+}
+
+// effectiveQuota 获取生效的配额，单用户未单独配置时使用全局默认配额
+func effectiveQuota(ctx context.Context, scope, targetId string) (model.ChatQuota, error) {
+	key := rds.Key("quota", "conf", scope, targetId)
+	if v, ok := cache.GetCtx(ctx, key); ok {
+		if q, ok := v.(model.ChatQuota); ok {
+			return q, nil
+		}
+	}
+
+	q, err := dao.Quota.GetQuota(ctx, scope, targetId)
+	if err != nil {
+		return model.ChatQuota{}, err
+	}
+	var result model.ChatQuota
+	switch {
+	case q != nil:
+		result = *q
+	case scope == model.QuotaScopeUser:
+		def := config.GetConfig().FastGPT.Quota
+		result = model.ChatQuota{
+			RequestsPerMinute: def.RequestsPerMinute,
+			MessagesPerDay:    def.MessagesPerDay,
+			TokensPerMonth:    def.TokensPerMonth,
+		}
+	}
+	_ = cache.SetexCtx(ctx, key, result, quotaConfCacheSeconds)
+	return result, nil
+}
+
+// monthTokens 获取本月 token 用量，优先读取热计数
+func monthTokens(ctx context.Context, scope, id string, now time.Time) (int64, error) {
+	key := monthTokensKey(scope, id, now)
+	if exist, _ := cache.ExistsCtx(ctx, key); exist {
+		return counterValue(ctx, key), nil
+	}
+
+	var (
+		used int64
+		err  error
+	)
+	if scope == model.QuotaScopeUser {
+		used, err = dao.Quota.SumUserTokens(ctx, id, period(now))
+	} else {
+		used, err = dao.Quota.SumAppTokens(ctx, id, period(now))
+	}
+	if err != nil {
+		return 0, err
+	}
+	_ = cache.SetexCtx(ctx, key, used, monthTokensCacheSeconds)
+	return used, nil
+}
+
+func counterValue(ctx context.Context, key string) int64 {
+	v, ok := cache.GetCtx(ctx, key)
+	if !ok {
+		return 0
+	}
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	}
+	return 0
+}
+
+// takeCounter 先累加再比较，超出上限时撤销本次累加，并发请求不会同时通过检查
+func takeCounter(ctx context.Context, key string, limit int64, expireSeconds int) bool {
+	v, err := cache.IncrByCtx(ctx, key, 1)
+	if err != nil {
+		return true
+	}
+	if v == 1 {
+		_, _ = cache.ExpireCtx(ctx, key, expireSeconds)
+	}
+	if v > limit {
+		_, _ = cache.IncrByCtx(ctx, key, -1)
+		return false
+	}
+	return true
+}
+
+func period(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+func rpmKey(scope, id string, t time.Time) string {
+	return rds.Key("quota", "rpm", scope, id, t.Format("200601021504"))
+}
+
+func dailyWindow(t time.Time) string {
+	return t.Format("20060102")
+}
+
+func monthTokensKey(scope, id string, t time.Time) string {
+	return rds.Key("quota", "tokens", scope, id, period(t))
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTakeCounter_Limit(t *testing.T) {
+	ctx := context.Background()
+	key := rpmKey(model.QuotaScopeUser, "take-counter-limit", time.Now())
+
+	for i := 1; i <= 3; i++ {
+		if !takeCounter(ctx, key, 3, 60) {
+			t.Fatalf("request %d rejected under limit", i)
+		}
+	}
+	if takeCounter(ctx, key, 3, 60) {
+		t.Error("request over limit accepted")
+	}
+	// 被拒绝的请求不占用额度
+	if v := counterValue(ctx, key); v != 3 {
+		t.Errorf("counter after reject: got %d, want 3", v)
+	}
+}
+
+func TestTakeCounter_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	key := rpmKey(model.QuotaScopeApp, "take-counter-concurrent", time.Now())
+
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int64
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if takeCounter(ctx, key, 10, 60) {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted.Load() != 10 {
+		t.Errorf("accepted: got %d, want 10", accepted.Load())
+	}
+	if v := counterValue(ctx, key); v != 10 {
+		t.Errorf("counter: got %d, want 10", v)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	limitErr := &QuotaError{Code: QuotaCodeAppDaily, Message: "limit"}
+	dbErr := errors.New("db down")
+
+	tests := []struct {
+		name     string
+		results  []bool
+		failAt   int // 返回 dbErr 的步骤，-1 表示不出错
+		wantErr  error
+		released []int
+	}{
+		{name: "all pass", results: []bool{true, true, true}, failAt: -1, released: nil},
+		{name: "first rejected", results: []bool{false, true}, failAt: -1, wantErr: limitErr, released: nil},
+		{name: "last rejected", results: []bool{true, true, false}, failAt: -1, wantErr: limitErr, released: []int{1, 0}},
+		{name: "error rolls back", results: []bool{true, true, true}, failAt: 1, wantErr: dbErr, released: []int{0}},
+		{name: "no steps", results: nil, failAt: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var released []int
+			steps := make([]quotaStep, len(tt.results))
+			for i, ok := range tt.results {
+				i, ok := i, ok
+				steps[i] = quotaStep{
+					take: func(context.Context) (bool, error) {
+						if i == tt.failAt {
+							return false, dbErr
+						}
+						return ok, nil
+					},
+					release: func(context.Context) { released = append(released, i) },
+					err:     limitErr,
+				}
+			}
+
+			err := reserve(context.Background(), steps)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err: got %v, want %v", err, tt.wantErr)
+			}
+			if len(released) != len(tt.released) {
+				t.Fatalf("released: got %v, want %v", released, tt.released)
+			}
+			for i := range released {
+				if released[i] != tt.released[i] {
+					t.Errorf("released: got %v, want %v", released, tt.released)
+				}
+			}
+		})
+	}
+}
+
+func TestLimits_Steps(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		quota model.ChatQuota
+		want  []int
+	}{
+		{name: "unlimited", quota: model.ChatQuota{}, want: nil},
+		{name: "rpm only", quota: model.ChatQuota{RequestsPerMinute: 5}, want: []int{QuotaCodeUserRPM}},
+		{name: "daily only", quota: model.ChatQuota{MessagesPerDay: 50}, want: []int{QuotaCodeUserDaily}},
+		{name: "tokens only", quota: model.ChatQuota{TokensPerMonth: 1000}, want: nil},
+		{name: "both", quota: model.ChatQuota{RequestsPerMinute: 5, MessagesPerDay: 50}, want: []int{QuotaCodeUserRPM, QuotaCodeUserDaily}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := limits{scope: model.QuotaScopeUser, id: "u1", quota: tt.quota}
+			steps := l.steps(now)
+			if len(steps) != len(tt.want) {
+				t.Fatalf("steps: got %d, want %d", len(steps), len(tt.want))
+			}
+			for i, s := range steps {
+				if s.err.Code != tt.want[i] {
+					t.Errorf("step %d code: got %d, want %d", i, s.err.Code, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLimits_LimitErr(t *testing.T) {
+	tests := []struct {
+		scope string
+		kind  int
+		want  int
+	}{
+		{model.QuotaScopeUser, quotaRPM, QuotaCodeUserRPM},
+		{model.QuotaScopeUser, quotaDaily, QuotaCodeUserDaily},
+		{model.QuotaScopeUser, quotaTokens, QuotaCodeUserTokens},
+		{model.QuotaScopeApp, quotaRPM, QuotaCodeAppRPM},
+		{model.QuotaScopeApp, quotaDaily, QuotaCodeAppDaily},
+		{model.QuotaScopeApp, quotaTokens, QuotaCodeAppTokens},
+	}
+
+	for _, tt := range tests {
+		l := limits{scope: tt.scope, quota: model.ChatQuota{MessagesPerDay: 20}}
+		if got := l.limitErr(tt.kind).Code; got != tt.want {
+			t.Errorf("%s/%d: got %d, want %d", tt.scope, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestQuotaWindows(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	base := time.Date(2026, 3, 31, 23, 59, 30, 0, loc)
+
+	tests := []struct {
+		name       string
+		a, b       time.Time
+		sameMinute bool
+		sameDay    bool
+		sameMonth  bool
+	}{
+		{name: "same second", a: base, b: base, sameMinute: true, sameDay: true, sameMonth: true},
+		{name: "within minute", a: base, b: base.Add(29 * time.Second), sameMinute: true, sameDay: true, sameMonth: true},
+		{name: "next minute crosses day and month", a: base, b: base.Add(30 * time.Second), sameMinute: false, sameDay: false, sameMonth: false},
+		{name: "earlier minute same day", a: base, b: base.Add(-time.Minute), sameMinute: false, sameDay: true, sameMonth: true},
+		{name: "start of day", a: base, b: time.Date(2026, 3, 31, 0, 0, 0, 0, loc), sameMinute: false, sameDay: true, sameMonth: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rpmKey("user", "u1", tt.a) == rpmKey("user", "u1", tt.b); got != tt.sameMinute {
+				t.Errorf("same minute: got %v, want %v", got, tt.sameMinute)
+			}
+			if got := dailyWindow(tt.a) == dailyWindow(tt.b); got != tt.sameDay {
+				t.Errorf("same day: got %v, want %v", got, tt.sameDay)
+			}
+			if got := period(tt.a) == period(tt.b); got != tt.sameMonth {
+				t.Errorf("same month: got %v, want %v", got, tt.sameMonth)
+			}
+		})
+	}
+
+	// 不同用户、不同范围的窗口互不影响
+	if rpmKey("user", "u1", base) == rpmKey("user", "u2", base) {
+		t.Error("rpm key shared between users")
+	}
+	if rpmKey("user", "x", base) == rpmKey("app", "x", base) {
+		t.Error("rpm key shared between scopes")
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"HelpStudent/core/cache"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/store/rds"
+	"HelpStudent/core/syncx"
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const (
+	// ResponseKindJSON 非流式回答
+	ResponseKindJSON = "json"
+	// ResponseKindStream 流式回答，缓存的是 SSE data 块
+	ResponseKindStream = "stream"
+)
+
+// ErrResponseNotCached 回答未完整生成，不写入缓存
+var ErrResponseNotCached = errors.New("response not cached")
+
+// responseFlight 合并相同问题的并发请求，只向 FastGPT 发起一次
+var responseFlight = syncx.NewSingleFlight()
+
+// NormalizeQuestion 规范化问题文本：忽略大小写、多余空白和结尾标点
+func NormalizeQuestion(question string) string {
+	q := strings.Join(strings.Fields(strings.ToLower(question)), " ")
+	return strings.TrimRight(q, " ?？。.!！~～")
+}
+
+// ResponseCacheKey 生成回答缓存的 key，应用未开启缓存或问题为空时返回 false
+// 只按最后一条问题和变量区分，相同问题在不同会话中得到相同回答
+func ResponseCacheKey(app *model.FastgptApp, kind string, detail bool, question, prompt string, variables map[string]interface{}) (string, bool) {
+	if app.CacheTTL <= 0 {
+		return "", false
+	}
+	q := NormalizeQuestion(question)
+	if q == "" {
+		return "", false
+	}
+	// map 序列化时按 key 排序，相同变量得到相同结果
+	raw, err := json.Marshal(struct {
+		Question  string                 `json:"q"`
+		Prompt    string                 `json:"p,omitempty"`
+		Detail    bool                   `json:"d"`
+		Variables map[string]interface{} `json:"v"`
+	}{q, prompt, detail, variables})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(raw)
+	return rds.Key("chatcache", app.ID, kind, hex.EncodeToString(sum[:])), true
+}
+
+// GetCachedResponse 读取缓存的回答
+func GetCachedResponse(ctx context.Context, key string) (interface{}, bool) {
+	return cache.GetCtx(ctx, key)
+}
+
+// SetCachedResponse 按应用配置的时间缓存回答
+func SetCachedResponse(ctx context.Context, app *model.FastgptApp, key string, value interface{}) {
+	if err := cache.SetexCtx(ctx, key, value, app.CacheTTL); err != nil {
+		logx.SystemLogger.CtxError(ctx, "cache chat response failed", err)
+	}
+}
+
+// CollapseResponse 合并相同 key 的并发请求，fresh 为 true 表示本次调用执行了 fn
+// 等待其他请求的结果时 ctx 结束则返回 ctx.Err()，不会被第一个请求拖住
+func CollapseResponse(ctx context.Context, key string, fn func() (any, error)) (val any, fresh bool, err error) {
+	return responseFlight.DoCtx(ctx, key, fn)
+}
+
+// InvalidateResponseCache 清除应用的全部回答缓存，知识库或应用配置更新后调用
+func InvalidateResponseCache(ctx context.Context, appId string) {
+	keys, err := cache.KeysCtx(ctx, rds.Key("chatcache", appId)+rds.KeySeparator+"*")
+	if err != nil {
+		logx.SystemLogger.CtxError(ctx, "list cached responses failed", err)
+		return
+	}
+	if len(keys) > 0 {
+		_, _ = cache.DelCtx(ctx, keys...)
+	}
+}
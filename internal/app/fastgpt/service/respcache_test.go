@@ -0,0 +1,149 @@
+package service
+
+import (
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNormalizeQuestion(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "empty", in: "", want: ""},
+		{name: "blank", in: "  \t\n ", want: ""},
+		{name: "lower case", in: "What Is GDP", want: "what is gdp"},
+		{name: "collapse spaces", in: "  什么是  极限\n定义 ", want: "什么是 极限 定义"},
+		{name: "ascii question mark", in: "what is gdp?", want: "what is gdp"},
+		{name: "full width punctuation", in: "什么是极限？！", want: "什么是极限"},
+		{name: "trailing punctuation and spaces", in: "什么是极限 ？ 。", want: "什么是极限"},
+		{name: "tilde", in: "谢谢~～", want: "谢谢"},
+		{name: "inner punctuation kept", in: "a?b", want: "a?b"},
+		{name: "only punctuation", in: "？？", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeQuestion(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	app := &model.FastgptApp{CacheTTL: 60}
+	app.ID = "app1"
+	other := &model.FastgptApp{CacheTTL: 60}
+	other.ID = "app2"
+	vars := map[string]interface{}{"a": 1, "b": "x"}
+
+	base, ok := ResponseCacheKey(app, ResponseKindJSON, false, "什么是极限？", "", vars)
+	if !ok {
+		t.Fatal("cacheable question rejected")
+	}
+	if !strings.Contains(base, "chatcache:app1:json:") {
+		t.Errorf("key %q missing app and kind", base)
+	}
+
+	tests := []struct {
+		name      string
+		app       *model.FastgptApp
+		kind      string
+		detail    bool
+		question  string
+		prompt    string
+		variables map[string]interface{}
+		same      bool
+	}{
+		{name: "normalized question", app: app, kind: ResponseKindJSON, question: "  什么是极限 ", variables: vars, same: true},
+		{name: "variables order", app: app, kind: ResponseKindJSON, question: "什么是极限", variables: map[string]interface{}{"b": "x", "a": 1}, same: true},
+		{name: "different question", app: app, kind: ResponseKindJSON, question: "什么是导数", variables: vars, same: false},
+		{name: "different app", app: other, kind: ResponseKindJSON, question: "什么是极限", variables: vars, same: false},
+		{name: "different kind", app: app, kind: ResponseKindStream, question: "什么是极限", variables: vars, same: false},
+		{name: "detail", app: app, kind: ResponseKindJSON, detail: true, question: "什么是极限", variables: vars, same: false},
+		{name: "prompt", app: app, kind: ResponseKindJSON, question: "什么是极限", prompt: "考试期间", variables: vars, same: false},
+		{name: "different variables", app: app, kind: ResponseKindJSON, question: "什么是极限", variables: map[string]interface{}{"a": 2, "b": "x"}, same: false},
+		{name: "no variables", app: app, kind: ResponseKindJSON, question: "什么是极限", variables: nil, same: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := ResponseCacheKey(tt.app, tt.kind, tt.detail, tt.question, tt.prompt, tt.variables)
+			if !ok {
+				t.Fatal("cacheable question rejected")
+			}
+			if got := key == base; got != tt.same {
+				t.Errorf("same key: got %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	// 未开启缓存或问题为空时不缓存
+	if _, ok := ResponseCacheKey(&model.FastgptApp{}, ResponseKindJSON, false, "什么是极限", "", nil); ok {
+		t.Error("app without cache ttl is cacheable")
+	}
+	if _, ok := ResponseCacheKey(app, ResponseKindJSON, false, " ？ ", "", nil); ok {
+		t.Error("empty question is cacheable")
+	}
+}
+
+func TestCollapseResponse(t *testing.T) {
+	key := "collapse-test"
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls atomic.Int32
+
+	type result struct {
+		val   any
+		fresh bool
+		err   error
+	}
+	leader := make(chan result, 1)
+	go func() {
+		v, fresh, err := CollapseResponse(context.Background(), key, func() (any, error) {
+			calls.Add(1)
+			close(started)
+			<-release
+			return "answer", nil
+		})
+		leader <- result{v, fresh, err}
+	}()
+	<-started
+
+	// 等待中的请求断开后立即返回
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, fresh, err := CollapseResponse(ctx, key, func() (any, error) { return nil, nil }); fresh || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("canceled waiter: got fresh=%v err=%v", fresh, err)
+	}
+
+	joined := make(chan result, 1)
+	go func() {
+		v, fresh, err := CollapseResponse(context.Background(), key, func() (any, error) { return nil, nil })
+		joined <- result{v, fresh, err}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if r := <-leader; !r.fresh || r.val != "answer" || r.err != nil {
+		t.Errorf("leader: got %+v", r)
+	}
+	if r := <-joined; r.fresh || r.val != "answer" || r.err != nil {
+		t.Errorf("waiter: got %+v", r)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls: got %d, want 1", calls.Load())
+	}
+
+	// 结束后再次调用会重新执行
+	if _, fresh, _ := CollapseResponse(context.Background(), key, func() (any, error) { return nil, nil }); !fresh {
+		t.Error("finished call reused")
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// ExtractAnswerDelta 从流式响应的一个 data 块中提取回答增量
+func ExtractAnswerDelta(data string) string {
+	if data == "" || data == "[DONE]" {
+		return ""
+	}
+	return gjson.Get(data, "choices.0.delta.content").String()
+}
+
+// IsAnswerFinished 判断 data 块是否为回答的最后一块，即带有 finish_reason
+func IsAnswerFinished(data string) bool {
+	if data == "" || data == "[DONE]" {
+		return false
+	}
+	return gjson.Get(data, "choices.0.finish_reason").String() != ""
+}
+
+// IsPlainStreamEvent 判断流式事件在非 detail 模式下是否也会发送
+// 非 detail 模式下 FastGPT 只发送不带 event 名的回答和错误，detail 模式下对应 answer、fastAnswer 和 error
+func IsPlainStreamEvent(event string) bool {
+	switch event {
+	case "", "answer", "fastAnswer", "error":
+		return true
+	}
+	return false
+}
+
+// MessageText 将消息内容转换为文本，多模态内容只保留文本部分
+func MessageText(content interface{}) string {
+	switch v := content.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				if text, ok := m["text"].(string); ok {
+					parts = append(parts, text)
+				}
+			}
+		}
+		if len(parts) > 0 {
+			return strings.Join(parts, "\n")
+		}
+	}
+	b, _ := json.Marshal(content)
+	return string(b)
+}
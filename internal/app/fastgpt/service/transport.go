@@ -0,0 +1,158 @@
+package service
+
+import (
+	"HelpStudent/config"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRequestTimeout   = 30 * time.Second
+	defaultConnectTimeout   = 5 * time.Second
+	defaultFirstByteTimeout = 60 * time.Second
+	defaultRetryBackoff     = 200 * time.Millisecond
+)
+
+// DefaultBackend 未指定后端的应用使用 FastGPT.BaseURL
+const DefaultBackend = ""
+
+// ErrBackendNotFound 应用配置的后端不存在
+var ErrBackendNotFound = errors.New("FastGPT 后端不存在")
+
+// backend 一个 FastGPT 实例的连接配置和连接池
+type backend struct {
+	name             string
+	baseURL          string
+	headers          map[string]string
+	firstByteTimeout time.Duration
+	transport        *http.Transport
+	client           *http.Client // 非流式请求，带整体超时
+	stream           *http.Client // 流式请求，不设置整体超时
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   map[string]*backend
+)
+
+func newBackend(cfg config.FastGPT, b config.Backend) *backend {
+	dialer := &net.Dialer{
+		Timeout:   durationOr(b.ConnectTimeout, durationOr(cfg.ConnectTimeout, defaultConnectTimeout)),
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		DisableCompression:  true, // 禁用压缩，确保流式数据实时到达
+	}
+	return &backend{
+		name:             b.Name,
+		baseURL:          b.BaseURL,
+		headers:          b.Headers,
+		firstByteTimeout: durationOr(b.FirstByteTimeout, durationOr(cfg.FirstByteTimeout, defaultFirstByteTimeout)),
+		transport:        transport,
+		client: &http.Client{
+			Timeout:   durationOr(b.RequestTimeout, durationOr(cfg.RequestTimeout, defaultRequestTimeout)),
+			Transport: transport,
+		},
+		stream: &http.Client{Transport: transport},
+	}
+}
+
+// ReloadBackends 根据配置重建所有后端，旧连接池的空闲连接随即关闭，进行中的请求不受影响
+func ReloadBackends(cfg config.FastGPT) {
+	next := map[string]*backend{
+		DefaultBackend: newBackend(cfg, config.Backend{BaseURL: cfg.BaseURL}),
+	}
+	for _, b := range cfg.Backends {
+		if b.Name == DefaultBackend {
+			continue
+		}
+		next[b.Name] = newBackend(cfg, b)
+	}
+
+	backendsMu.Lock()
+	prev := backends
+	backends = next
+	backendsMu.Unlock()
+
+	for _, b := range prev {
+		b.transport.CloseIdleConnections()
+	}
+}
+
+// getBackend 获取指定名称的后端，首次使用时从配置加载
+func getBackend(name string) (*backend, error) {
+	backendsMu.RLock()
+	loaded := backends != nil
+	b, ok := backends[name]
+	backendsMu.RUnlock()
+	if !loaded {
+		ReloadBackends(config.GetConfig().FastGPT)
+		return getBackend(name)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBackendNotFound, name)
+	}
+	return b, nil
+}
+
+// BackendNames 返回所有后端名称，默认后端排在第一位
+func BackendNames() []string {
+	if _, err := getBackend(DefaultBackend); err != nil {
+		return nil
+	}
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := []string{DefaultBackend}
+	for name := range backends {
+		if name != DefaultBackend {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// HasBackend 检查后端是否存在
+func HasBackend(name string) bool {
+	_, err := getBackend(name)
+	return err == nil
+}
+
+func durationOr(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// upstreamStatusError FastGPT 返回 5xx，计为一次熔断失败
+type upstreamStatusError struct {
+	statusCode int
+}
+
+func (e *upstreamStatusError) Error() string {
+	return fmt.Sprintf("fastgpt upstream status %d", e.statusCode)
+}
+
+// cancelOnClose 关闭响应体时释放请求 context
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/core/auth"
+	"HelpStudent/core/fileServer"
+	"HelpStudent/internal/app/fastgpt/dao"
+	"HelpStudent/internal/app/fastgpt/model"
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// UploadPath 附件下载路由前缀，FastGPT 通过该地址下载附件
+const UploadPath = "/api/chat/files/"
+
+// maxAttachments 单次请求最多引用的附件数
+const maxAttachments = 10
+
+var (
+	ErrUploadDisabled    = errors.New("该应用未开启附件上传")
+	ErrUploadTooLarge    = errors.New("附件过大")
+	ErrUploadType        = errors.New("不支持的附件类型")
+	ErrUploadSignature   = errors.New("附件链接无效或已过期")
+	ErrAttachmentInvalid = errors.New("附件无效，请重新上传")
+)
+
+// UploadTypes 可以开启上传的附件类型
+var UploadTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf"}
+
+// UploadSettings 返回附件配置，未配置的项使用默认值
+func UploadSettings() config.Upload {
+	cfg := config.GetConfig().FastGPT.Upload
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 10
+	}
+	if cfg.URLTTL <= 0 {
+		cfg.URLTTL = 10 * time.Minute
+	}
+	if len(cfg.AllowTypes) == 0 {
+		cfg.AllowTypes = UploadTypes
+	}
+	return cfg
+}
+
+// UploadTypeSupported 检查附件类型是否可以开启
+func UploadTypeSupported(contentType string) bool {
+	for _, t := range UploadTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// AppUploadLimits 返回应用的附件大小上限（MB）和允许的类型，大小为 0 表示禁止上传
+func AppUploadLimits(app *model.FastgptApp) (int64, []string) {
+	cfg := UploadSettings()
+	maxSize := cfg.MaxSize
+	switch {
+	case app.UploadMaxSize < 0:
+		maxSize = 0
+	case app.UploadMaxSize > 0:
+		maxSize = int64(app.UploadMaxSize)
+	}
+	types := cfg.AllowTypes
+	if app.UploadTypes != "" {
+		types = strings.Split(app.UploadTypes, ",")
+	}
+	return maxSize, types
+}
+
+// StoreUpload 校验并保存附件，类型以内容识别结果为准
+func StoreUpload(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, fileName string, data []byte) (*model.ChatUpload, error) {
+	maxSize, types := AppUploadLimits(app)
+	if maxSize <= 0 {
+		return nil, ErrUploadDisabled
+	}
+	if int64(len(data)) > maxSize<<20 {
+		return nil, ErrUploadTooLarge
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	allowed := false
+	for _, t := range types {
+		if t == contentType {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, ErrUploadType
+	}
+
+	key := path.Join("fastgpt", "chat", app.ID, strings.ToLower(ulid.Make().String())+path.Ext(fileName))
+	if _, err := fileServer.Client(UploadSettings().FileServer).UploadFile(data, key); err != nil {
+		return nil, err
+	}
+	up := &model.ChatUpload{
+		AppId:       app.ID,
+		UserId:      authInfo.Uid,
+		StaffId:     authInfo.StaffId,
+		FileName:    path.Base(fileName),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  key,
+	}
+	if err := dao.Upload.CreateUpload(ctx, up); err != nil {
+		return nil, err
+	}
+	return up, nil
+}
+
+// SignUploadURL 生成附件的下载链接，FastGPT 需要能访问 BaseURL
+func SignUploadURL(id string, now time.Time) (string, time.Time) {
+	expAt := now.Add(UploadSettings().URLTTL)
+	exp := strconv.FormatInt(expAt.Unix(), 10)
+	q := url.Values{}
+	q.Set("exp", exp)
+	q.Set("sig", signFields("upload", id, exp))
+	return strings.TrimRight(config.GetConfig().BaseURL, "/") + UploadPath + id + "?" + q.Encode(), expAt
+}
+
+// VerifyUploadSignature 校验附件链接的签名和过期时间
+func VerifyUploadSignature(id, exp, sig string, now time.Time) error {
+	expAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || sig == "" || now.Unix() > expAt {
+		return ErrUploadSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(signFields("upload", id, exp))) {
+		return ErrUploadSignature
+	}
+	return nil
+}
+
+// ReadUpload 读取附件内容
+func ReadUpload(ctx context.Context, id string) (*model.ChatUpload, []byte, error) {
+	up, err := dao.Upload.GetUpload(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := fileServer.Client(UploadSettings().FileServer).ReadAll(up.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return up, data, nil
+}
+
+// ResolveAttachments 校验消息中的 image_url、file_url 是否为当前用户在该应用上传的附件，并替换为新签名的链接
+// 历史消息中的链接可能已过期，只要附件属于当前用户就重新签名
+func ResolveAttachments(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, content interface{}, count *int) (interface{}, error) {
+	parts, ok := content.([]interface{})
+	if !ok {
+		return content, nil
+	}
+	now := time.Now()
+	resolved := make([]interface{}, 0, len(parts))
+	for _, item := range parts {
+		part, ok := item.(map[string]interface{})
+		if !ok {
+			resolved = append(resolved, item)
+			continue
+		}
+		switch part["type"] {
+		case "image_url":
+			image, _ := part["image_url"].(map[string]interface{})
+			if image == nil {
+				return nil, ErrAttachmentInvalid
+			}
+			raw, _ := image["url"].(string)
+			up, err := ownUpload(ctx, authInfo, app, raw)
+			if err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(up.ContentType, "image/") {
+				return nil, fmt.Errorf("%w: %s 不是图片", ErrAttachmentInvalid, up.FileName)
+			}
+			link, _ := SignUploadURL(up.ID, now)
+			image = copyPart(image)
+			image["url"] = link
+			part = copyPart(part)
+			part["image_url"] = image
+		case "file_url":
+			raw, _ := part["url"].(string)
+			up, err := ownUpload(ctx, authInfo, app, raw)
+			if err != nil {
+				return nil, err
+			}
+			link, _ := SignUploadURL(up.ID, now)
+			part = copyPart(part)
+			part["url"] = link
+			if name, _ := part["name"].(string); name == "" {
+				part["name"] = up.FileName
+			}
+		default:
+			resolved = append(resolved, part)
+			continue
+		}
+		*count++
+		if *count > maxAttachments {
+			return nil, fmt.Errorf("%w: 一次最多引用 %d 个附件", ErrAttachmentInvalid, maxAttachments)
+		}
+		resolved = append(resolved, part)
+	}
+	return resolved, nil
+}
+
+// ownUpload 从链接中解析附件，只接受本服务的附件地址，且附件必须属于当前用户和应用
+func ownUpload(ctx context.Context, authInfo auth.Info, app *model.FastgptApp, raw string) (*model.ChatUpload, error) {
+	u, err := url.Parse(raw)
+	if err != nil || !strings.HasPrefix(u.Path, UploadPath) {
+		return nil, ErrAttachmentInvalid
+	}
+	if u.Host != "" {
+		base, err := url.Parse(config.GetConfig().BaseURL)
+		if err != nil || !strings.EqualFold(base.Host, u.Host) {
+			return nil, ErrAttachmentInvalid
+		}
+	}
+	id := strings.TrimPrefix(u.Path, UploadPath)
+	up, err := dao.Upload.GetUpload(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentInvalid
+		}
+		return nil, err
+	}
+	if up.UserId != authInfo.Uid || up.AppId != app.ID {
+		return nil, ErrAttachmentInvalid
+	}
+	return up, nil
+}
+
+func copyPart(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"github.com/tidwall/gjson"
+)
+
+// UsageCollector 从流式响应中收集 token 用量
+type UsageCollector struct {
+	usage      int64
+	nodeTokens int64
+}
+
+// Feed 处理一个 data 块
+func (u *UsageCollector) Feed(data string) {
+	if data == "" || data == "[DONE]" || !gjson.Valid(data) {
+		return
+	}
+	result := gjson.Parse(data)
+	if result.IsArray() {
+		// detail 模式下 flowResponses 事件为节点数组
+		u.nodeTokens += sumNodeTokens(result)
+		return
+	}
+	if usage := result.Get("usage.total_tokens"); usage.Exists() {
+		u.usage = usage.Int()
+	}
+}
+
+// Total 返回本次流式响应的 token 用量
+func (u *UsageCollector) Total() int64 {
+	if u.usage > 0 {
+		return u.usage
+	}
+	return u.nodeTokens
+}
+
+// sumNodeTokens 累加节点的 token 用量，插件和工具调用的子节点单独计费，同样累加
+func sumNodeTokens(nodes gjson.Result) int64 {
+	var total int64
+	for _, node := range nodes.Array() {
+		total += nodeTokens(node)
+		for _, key := range []string{"pluginDetail", "toolDetail", "childrenResponses"} {
+			if children := node.Get(key); children.IsArray() {
+				total += sumNodeTokens(children)
+			}
+		}
+	}
+	return total
+}
+
+// nodeTokens 单个节点的 token 用量，不同版本的 FastGPT 字段不同：
+// totalTokens 或 tokens 为总量，新版本只返回 inputTokens 和 outputTokens
+func nodeTokens(node gjson.Result) int64 {
+	for _, key := range []string{"totalTokens", "tokens"} {
+		if v := node.Get(key); v.Exists() {
+			return v.Int()
+		}
+	}
+	return node.Get("inputTokens").Int() + node.Get("outputTokens").Int()
+}
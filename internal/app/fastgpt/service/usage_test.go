@@ -0,0 +1,39 @@
+package service
+
+import "testing"
+
+func TestUsageCollector(t *testing.T) {
+	tests := []struct {
+		name string
+		data []string
+		want int64
+	}{
+		{name: "empty", data: nil, want: 0},
+		{name: "ignores done and invalid", data: []string{"[DONE]", "", "not json", `{"choices":[]}`}, want: 0},
+		{name: "usage total", data: []string{`{"choices":[]}`, `{"usage":{"total_tokens":42}}`}, want: 42},
+		{name: "last usage wins", data: []string{`{"usage":{"total_tokens":10}}`, `{"usage":{"total_tokens":30}}`}, want: 30},
+		{name: "node tokens", data: []string{`[{"moduleName":"AI 对话","tokens":120},{"moduleName":"知识库搜索"}]`}, want: 120},
+		{name: "total tokens preferred", data: []string{`[{"totalTokens":50,"tokens":20,"inputTokens":1,"outputTokens":1}]`}, want: 50},
+		{name: "input and output tokens", data: []string{`[{"inputTokens":70,"outputTokens":30}]`}, want: 100},
+		{
+			name: "nested tool and plugin nodes",
+			data: []string{`[{"tokens":10,"toolDetail":[{"tokens":5},{"inputTokens":2,"outputTokens":3}]},` +
+				`{"pluginDetail":[{"tokens":7,"childrenResponses":[{"totalTokens":4}]}]}]`},
+			want: 31,
+		},
+		{name: "several flowResponses add up", data: []string{`[{"tokens":10}]`, `[{"tokens":15}]`}, want: 25},
+		{name: "usage preferred over nodes", data: []string{`[{"tokens":10}]`, `{"usage":{"total_tokens":12}}`}, want: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var u UsageCollector
+			for _, data := range tt.data {
+				u.Feed(data)
+			}
+			if got := u.Total(); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+)
+
+var (
+	Job = &job{}
+)
+
+func InitPG(db *gorm.DB) error {
+	err := Job.Init(db)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
@@ -0,0 +1,226 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/jobs/model"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type job struct {
+	*gorm.DB
+}
+
+func (u *job) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.Job{})
+}
+
+// CreateJob 创建任务
+func (u *job) CreateJob(ctx context.Context, j *model.Job) error {
+	return u.WithContext(ctx).Create(j).Error
+}
+
+// GetJob 获取任务
+func (u *job) GetJob(ctx context.Context, id string) (*model.Job, error) {
+	var j model.Job
+	err := u.WithContext(ctx).Where("id = ?", id).First(&j).Error
+	return &j, err
+}
+
+// Claim 领取一个到期的待执行任务，多个进程并发领取时通过 SKIP LOCKED 互不阻塞
+// 没有可执行的任务时返回 nil
+func (u *job) Claim(ctx context.Context, workerId string, types []string) (*model.Job, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+	var claimed *model.Job
+	err := u.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var j model.Job
+		err := claimable(tx, types, time.Now()).First(&j).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if err := tx.Model(&model.Job{}).Where("id = ?", j.ID).Updates(claimUpdates(&j, workerId, time.Now())).Error; err != nil {
+			return err
+		}
+		claimed = &j
+		return nil
+	})
+	return claimed, err
+}
+
+// claimable 到期的待执行任务，先到期的先执行，其他进程正在领取的行直接跳过
+func claimable(db *gorm.DB, types []string, now time.Time) *gorm.DB {
+	return db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND run_after <= ? AND type IN ?", model.StatusPending, now, types).
+		Order("run_after ASC")
+}
+
+// claimUpdates 将任务标记为由 workerId 执行，返回需要更新的字段，每次领取计入一次尝试
+func claimUpdates(j *model.Job, workerId string, now time.Time) map[string]interface{} {
+	j.Status = model.StatusRunning
+	j.Attempts++
+	j.WorkerId = workerId
+	j.HeartbeatAt = &now
+	j.StartedAt = &now
+	return map[string]interface{}{
+		"status":       j.Status,
+		"attempts":     j.Attempts,
+		"worker_id":    j.WorkerId,
+		"heartbeat_at": j.HeartbeatAt,
+		"started_at":   j.StartedAt,
+	}
+}
+
+// Heartbeat 更新心跳并更新进度，返回任务是否已请求取消
+// 任务已被其他进程接管时返回 gorm.ErrRecordNotFound
+func (u *job) Heartbeat(ctx context.Context, id, workerId string, progress map[string]interface{}) (bool, error) {
+	updates := map[string]interface{}{"heartbeat_at": time.Now()}
+	for k, v := range progress {
+		updates[k] = v
+	}
+	result := u.WithContext(ctx).Model(&model.Job{}).
+		Where("id = ? AND worker_id = ? AND status = ?", id, workerId, model.StatusRunning).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, gorm.ErrRecordNotFound
+	}
+
+	var cancelRequested bool
+	err := u.WithContext(ctx).Model(&model.Job{}).Where("id = ?", id).Pluck("cancel_requested", &cancelRequested).Error
+	return cancelRequested, err
+}
+
+// Checkpoint 锁住 workerId 正在执行的任务行后执行 fn，fn 成功时在同一事务中更新心跳和进度
+// fn 执行期间回收和心跳都会等待行锁，任务不会被其他进程接管后重复执行 fn；
+// 任务已被接管时返回 gorm.ErrRecordNotFound，已请求取消时不执行 fn 并返回 true
+func (u *job) Checkpoint(ctx context.Context, id, workerId string, progress map[string]interface{}, fn func() error) (canceled bool, err error) {
+	// fn 成功后即使 ctx 被取消也要提交进度
+	err = u.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		canceled, err = checkpoint(tx, id, workerId, progress, fn)
+		return err
+	})
+	return canceled, err
+}
+
+// checkpoint Checkpoint 在事务内的步骤：锁行、检查取消、执行 fn、更新心跳和进度
+func checkpoint(tx *gorm.DB, id, workerId string, progress map[string]interface{}, fn func() error) (bool, error) {
+	var j model.Job
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND worker_id = ? AND status = ?", id, workerId, model.StatusRunning).
+		First(&j).Error; err != nil {
+		return false, err
+	}
+	if j.CancelRequested {
+		return true, nil
+	}
+	if err := fn(); err != nil {
+		return false, err
+	}
+	updates := map[string]interface{}{"heartbeat_at": time.Now()}
+	for k, v := range progress {
+		updates[k] = v
+	}
+	return false, tx.Model(&model.Job{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Finish 结束由 workerId 执行的任务，status 为 pending 时表示稍后重试
+func (u *job) Finish(ctx context.Context, id, workerId, status string, updates map[string]interface{}) error {
+	return u.WithContext(ctx).Model(&model.Job{}).
+		Where("id = ? AND worker_id = ? AND status = ?", id, workerId, model.StatusRunning).
+		Updates(finishUpdates(status, updates, time.Now())).Error
+}
+
+// finishUpdates 结束任务时需要更新的字段，放回队列时释放 worker，其他状态记录结束时间
+func finishUpdates(status string, updates map[string]interface{}, now time.Time) map[string]interface{} {
+	if updates == nil {
+		updates = make(map[string]interface{})
+	}
+	updates["status"] = status
+	if status == model.StatusPending {
+		updates["worker_id"] = ""
+	} else {
+		updates["finished_at"] = now
+	}
+	return updates
+}
+
+// RequestCancel 取消任务：待执行的直接取消，运行中的标记后由执行进程中止
+func (u *job) RequestCancel(ctx context.Context, id string) error {
+	return u.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Job{}).
+			Where("id = ? AND status = ?", id, model.StatusPending).
+			Updates(map[string]interface{}{"status": model.StatusCanceled, "finished_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Job{}).
+			Where("id = ? AND status = ?", id, model.StatusRunning).
+			Update("cancel_requested", true).Error
+	})
+}
+
+// ReclaimStale 将心跳超时的运行中任务放回队列，已请求取消的直接取消
+// 进程崩溃或重启后，未完成的任务由此恢复执行
+func (u *job) ReclaimStale(ctx context.Context, before time.Time) (int64, error) {
+	db := u.WithContext(ctx)
+	now := time.Now()
+	if err := db.Model(&model.Job{}).
+		Where("status = ? AND heartbeat_at < ? AND cancel_requested = ?", model.StatusRunning, before, true).
+		Updates(reclaimUpdates(true, now)).Error; err != nil {
+		return 0, err
+	}
+	result := db.Model(&model.Job{}).
+		Where("status = ? AND heartbeat_at < ?", model.StatusRunning, before).
+		Updates(reclaimUpdates(false, now))
+	return result.RowsAffected, result.Error
+}
+
+// reclaimUpdates 回收任务时需要更新的字段：已请求取消的直接结束，否则释放 worker 并立即重新执行
+func reclaimUpdates(cancelRequested bool, now time.Time) map[string]interface{} {
+	if cancelRequested {
+		return map[string]interface{}{"status": model.StatusCanceled, "finished_at": now}
+	}
+	return map[string]interface{}{"status": model.StatusPending, "worker_id": "", "run_after": now}
+}
+
+// JobFilter 任务筛选条件，空值表示不筛选
+type JobFilter struct {
+	Type      string
+	Status    string
+	CreatedBy string
+}
+
+// ListJobs 分页获取任务
+func (u *job) ListJobs(ctx context.Context, filter JobFilter, offset, limit int) ([]model.Job, int64, error) {
+	var jobs []model.Job
+	var total int64
+
+	query := u.WithContext(ctx).Model(&model.Job{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CreatedBy != "" {
+		query = query.Where("created_by = ?", filter.CreatedBy)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 列表不返回参数和结果，避免大字段拖慢查询
+	err := query.Omit("payload", "result").Order("created_at DESC").Offset(offset).Limit(limit).Find(&jobs).Error
+	return jobs, total, err
+}
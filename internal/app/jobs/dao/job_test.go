@@ -0,0 +1,202 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/jobs/model"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder 记录 DryRun 生成的 SQL
+type sqlRecorder struct {
+	logger.Interface
+	sqls []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.sqls = append(r.sqls, sql)
+}
+
+// dryRunJob 不连接数据库，只生成 PostgreSQL 方言的 SQL
+func dryRunJob(t *testing.T) (*job, *sqlRecorder) {
+	t.Helper()
+	rec := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 rec,
+	})
+	if err != nil {
+		t.Fatalf("open dry run db: %v", err)
+	}
+	return &job{DB: db}, rec
+}
+
+func TestClaimable(t *testing.T) {
+	u, _ := dryRunJob(t)
+	var j model.Job
+	stmt := claimable(u.DB, []string{"export"}, time.Now()).First(&j).Statement
+	sql := stmt.SQL.String()
+
+	for _, want := range []string{"status = $1 AND run_after <= $2 AND type IN ($3)", "ORDER BY run_after ASC", "FOR UPDATE SKIP LOCKED"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("claim sql %q should contain %q", sql, want)
+		}
+	}
+	if stmt.Vars[0] != model.StatusPending {
+		t.Errorf("claim status: got %v, want %s", stmt.Vars[0], model.StatusPending)
+	}
+}
+
+func TestClaimUpdates(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	j := &model.Job{Status: model.StatusPending, Attempts: 1, MaxAttempts: 3}
+
+	updates := claimUpdates(j, "w1", now)
+	if j.Status != model.StatusRunning || j.Attempts != 2 || j.WorkerId != "w1" {
+		t.Errorf("claimed job: got status=%s attempts=%d worker=%s", j.Status, j.Attempts, j.WorkerId)
+	}
+	if !j.HeartbeatAt.Equal(now) || !j.StartedAt.Equal(now) {
+		t.Errorf("claimed times: got heartbeat=%v started=%v", j.HeartbeatAt, j.StartedAt)
+	}
+	if updates["status"] != model.StatusRunning || updates["attempts"] != 2 || updates["worker_id"] != "w1" {
+		t.Errorf("claim updates: got %v", updates)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name       string
+		fnErr      error
+		wantUpdate bool // 是否在 fn 之后更新进度
+	}{
+		{name: "fn succeeded", wantUpdate: true},
+		{name: "fn failed", fnErr: boom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, rec := dryRunJob(t)
+			var sqlsBeforeFn int
+			canceled, err := checkpoint(u.DB, "j1", "w1", map[string]interface{}{"done": 5}, func() error {
+				sqlsBeforeFn = len(rec.sqls)
+				return tt.fnErr
+			})
+			if canceled || !errors.Is(err, tt.fnErr) {
+				t.Fatalf("got canceled=%v err=%v", canceled, err)
+			}
+
+			// fn 执行前已锁住本 worker 正在执行的任务行
+			if sqlsBeforeFn != 1 {
+				t.Fatalf("sql before fn: got %v", rec.sqls[:sqlsBeforeFn])
+			}
+			for _, want := range []string{"id = 'j1' AND worker_id = 'w1' AND status = 'running'", "FOR UPDATE"} {
+				if !strings.Contains(rec.sqls[0], want) {
+					t.Errorf("lock sql %q should contain %q", rec.sqls[0], want)
+				}
+			}
+			if got := len(rec.sqls) == 2; got != tt.wantUpdate {
+				t.Fatalf("progress update: got %v", rec.sqls)
+			}
+			if tt.wantUpdate && !strings.Contains(rec.sqls[1], `"done"=5`) {
+				t.Errorf("progress sql %q should set done", rec.sqls[1])
+			}
+		})
+	}
+}
+
+func TestFinishUpdates(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		status       string
+		updates      map[string]interface{}
+		wantWorker   bool // 是否释放 worker
+		wantFinished bool // 是否记录结束时间
+	}{
+		{name: "succeeded", status: model.StatusSucceeded, updates: map[string]interface{}{"error": ""}, wantFinished: true},
+		{name: "failed", status: model.StatusFailed, updates: map[string]interface{}{"error": "boom"}, wantFinished: true},
+		{name: "canceled without updates", status: model.StatusCanceled, wantFinished: true},
+		{name: "retry", status: model.StatusPending, updates: map[string]interface{}{"error": "boom", "run_after": now}, wantWorker: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := finishUpdates(tt.status, tt.updates, now)
+			if got["status"] != tt.status {
+				t.Errorf("status: got %v, want %s", got["status"], tt.status)
+			}
+			if worker, ok := got["worker_id"]; ok != tt.wantWorker || (ok && worker != "") {
+				t.Errorf("worker_id: got %v", got)
+			}
+			if _, ok := got["finished_at"]; ok != tt.wantFinished {
+				t.Errorf("finished_at: got %v", got)
+			}
+		})
+	}
+}
+
+func TestReclaimStale(t *testing.T) {
+	u, rec := dryRunJob(t)
+	if _, err := u.ReclaimStale(context.Background(), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if len(rec.sqls) != 2 {
+		t.Fatalf("reclaim sql: got %v, want 2 statements", rec.sqls)
+	}
+
+	// 已请求取消的先结束，其余的放回队列
+	canceled, requeued := rec.sqls[0], rec.sqls[1]
+	for _, want := range []string{`"status"='canceled'`, `status = 'running' AND heartbeat_at <`, "cancel_requested = true"} {
+		if !strings.Contains(canceled, want) {
+			t.Errorf("cancel sql %q should contain %q", canceled, want)
+		}
+	}
+	for _, want := range []string{`"status"='pending'`, `"worker_id"=''`, `status = 'running' AND heartbeat_at <`} {
+		if !strings.Contains(requeued, want) {
+			t.Errorf("requeue sql %q should contain %q", requeued, want)
+		}
+	}
+}
+
+func TestReclaimUpdates(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name            string
+		cancelRequested bool
+		want            map[string]interface{}
+	}{
+		{
+			name:            "cancel requested",
+			cancelRequested: true,
+			want:            map[string]interface{}{"status": model.StatusCanceled, "finished_at": now},
+		},
+		{
+			name: "interrupted",
+			want: map[string]interface{}{"status": model.StatusPending, "worker_id": "", "run_after": now},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reclaimUpdates(tt.cancelRequested, now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s: got %v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
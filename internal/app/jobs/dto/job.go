@@ -0,0 +1,42 @@
+package dto
+
+import "encoding/json"
+
+// GetJobListRequest 获取任务列表请求，非管理员只能看到自己创建的任务
+type GetJobListRequest struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+// CancelJobRequest 取消任务请求
+type CancelJobRequest struct {
+	ID string `json:"id" binding:"Required"`
+}
+
+// JobItem 任务，Progress 为 0-100 的百分比
+type JobItem struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	Done        int             `json:"done"`
+	Total       int             `json:"total"`
+	Progress    float64         `json:"progress"`
+	Message     string          `json:"message"`
+	Error       string          `json:"error"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	CreatedBy   string          `json:"createdBy"`
+	CreatedAt   string          `json:"createdAt"`
+	StartedAt   string          `json:"startedAt"`
+	FinishedAt  string          `json:"finishedAt"`
+}
+
+// JobListResponse 任务列表响应
+type JobListResponse struct {
+	Jobs  []JobItem `json:"jobs"`
+	Total int64     `json:"total"`
+}
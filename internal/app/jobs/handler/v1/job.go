@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	"HelpStudent/internal/app/jobs/dao"
+	"HelpStudent/internal/app/jobs/dto"
+	"HelpStudent/internal/app/jobs/model"
+	"HelpStudent/internal/app/jobs/service"
+	dao2 "HelpStudent/internal/app/managers/dao"
+	"encoding/json"
+	"errors"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+	"gorm.io/gorm"
+)
+
+// HandleGetJob 查看任务详情，创建者或管理员可见
+// 路由: GET /jobs/detail?id=xxx
+func HandleGetJob(c flamego.Context, r flamego.Render, authInfo auth.Info) {
+	id := c.Query("id")
+	if id == "" {
+		response.HTTPFail(r, 400001, "缺少必要参数 id")
+		return
+	}
+	job, ok := loadJob(c, r, authInfo, id)
+	if !ok {
+		return
+	}
+	response.HTTPSuccess(r, jobItem(*job, true))
+}
+
+// HandleGetJobList 分页查看任务，非管理员只返回自己创建的任务
+func HandleGetJobList(c flamego.Context, r flamego.Render, req dto.GetJobListRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	filter := dao.JobFilter{Type: req.Type, Status: req.Status}
+	if !dao2.Managers.IsManager(authInfo.StaffId) {
+		filter.CreatedBy = authInfo.StaffId
+	}
+	jobs, total, err := dao.Job.ListJobs(c.Request().Context(), filter, req.Offset, req.Limit)
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	items := make([]dto.JobItem, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, jobItem(job, false))
+	}
+
+	response.HTTPSuccess(r, dto.JobListResponse{Jobs: items, Total: total})
+}
+
+// HandleCancelJob 取消任务，创建者或管理员可操作
+func HandleCancelJob(c flamego.Context, r flamego.Render, req dto.CancelJobRequest, errs binding.Errors, authInfo auth.Info) {
+	if errs != nil {
+		response.InValidParam(r, errs)
+		return
+	}
+	job, ok := loadJob(c, r, authInfo, req.ID)
+	if !ok {
+		return
+	}
+	if job.Finished() {
+		response.HTTPFail(r, 400028, "任务已结束，无法取消")
+		return
+	}
+
+	if err := service.Cancel(c.Request().Context(), job.ID); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+
+	response.HTTPSuccess(r, nil)
+}
+
+// loadJob 获取任务并检查查看权限，失败时已写入响应
+func loadJob(c flamego.Context, r flamego.Render, authInfo auth.Info, id string) (*model.Job, bool) {
+	job, err := dao.Job.GetJob(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "任务不存在")
+			return nil, false
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return nil, false
+	}
+	if job.CreatedBy != authInfo.StaffId && !dao2.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 403014, "无权操作该任务")
+		return nil, false
+	}
+	return job, true
+}
+
+func jobItem(job model.Job, detail bool) dto.JobItem {
+	item := dto.JobItem{
+		ID:          job.ID,
+		Type:        job.Type,
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		Done:        job.Done,
+		Total:       job.Total,
+		Message:     job.Message,
+		Error:       job.Error,
+		CreatedBy:   job.CreatedBy,
+		CreatedAt:   job.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if job.Total > 0 {
+		item.Progress = float64(job.Done) * 100 / float64(job.Total)
+	}
+	if job.Status == model.StatusSucceeded {
+		item.Progress = 100
+	}
+	if detail {
+		item.Payload = json.RawMessage(job.Payload)
+		item.Result = json.RawMessage(job.Result)
+	}
+	if job.StartedAt != nil {
+		item.StartedAt = job.StartedAt.Format("2006-01-02 15:04:05")
+	}
+	if job.FinishedAt != nil {
+		item.FinishedAt = job.FinishedAt.Format("2006-01-02 15:04:05")
+	}
+	return item
+}
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"HelpStudent/core/kernel"
+	"HelpStudent/internal/app"
+	"HelpStudent/internal/app/jobs/dao"
+	"HelpStudent/internal/app/jobs/router"
+	"HelpStudent/internal/app/jobs/service"
+	"context"
+	"sync"
+)
+
+type (
+	// Jobs 后台任务模块，其他模块在 Init 阶段通过 service.Register 注册任务类型
+	Jobs struct {
+		Name string
+		app.UnimplementedModule
+	}
+)
+
+func (p *Jobs) Info() string {
+	return p.Name
+}
+
+func (p *Jobs) PreInit(engine *kernel.Engine) error {
+	return dao.InitPG(engine.MainPG.GetOrm())
+}
+
+func (p *Jobs) Init(engine *kernel.Engine) error {
+	return nil
+}
+
+func (p *Jobs) PostInit(*kernel.Engine) error {
+	return nil
+}
+
+func (p *Jobs) Load(engine *kernel.Engine) error {
+	// 加载flamego api
+	router.AppJobsInit(engine.Fg)
+	return nil
+}
+
+// Start 所有模块注册完任务类型后启动 worker，同时恢复上次未完成的任务
+func (p *Jobs) Start(engine *kernel.Engine) error {
+	service.StartWorkers(engine.Ctx)
+	return nil
+}
+
+func (p *Jobs) Stop(wg *sync.WaitGroup, ctx context.Context) error {
+	defer wg.Done()
+	return service.StopWorkers(ctx)
+}
+
+func (p *Jobs) OnConfigChange() func(*kernel.Engine) error {
+	return func(engine *kernel.Engine) error {
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	// StatusPending 等待执行，包括等待重试
+	StatusPending = "pending"
+	// StatusRunning 正在执行
+	StatusRunning = "running"
+	// StatusSucceeded 执行成功
+	StatusSucceeded = "succeeded"
+	// StatusFailed 重试次数用尽或不可重试的失败
+	StatusFailed = "failed"
+	// StatusCanceled 已取消
+	StatusCanceled = "canceled"
+)
+
+// Job 后台任务
+type Job struct {
+	model.Base
+	Type            string         `gorm:"type:varchar(50);not null;index;comment:任务类型"`
+	Payload         datatypes.JSON `gorm:"comment:任务参数"`
+	Status          string         `gorm:"type:varchar(20);not null;index:idx_job_claim,priority:1;comment:任务状态"`
+	RunAfter        time.Time      `gorm:"not null;index:idx_job_claim,priority:2;comment:最早执行时间，用于重试退避"`
+	Attempts        int            `gorm:"not null;default:0;comment:已尝试次数"`
+	MaxAttempts     int            `gorm:"not null;default:3;comment:最大尝试次数"`
+	Done            int            `gorm:"not null;default:0;comment:已完成的工作量，恢复执行时从这里继续"`
+	Total           int            `gorm:"not null;default:0;comment:总工作量"`
+	Message         string         `gorm:"type:varchar(255);comment:进度说明"`
+	Error           string         `gorm:"type:text;comment:最近一次失败原因"`
+	Result          datatypes.JSON `gorm:"comment:执行结果"`
+	CancelRequested bool           `gorm:"not null;default:false;comment:运行中的任务已请求取消"`
+	WorkerId        string         `gorm:"type:varchar(100);comment:执行该任务的进程"`
+	HeartbeatAt     *time.Time     `gorm:"comment:最近一次心跳"`
+	CreatedBy       string         `gorm:"type:varchar(50);index;comment:创建者学号"`
+	StartedAt       *time.Time     `gorm:"comment:最近一次开始执行时间"`
+	FinishedAt      *time.Time     `gorm:"comment:结束时间"`
+}
+
+// Finished 任务是否已经结束
+func (j *Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed || j.Status == StatusCanceled
+}
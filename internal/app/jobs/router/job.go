@@ -0,0 +1,18 @@
+package router
+
+import (
+	"HelpStudent/core/middleware/web"
+	"HelpStudent/internal/app/jobs/dto"
+	"HelpStudent/internal/app/jobs/handler/v1"
+
+	"github.com/flamego/binding"
+	"github.com/flamego/flamego"
+)
+
+func AppJobsInit(e *flamego.Flame) {
+	e.Group("/jobs", func() {
+		e.Get("/detail", handler.HandleGetJob)
+		e.Post("/list", binding.JSON(dto.GetJobListRequest{}), handler.HandleGetJobList)
+		e.Post("/cancel", binding.JSON(dto.CancelJobRequest{}), handler.HandleCancelJob)
+	}, web.Authorization)
+}
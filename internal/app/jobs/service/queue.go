@@ -0,0 +1,224 @@
+package service
+
+import (
+	"HelpStudent/config"
+	"HelpStudent/internal/app/jobs/dao"
+	"HelpStudent/internal/app/jobs/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+var (
+	// ErrCanceled 任务已被取消，处理函数收到后应尽快返回
+	ErrCanceled = errors.New("job canceled")
+	// ErrUnknownType 任务类型没有注册处理函数
+	ErrUnknownType = errors.New("unknown job type")
+)
+
+// Handler 执行一个任务，返回错误时按 MaxAttempts 重试，用 Permanent 包装的错误不再重试
+// 任务可能在中断后重新执行，处理函数应从 task.Done 记录的进度继续
+type Handler func(ctx context.Context, task *Task) error
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = make(map[string]Handler)
+)
+
+// Register 注册任务类型的处理函数，应在模块 Init 阶段调用
+func Register(jobType string, handler Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	if _, ok := handlers[jobType]; ok {
+		panic("jobs: duplicate handler for " + jobType)
+	}
+	handlers[jobType] = handler
+}
+
+func handlerFor(jobType string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[jobType]
+	return h, ok
+}
+
+// registeredTypes 本进程可以执行的任务类型
+func registeredTypes() []string {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	types := make([]string, 0, len(handlers))
+	for t := range handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 标记不需要重试的错误，例如参数错误
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 判断错误是否不再重试，处理函数可据此判断本次失败是否为最后一次
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// EnqueueOption 创建任务时的可选项
+type EnqueueOption func(*model.Job)
+
+// WithMaxAttempts 设置最大尝试次数
+func WithMaxAttempts(n int) EnqueueOption {
+	return func(j *model.Job) {
+		if n > 0 {
+			j.MaxAttempts = n
+		}
+	}
+}
+
+// WithCreatedBy 记录任务创建者，创建者可以查看和取消自己的任务
+func WithCreatedBy(staffId string) EnqueueOption {
+	return func(j *model.Job) {
+		j.CreatedBy = staffId
+	}
+}
+
+// WithRunAfter 延迟执行
+func WithRunAfter(t time.Time) EnqueueOption {
+	return func(j *model.Job) {
+		j.RunAfter = t
+	}
+}
+
+// Enqueue 创建任务，payload 序列化为 JSON 保存
+func Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...EnqueueOption) (*model.Job, error) {
+	if _, ok := handlerFor(jobType); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	j := &model.Job{
+		Type:        jobType,
+		Payload:     datatypes.JSON(raw),
+		Status:      model.StatusPending,
+		RunAfter:    time.Now(),
+		MaxAttempts: settings().MaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	if err := dao.Job.CreateJob(ctx, j); err != nil {
+		return nil, err
+	}
+	wake()
+	return j, nil
+}
+
+// Cancel 取消任务，运行中的任务在本进程内立即中止，在其他进程则在下次心跳时中止
+func Cancel(ctx context.Context, id string) error {
+	if err := dao.Job.RequestCancel(ctx, id); err != nil {
+		return err
+	}
+	if cancel, ok := running.Load(id); ok {
+		cancel.(context.CancelFunc)()
+	}
+	return nil
+}
+
+// Task 正在执行的任务
+type Task struct {
+	*model.Job
+	result interface{}
+}
+
+// Decode 解析任务参数
+func (t *Task) Decode(v interface{}) error {
+	if err := json.Unmarshal(t.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("decode payload: %w", err))
+	}
+	return nil
+}
+
+// Progress 保存进度，任务已请求取消时返回 ErrCanceled
+func (t *Task) Progress(ctx context.Context, done, total int, message string) error {
+	t.Done, t.Total, t.Message = done, total, message
+	canceled, err := dao.Job.Heartbeat(ctx, t.ID, t.WorkerId, map[string]interface{}{
+		"done":    done,
+		"total":   total,
+		"message": message,
+	})
+	if err != nil {
+		return err
+	}
+	if canceled {
+		return ErrCanceled
+	}
+	return nil
+}
+
+// Checkpoint 执行 fn 并在同一事务中保存进度，用于推送数据等重复执行会产生副作用的步骤
+// fn 成功则进度一定随之保存，任务被接管或请求取消时不再执行 fn
+func (t *Task) Checkpoint(ctx context.Context, done, total int, message string, fn func() error) error {
+	canceled, err := dao.Job.Checkpoint(ctx, t.ID, t.WorkerId, map[string]interface{}{
+		"done":    done,
+		"total":   total,
+		"message": message,
+	}, fn)
+	if err != nil {
+		return err
+	}
+	if canceled {
+		return ErrCanceled
+	}
+	t.Done, t.Total, t.Message = done, total, message
+	return nil
+}
+
+// CancelRequested 任务是否已被请求取消，处理函数因 ctx 取消而返回时可据此区分取消和进程退出
+func (t *Task) CancelRequested(ctx context.Context) bool {
+	j, err := dao.Job.GetJob(ctx, t.ID)
+	return err == nil && j.CancelRequested
+}
+
+// SetResult 设置任务成功后保存的结果
+func (t *Task) SetResult(v interface{}) {
+	t.result = v
+}
+
+// settings 返回任务队列配置，未配置的项使用默认值
+func settings() config.Jobs {
+	cfg := config.GetConfig().Jobs
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = 2 * time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	return cfg
+}
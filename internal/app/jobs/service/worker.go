@@ -0,0 +1,243 @@
+package service
+
+import (
+	"HelpStudent/core/logx"
+	"HelpStudent/core/threadx"
+	"HelpStudent/internal/app/jobs/dao"
+	"HelpStudent/internal/app/jobs/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+var (
+	// workerId 标识本进程，心跳和结束任务时只更新本进程领取的任务
+	workerId = fmt.Sprintf("%s-%d-%s", hostname(), os.Getpid(), ulid.Make().String()[20:])
+	// wakeCh 有新任务时唤醒空闲的 worker
+	wakeCh = make(chan struct{}, 1)
+	// running 本进程正在执行的任务，用于立即取消
+	running sync.Map
+
+	poolMu     sync.Mutex
+	poolCancel context.CancelFunc
+	poolGroup  *threadx.RoutineGroup
+)
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
+
+func wake() {
+	select {
+	case wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// StartWorkers 启动 worker 池和中断任务回收
+func StartWorkers(ctx context.Context) {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	if poolCancel != nil {
+		return
+	}
+
+	cfg := settings()
+	ctx, poolCancel = context.WithCancel(ctx)
+	poolGroup = threadx.NewRoutineGroup()
+
+	poolGroup.RunSafe(func() {
+		reclaimLoop(ctx)
+	})
+	for i := 0; i < cfg.Workers; i++ {
+		poolGroup.RunSafe(func() {
+			workerLoop(ctx)
+		})
+	}
+	logx.SystemLogger.Infof("job workers started: %s, workers=%d, types=%v", workerId, cfg.Workers, registeredTypes())
+}
+
+// StopWorkers 停止领取新任务并等待执行中的任务退出
+// 被中止的任务放回队列，由下次启动或其他进程继续执行
+func StopWorkers(ctx context.Context) error {
+	poolMu.Lock()
+	cancel, group := poolCancel, poolGroup
+	poolCancel, poolGroup = nil, nil
+	poolMu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		group.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reclaimLoop 定期回收心跳超时的任务
+func reclaimLoop(ctx context.Context) {
+	cfg := settings()
+	ticker := time.NewTicker(cfg.StaleAfter / 2)
+	defer ticker.Stop()
+	for {
+		n, err := dao.Job.ReclaimStale(ctx, time.Now().Add(-cfg.StaleAfter))
+		if err != nil && ctx.Err() == nil {
+			logx.SystemLogger.CtxError(ctx, "reclaim stale jobs failed", err)
+		}
+		if n > 0 {
+			logx.SystemLogger.Infof("reclaimed %d interrupted jobs", n)
+			wake()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// workerLoop 不断领取并执行任务，没有任务时等待通知或轮询
+func workerLoop(ctx context.Context) {
+	poll := settings().PollInterval
+	for ctx.Err() == nil {
+		j, err := dao.Job.Claim(ctx, workerId, registeredTypes())
+		if err != nil && ctx.Err() == nil {
+			logx.SystemLogger.CtxError(ctx, "claim job failed", err)
+		}
+		if j == nil {
+			select {
+			case <-ctx.Done():
+			case <-wakeCh:
+			case <-time.After(poll):
+			}
+			continue
+		}
+		execute(ctx, j)
+	}
+}
+
+// execute 执行一个已领取的任务并根据结果更新状态
+func execute(ctx context.Context, j *model.Job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	running.Store(j.ID, cancel)
+	defer running.Delete(j.ID)
+
+	// 心跳，同时发现其他进程发起的取消
+	go heartbeat(jobCtx, cancel, j)
+
+	task := &Task{Job: j}
+	err := runHandler(jobCtx, task)
+
+	// 状态更新不受 ctx 取消影响
+	store := context.WithoutCancel(ctx)
+	status, updates := nextState(j, err, ctx.Err() != nil, jobCtx.Err() != nil, time.Now())
+	if status == model.StatusSucceeded && task.result != nil {
+		if raw, mErr := json.Marshal(task.result); mErr == nil {
+			updates["result"] = raw
+		}
+	}
+	finishErr := dao.Job.Finish(store, j.ID, workerId, status, updates)
+	if err != nil && ctx.Err() == nil {
+		logx.SystemLogger.Errorf("job %s (%s) attempt %d failed: %v", j.ID, j.Type, j.Attempts, err)
+	}
+	if finishErr != nil {
+		logx.SystemLogger.CtxError(store, "update job status failed", j.ID, finishErr)
+	}
+}
+
+// nextState 根据处理结果决定任务的下一个状态和需要更新的字段
+// shutdown 表示进程正在退出，canceled 表示任务执行被取消
+func nextState(j *model.Job, err error, shutdown, canceled bool, now time.Time) (string, map[string]interface{}) {
+	switch {
+	case err == nil:
+		return model.StatusSucceeded, map[string]interface{}{"error": ""}
+	case shutdown:
+		// 进程退出，放回队列且不计入尝试次数
+		return model.StatusPending, map[string]interface{}{
+			"attempts":  j.Attempts - 1,
+			"run_after": now,
+		}
+	case errors.Is(err, ErrCanceled) || canceled:
+		return model.StatusCanceled, map[string]interface{}{}
+	case IsPermanent(err) || j.Attempts >= j.MaxAttempts:
+		return model.StatusFailed, map[string]interface{}{"error": err.Error()}
+	default:
+		return model.StatusPending, map[string]interface{}{
+			"error":     err.Error(),
+			"run_after": now.Add(backoff(j.Attempts)),
+		}
+	}
+}
+
+// runHandler 调用处理函数，panic 视为不可重试的失败
+func runHandler(ctx context.Context, task *Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("panic: %v", r))
+		}
+	}()
+	h, ok := handlerFor(task.Type)
+	if !ok {
+		return Permanent(fmt.Errorf("%w: %s", ErrUnknownType, task.Type))
+	}
+	return h(ctx, task)
+}
+
+// heartbeat 定期更新心跳，任务被请求取消或被其他进程接管时中止执行
+func heartbeat(ctx context.Context, cancel context.CancelFunc, j *model.Job) {
+	ticker := time.NewTicker(settings().StaleAfter / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		canceled, err := dao.Job.Heartbeat(ctx, j.ID, workerId, nil)
+		if heartbeatAborts(canceled, err) {
+			cancel()
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			logx.SystemLogger.CtxError(ctx, "job heartbeat failed", j.ID, err)
+		}
+	}
+}
+
+// heartbeatAborts 心跳后是否中止执行：任务心跳超时已被其他进程接管，或已请求取消
+// 其他错误可能是数据库短暂不可用，继续执行并在下次心跳重试
+func heartbeatAborts(canceled bool, err error) bool {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true
+	}
+	return err == nil && canceled
+}
+
+// backoff 第 n 次失败后的重试等待时间：10s、40s、90s……最长 10 分钟
+func backoff(attempt int) time.Duration {
+	d := time.Duration(attempt*attempt) * 10 * time.Second
+	if d > 10*time.Minute {
+		d = 10 * time.Minute
+	}
+	return d
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"HelpStudent/internal/app/jobs/model"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestNextState(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	boom := errors.New("boom")
+	tests := []struct {
+		name         string
+		attempts     int
+		err          error
+		shutdown     bool
+		canceled     bool
+		wantStatus   string
+		wantError    interface{}   // nil 表示不更新 error
+		wantAttempts interface{}   // nil 表示不更新 attempts
+		wantRetryIn  time.Duration // 放回队列时的等待时间
+	}{
+		{name: "succeeded", attempts: 1, wantStatus: model.StatusSucceeded, wantError: ""},
+		{name: "succeeded while canceling", attempts: 1, canceled: true, wantStatus: model.StatusSucceeded, wantError: ""},
+		{name: "retry", attempts: 1, err: boom, wantStatus: model.StatusPending, wantError: "boom", wantRetryIn: 10 * time.Second},
+		{name: "retry backoff grows", attempts: 2, err: boom, wantStatus: model.StatusPending, wantError: "boom", wantRetryIn: 40 * time.Second},
+		{name: "max attempts", attempts: 3, err: boom, wantStatus: model.StatusFailed, wantError: "boom"},
+		{name: "permanent", attempts: 1, err: Permanent(boom), wantStatus: model.StatusFailed, wantError: "boom"},
+		{name: "wrapped permanent", attempts: 1, err: fmt.Errorf("step 2: %w", Permanent(boom)), wantStatus: model.StatusFailed, wantError: "step 2: boom"},
+		{name: "handler returned canceled", attempts: 1, err: fmt.Errorf("export: %w", ErrCanceled), wantStatus: model.StatusCanceled},
+		{name: "canceled by request", attempts: 1, err: context.Canceled, canceled: true, wantStatus: model.StatusCanceled},
+		{name: "canceled on last attempt", attempts: 3, err: context.Canceled, canceled: true, wantStatus: model.StatusCanceled},
+		{name: "shutdown", attempts: 2, err: context.Canceled, shutdown: true, canceled: true, wantStatus: model.StatusPending, wantAttempts: 1},
+		{name: "shutdown on last attempt", attempts: 3, err: Permanent(boom), shutdown: true, wantStatus: model.StatusPending, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &model.Job{Attempts: tt.attempts, MaxAttempts: 3}
+			status, updates := nextState(j, tt.err, tt.shutdown, tt.canceled, now)
+			if status != tt.wantStatus {
+				t.Fatalf("status: got %s, want %s", status, tt.wantStatus)
+			}
+			if updates["error"] != tt.wantError {
+				t.Errorf("error: got %v, want %v", updates["error"], tt.wantError)
+			}
+			if updates["attempts"] != tt.wantAttempts {
+				t.Errorf("attempts: got %v, want %v", updates["attempts"], tt.wantAttempts)
+			}
+			if status == model.StatusPending {
+				runAfter, _ := updates["run_after"].(time.Time)
+				if got := runAfter.Sub(now); got != tt.wantRetryIn {
+					t.Errorf("run after: got +%v, want +%v", got, tt.wantRetryIn)
+				}
+			}
+		})
+	}
+}
+
+func TestHeartbeatAborts(t *testing.T) {
+	tests := []struct {
+		name     string
+		canceled bool
+		err      error
+		want     bool
+	}{
+		{name: "running", want: false},
+		{name: "cancel requested", canceled: true, want: true},
+		{name: "taken over", err: gorm.ErrRecordNotFound, want: true},
+		{name: "db error", err: errors.New("connection refused"), want: false},
+		{name: "db error while reading cancel", canceled: true, err: errors.New("connection refused"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := heartbeatAborts(tt.canceled, tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 10 * time.Second},
+		{attempt: 2, want: 40 * time.Second},
+		{attempt: 3, want: 90 * time.Second},
+		{attempt: 7, want: 490 * time.Second},
+		{attempt: 8, want: 10 * time.Minute},
+		{attempt: 100, want: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d): got %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRunHandler(t *testing.T) {
+	boom := errors.New("boom")
+	Register("test.ok", func(ctx context.Context, task *Task) error { return nil })
+	Register("test.retry", func(ctx context.Context, task *Task) error { return boom })
+	Register("test.panic", func(ctx context.Context, task *Task) error { panic("nil map") })
+
+	tests := []struct {
+		jobType       string
+		wantErr       error
+		wantPermanent bool
+	}{
+		{jobType: "test.ok"},
+		{jobType: "test.retry", wantErr: boom},
+		{jobType: "test.panic", wantPermanent: true},
+		{jobType: "test.unknown", wantErr: ErrUnknownType, wantPermanent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jobType, func(t *testing.T) {
+			err := runHandler(context.Background(), &Task{Job: &model.Job{Type: tt.jobType}})
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err: got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !tt.wantPermanent && err != nil {
+				t.Errorf("err: got %v, want nil", err)
+			}
+			if IsPermanent(err) != tt.wantPermanent {
+				t.Errorf("permanent: got %v, want %v", IsPermanent(err), tt.wantPermanent)
+			}
+		})
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"HelpStudent/internal/app/managers/dto"
 	"HelpStudent/internal/app/managers/model"
 	subjectDAO "HelpStudent/internal/app/subject/dao"
-	"bytes"
+	"HelpStudent/pkg/utils/sheet"
 	"errors"
 	"fmt"
 	"io"
@@ -55,28 +55,18 @@ func HandleImportStudentSubjectsExcel(c flamego.Context, r flamego.Render, authI
 		return
 	}
 
-	// 打开Excel文件
-	f, err := excelize.OpenReader(bytes.NewReader(fileBytes))
+	// 读取第一个工作表
+	rows, err := sheet.ReadRows(header.Filename, fileBytes)
 	if err != nil {
 		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.HTTPFail(r, 400005, "打开Excel文件失败")
-		return
-	}
-	defer func() {
-		_ = f.Close()
-	}()
-
-	// 获取第一个工作表
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		response.HTTPFail(r, 400006, "Excel文件中没有工作表")
-		return
-	}
-
-	rows, err := f.GetRows(sheets[0])
-	if err != nil {
-		logx.SystemLogger.CtxError(c.Request().Context(), err)
-		response.HTTPFail(r, 400007, "读取Excel内容失败")
+		switch {
+		case errors.Is(err, sheet.ErrOpen):
+			response.HTTPFail(r, 400005, "打开Excel文件失败")
+		case errors.Is(err, sheet.ErrNoSheet):
+			response.HTTPFail(r, 400006, "Excel文件中没有工作表")
+		default:
+			response.HTTPFail(r, 400007, "读取Excel内容失败")
+		}
 		return
 	}
 
@@ -87,18 +77,8 @@ func HandleImportStudentSubjectsExcel(c flamego.Context, r flamego.Render, authI
 
 	// 解析表头，查找学号和科目名称列
 	headerRow := rows[0]
-	staffIdColIdx := -1
-	subjectColIdx := -1
-
-	for idx, cell := range headerRow {
-		cellTrimmed := strings.TrimSpace(cell)
-		if cellTrimmed == "学号" || cellTrimmed == "staff_id" || cellTrimmed == "StaffId" {
-			staffIdColIdx = idx
-		}
-		if cellTrimmed == "科目名称" || cellTrimmed == "科目" || cellTrimmed == "subject_name" || cellTrimmed == "SubjectName" {
-			subjectColIdx = idx
-		}
-	}
+	staffIdColIdx := sheet.FindColumn(headerRow, "学号", "staff_id", "StaffId")
+	subjectColIdx := sheet.FindColumn(headerRow, "科目名称", "科目", "subject_name", "SubjectName")
 
 	if staffIdColIdx == -1 {
 		response.HTTPFail(r, 400009, "Excel缺少学号列（列名应为：学号/staff_id/StaffId）")
@@ -121,12 +101,8 @@ func HandleImportStudentSubjectsExcel(c flamego.Context, r flamego.Render, authI
 
 	for i := 1; i < len(rows); i++ {
 		row := rows[i]
-		if len(row) <= staffIdColIdx || len(row) <= subjectColIdx {
-			continue
-		}
-
-		staffId := strings.TrimSpace(row[staffIdColIdx])
-		subjectName := strings.TrimSpace(row[subjectColIdx])
+		staffId := sheet.Cell(row, staffIdColIdx)
+		subjectName := sheet.Cell(row, subjectColIdx)
 
 		if staffId == "" || subjectName == "" {
 			continue
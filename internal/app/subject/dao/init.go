@@ -14,5 +14,10 @@ func InitPG(db *gorm.DB) error {
 		return err
 	}
 
+	err = Lockout.Init(db)
+	if err != nil {
+		return err
+	}
+
 	return err
 }
@@ -0,0 +1,60 @@
+package dao
+
+import (
+	"HelpStudent/internal/app/subject/model"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type lockout struct {
+	*gorm.DB
+}
+
+var Lockout = &lockout{}
+
+func (u *lockout) Init(db *gorm.DB) (err error) {
+	u.DB = db
+	return db.AutoMigrate(&model.LockoutWindow{})
+}
+
+// CreateWindow 创建限制时段
+func (u *lockout) CreateWindow(ctx context.Context, w *model.LockoutWindow) error {
+	return u.WithContext(ctx).Create(w).Error
+}
+
+// UpdateWindow 更新限制时段，会保存全部字段
+func (u *lockout) UpdateWindow(ctx context.Context, w *model.LockoutWindow) error {
+	return u.WithContext(ctx).Save(w).Error
+}
+
+// DeleteWindow 删除限制时段
+func (u *lockout) DeleteWindow(ctx context.Context, id string) (int64, error) {
+	result := u.WithContext(ctx).Where("id = ?", id).Delete(&model.LockoutWindow{})
+	return result.RowsAffected, result.Error
+}
+
+// GetWindow 获取限制时段
+func (u *lockout) GetWindow(ctx context.Context, id string) (*model.LockoutWindow, error) {
+	var w model.LockoutWindow
+	err := u.WithContext(ctx).Where("id = ?", id).First(&w).Error
+	return &w, err
+}
+
+// ListWindows 获取限制时段，subjectName 为空时返回全部
+func (u *lockout) ListWindows(ctx context.Context, subjectName string) ([]model.LockoutWindow, error) {
+	var windows []model.LockoutWindow
+	query := u.WithContext(ctx)
+	if subjectName != "" {
+		query = query.Where("subject_name = ?", subjectName)
+	}
+	err := query.Order("subject_name ASC, created_at ASC").Find(&windows).Error
+	return windows, err
+}
+
+// ListEnabled 获取全部已启用的限制时段
+func (u *lockout) ListEnabled(ctx context.Context) ([]model.LockoutWindow, error) {
+	var windows []model.LockoutWindow
+	err := u.WithContext(ctx).Where("enabled = ?", true).Find(&windows).Error
+	return windows, err
+}
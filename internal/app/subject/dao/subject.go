@@ -221,3 +221,14 @@ func (d *subject) GetAllSubjectNames() ([]string, error) {
 	}
 	return names, nil
 }
+
+// HasUserSubject 检查用户是否选修了指定科目
+func (d *subject) HasUserSubject(staffId, subjectName string) (bool, error) {
+	var count int64
+	if err := d.Model(&model.UserSubject{}).
+		Where("staff_id = ? AND subject_name = ?", staffId, subjectName).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
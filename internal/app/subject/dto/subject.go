@@ -25,6 +25,12 @@ type SubjectItem struct {
 	AppID        string `json:"app_id"`         // 我们系统的 ID
 	FastgptAppId string `json:"fastgpt_app_id"` // FastGPT 的应用 ID
 	ShareId      string `json:"share_id"`
+
+	// 考试/作业限制时段
+	Available        bool   `json:"available"`                   // 当前是否可以提问
+	Restricted       bool   `json:"restricted"`                  // 当前处于限制模式
+	UnavailableUntil string `json:"unavailable_until,omitempty"` // 限制解除时间
+	LockoutMessage   string `json:"lockout_message,omitempty"`
 }
 
 type GetSubjectResp struct {
@@ -56,3 +62,43 @@ type UpdateUserSubjectReq struct {
 	StaffId     string `json:"staffId"`
 	SubjectName string `json:"subjectName"`
 }
+
+// LockoutWindowReq 创建或更新限制时段，时间格式为 2006-01-02 15:04:05，时刻格式为 15:04
+// kind 为 once 时需要 start_at、end_at；为 weekly 时需要 weekdays、start_time、end_time，start_at/end_at 可选表示生效日期范围
+type LockoutWindowReq struct {
+	ID          string `json:"id"`
+	SubjectName string `json:"subject_name"`
+	Name        string `json:"name"`
+	Action      string `json:"action"`
+	Kind        string `json:"kind"`
+	StartAt     string `json:"start_at"`
+	EndAt       string `json:"end_at"`
+	Weekdays    []int  `json:"weekdays"`
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time"`
+	Prompt      string `json:"prompt"`
+	Message     string `json:"message"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+type LockoutWindowItem struct {
+	ID          string `json:"id"`
+	SubjectName string `json:"subject_name"`
+	Name        string `json:"name"`
+	Action      string `json:"action"`
+	Kind        string `json:"kind"`
+	StartAt     string `json:"start_at"`
+	EndAt       string `json:"end_at"`
+	Weekdays    []int  `json:"weekdays"`
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time"`
+	Prompt      string `json:"prompt"`
+	Message     string `json:"message"`
+	Enabled     bool   `json:"enabled"`
+	Active      bool   `json:"active"` // 当前是否生效
+	CreatedBy   string `json:"created_by"`
+}
+
+type GetLockoutListResp struct {
+	Lockouts []LockoutWindowItem `json:"lockouts"`
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"HelpStudent/core/auth"
+	"HelpStudent/core/logx"
+	"HelpStudent/core/middleware/response"
+	managerDAO "HelpStudent/internal/app/managers/dao"
+	"HelpStudent/internal/app/subject/dao"
+	"HelpStudent/internal/app/subject/dto"
+	"HelpStudent/internal/app/subject/model"
+	"HelpStudent/internal/app/subject/service"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flamego/flamego"
+	"gorm.io/gorm"
+)
+
+const lockoutTimeLayout = "2006-01-02 15:04:05"
+
+// GetLockoutList 管理员查看限制时段，可按 subject_name 筛选
+func GetLockoutList(r flamego.Render, c flamego.Context, authInfo auth.Info) {
+	if !managerDAO.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理限制时段")
+		return
+	}
+
+	windows, err := dao.Lockout.ListWindows(c.Request().Context(), c.Query("subject_name"))
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	now := time.Now()
+	items := make([]dto.LockoutWindowItem, 0, len(windows))
+	for _, w := range windows {
+		items = append(items, lockoutItem(w, now))
+	}
+
+	response.HTTPSuccess(r, dto.GetLockoutListResp{Lockouts: items})
+}
+
+// AddLockout 管理员为科目添加限制时段
+func AddLockout(r flamego.Render, c flamego.Context, req dto.LockoutWindowReq, authInfo auth.Info) {
+	if !managerDAO.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理限制时段")
+		return
+	}
+
+	w := &model.LockoutWindow{Enabled: true, CreatedBy: authInfo.StaffId}
+	if err := fillLockout(w, req); err != nil {
+		response.HTTPFail(r, 400033, err.Error())
+		return
+	}
+	if err := dao.Lockout.CreateWindow(c.Request().Context(), w); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	service.ReloadLockouts()
+
+	response.HTTPSuccess(r, lockoutItem(*w, time.Now()))
+}
+
+// UpdateLockout 管理员修改限制时段，请求需要包含全部字段
+func UpdateLockout(r flamego.Render, c flamego.Context, req dto.LockoutWindowReq, authInfo auth.Info) {
+	if !managerDAO.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理限制时段")
+		return
+	}
+	if req.ID == "" {
+		response.HTTPFail(r, 400001, "id 不能为空")
+		return
+	}
+
+	w, err := dao.Lockout.GetWindow(c.Request().Context(), req.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.HTTPFail(r, 404001, "限制时段不存在")
+			return
+		}
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if err := fillLockout(w, req); err != nil {
+		response.HTTPFail(r, 400033, err.Error())
+		return
+	}
+	if err := dao.Lockout.UpdateWindow(c.Request().Context(), w); err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	service.ReloadLockouts()
+
+	response.HTTPSuccess(r, lockoutItem(*w, time.Now()))
+}
+
+// DeleteLockout 管理员删除限制时段
+func DeleteLockout(r flamego.Render, c flamego.Context, authInfo auth.Info) {
+	if !managerDAO.Managers.IsManager(authInfo.StaffId) {
+		response.HTTPFail(r, 400013, "非管理员用户无法管理限制时段")
+		return
+	}
+
+	affected, err := dao.Lockout.DeleteWindow(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		logx.SystemLogger.CtxError(c.Request().Context(), err)
+		response.ServiceErr(r, err)
+		return
+	}
+	if affected == 0 {
+		response.HTTPFail(r, 404001, "限制时段不存在")
+		return
+	}
+	service.ReloadLockouts()
+
+	response.HTTPSuccess(r, nil)
+}
+
+// fillLockout 校验请求并写入限制时段
+func fillLockout(w *model.LockoutWindow, req dto.LockoutWindowReq) error {
+	w.SubjectName = strings.TrimSpace(req.SubjectName)
+	w.Name = strings.TrimSpace(req.Name)
+	w.Action = req.Action
+	w.Kind = req.Kind
+	w.Prompt = req.Prompt
+	w.Message = req.Message
+	if req.Enabled != nil {
+		w.Enabled = *req.Enabled
+	}
+	if w.SubjectName == "" || w.Name == "" {
+		return errors.New("科目名称和时段名称不能为空")
+	}
+	switch w.Action {
+	case model.LockoutBlock:
+	case model.LockoutRestrict:
+		if strings.TrimSpace(w.Prompt) == "" {
+			return errors.New("restrict 时段需要填写提示词")
+		}
+	default:
+		return errors.New("action 应为 block 或 restrict")
+	}
+
+	var err error
+	if w.StartAt, err = parseLockoutTime(req.StartAt); err != nil {
+		return err
+	}
+	if w.EndAt, err = parseLockoutTime(req.EndAt); err != nil {
+		return err
+	}
+	if w.StartAt != nil && w.EndAt != nil && !w.EndAt.After(*w.StartAt) {
+		return errors.New("结束时间应晚于开始时间")
+	}
+
+	switch w.Kind {
+	case model.LockoutOnce:
+		if w.StartAt == nil || w.EndAt == nil {
+			return errors.New("一次性时段需要填写开始和结束时间")
+		}
+		w.Weekdays, w.StartTime, w.EndTime = "", "", ""
+	case model.LockoutWeekly:
+		if len(req.Weekdays) == 0 {
+			return errors.New("每周重复的时段需要选择星期")
+		}
+		days := make([]int, 0, len(req.Weekdays))
+		seen := make(map[int]bool)
+		for _, d := range req.Weekdays {
+			if d < 0 || d > 6 {
+				return errors.New("星期应为 0-6，0 为周日")
+			}
+			if !seen[d] {
+				seen[d] = true
+				days = append(days, d)
+			}
+		}
+		sort.Ints(days)
+		parts := make([]string, 0, len(days))
+		for _, d := range days {
+			parts = append(parts, strconv.Itoa(d))
+		}
+		w.Weekdays = strings.Join(parts, ",")
+
+		start, err1 := time.Parse("15:04", req.StartTime)
+		end, err2 := time.Parse("15:04", req.EndTime)
+		if err1 != nil || err2 != nil {
+			return errors.New("开始和结束时刻格式应为 15:04")
+		}
+		if !end.After(start) {
+			return errors.New("结束时刻应晚于开始时刻，跨天的时段请拆分为两段")
+		}
+		w.StartTime, w.EndTime = req.StartTime, req.EndTime
+	default:
+		return errors.New("kind 应为 once 或 weekly")
+	}
+	return nil
+}
+
+func parseLockoutTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation(lockoutTimeLayout, s, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("时间格式应为 %s", lockoutTimeLayout)
+	}
+	return &t, nil
+}
+
+func lockoutItem(w model.LockoutWindow, now time.Time) dto.LockoutWindowItem {
+	item := dto.LockoutWindowItem{
+		ID:          w.ID,
+		SubjectName: w.SubjectName,
+		Name:        w.Name,
+		Action:      w.Action,
+		Kind:        w.Kind,
+		Weekdays:    []int{},
+		StartTime:   w.StartTime,
+		EndTime:     w.EndTime,
+		Prompt:      w.Prompt,
+		Message:     w.Message,
+		Enabled:     w.Enabled,
+		Active:      w.ActiveAt(now),
+		CreatedBy:   w.CreatedBy,
+	}
+	if w.StartAt != nil {
+		item.StartAt = w.StartAt.Format(lockoutTimeLayout)
+	}
+	if w.EndAt != nil {
+		item.EndAt = w.EndAt.Format(lockoutTimeLayout)
+	}
+	for _, s := range strings.Split(w.Weekdays, ",") {
+		if d, err := strconv.Atoi(s); err == nil {
+			item.Weekdays = append(item.Weekdays, d)
+		}
+	}
+	return item
+}
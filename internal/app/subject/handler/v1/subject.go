@@ -9,11 +9,13 @@ import (
 	"HelpStudent/internal/app/subject/dao"
 	"HelpStudent/internal/app/subject/dto"
 	"HelpStudent/internal/app/subject/model"
+	"HelpStudent/internal/app/subject/service"
 	userDAO "HelpStudent/internal/app/users/dao"
 	userModel "HelpStudent/internal/app/users/model"
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/flamego/flamego"
 	"gorm.io/gorm"
@@ -64,6 +66,7 @@ func GetSubjectLink(r flamego.Render, c flamego.Context, authInfo auth.Info) {
 		}
 	}
 
+	now := time.Now()
 	var subjects []dto.SubjectItem
 	for _, a := range apps {
 		var subject dto.SubjectItem
@@ -71,6 +74,18 @@ func GetSubjectLink(r flamego.Render, c flamego.Context, authInfo auth.Info) {
 		subject.AppName = a.AppName
 		subject.AppID = a.ID
 		subject.FastgptAppId = a.AppId
+
+		// 限制时段，便于前端提示何时恢复
+		status, err := service.GetLockoutStatus(c.Request().Context(), a.AppName, now)
+		if err != nil {
+			logx.SystemLogger.CtxError(c.Request().Context(), err)
+		}
+		subject.Available = !status.Blocked
+		subject.Restricted = status.Restricted
+		subject.LockoutMessage = status.Message
+		if !status.Until.IsZero() {
+			subject.UnavailableUntil = status.Until.Format("2006-01-02 15:04:05")
+		}
 		subjects = append(subjects, subject)
 	}
 
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"HelpStudent/internal/model"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// LockoutBlock 时段内禁止使用助手
+	LockoutBlock = "block"
+	// LockoutRestrict 时段内可以使用，但注入限制提示词
+	LockoutRestrict = "restrict"
+
+	// LockoutOnce 一次性时段，StartAt 到 EndAt
+	LockoutOnce = "once"
+	// LockoutWeekly 每周重复，Weekdays 中每天的 StartTime 到 EndTime，StartAt/EndAt 为生效日期范围
+	LockoutWeekly = "weekly"
+)
+
+// LockoutWindow 科目的考试/作业限制时段，按服务器本地时间计算
+type LockoutWindow struct {
+	model.Base
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	SubjectName string         `gorm:"type:varchar(50);not null;index;comment:科目名称，与 FastgptApp 的 AppName 一致" json:"subject_name"`
+	Name        string         `gorm:"type:varchar(100);not null;comment:时段名称" json:"name"`
+	Action      string         `gorm:"type:varchar(10);not null;comment:block/restrict" json:"action"`
+	Kind        string         `gorm:"type:varchar(10);not null;comment:once/weekly" json:"kind"`
+	StartAt     *time.Time     `gorm:"comment:一次性时段的开始时间，或每周重复的生效开始时间" json:"start_at"`
+	EndAt       *time.Time     `gorm:"comment:一次性时段的结束时间，或每周重复的生效结束时间" json:"end_at"`
+	Weekdays    string         `gorm:"type:varchar(20);comment:每周重复的星期，0 为周日，逗号分隔" json:"weekdays"`
+	StartTime   string         `gorm:"type:varchar(5);comment:每周重复的开始时刻 HH:MM" json:"start_time"`
+	EndTime     string         `gorm:"type:varchar(5);comment:每周重复的结束时刻 HH:MM" json:"end_time"`
+	Prompt      string         `gorm:"type:text;comment:restrict 时注入的提示词" json:"prompt"`
+	Message     string         `gorm:"type:varchar(255);comment:展示给学生的说明" json:"message"`
+	Enabled     bool           `gorm:"not null;default:true;comment:是否启用" json:"enabled"`
+	CreatedBy   string         `gorm:"type:varchar(50);comment:创建者" json:"created_by"`
+}
+
+// ActiveAt 判断时段在 t 时是否生效
+func (w *LockoutWindow) ActiveAt(t time.Time) bool {
+	if !w.Enabled {
+		return false
+	}
+	switch w.Kind {
+	case LockoutOnce:
+		return w.StartAt != nil && w.EndAt != nil && !t.Before(*w.StartAt) && t.Before(*w.EndAt)
+	case LockoutWeekly:
+		if w.StartAt != nil && t.Before(*w.StartAt) || w.EndAt != nil && !t.Before(*w.EndAt) {
+			return false
+		}
+		if !w.onWeekday(t.Weekday()) {
+			return false
+		}
+		start, end, ok := w.clockRange(t)
+		return ok && !t.Before(start) && t.Before(end)
+	}
+	return false
+}
+
+// EndAfter 返回包含 t 的这一段时段的结束时间，调用前应确认 ActiveAt(t)
+func (w *LockoutWindow) EndAfter(t time.Time) time.Time {
+	switch w.Kind {
+	case LockoutOnce:
+		if w.EndAt != nil {
+			return *w.EndAt
+		}
+	case LockoutWeekly:
+		if _, end, ok := w.clockRange(t); ok {
+			if w.EndAt != nil && w.EndAt.Before(end) {
+				return *w.EndAt
+			}
+			return end
+		}
+	}
+	return t
+}
+
+func (w *LockoutWindow) onWeekday(day time.Weekday) bool {
+	for _, s := range strings.Split(w.Weekdays, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && time.Weekday(n) == day {
+			return true
+		}
+	}
+	return false
+}
+
+// clockRange 返回 t 当天的开始和结束时刻
+func (w *LockoutWindow) clockRange(t time.Time) (time.Time, time.Time, bool) {
+	start, err1 := time.ParseInLocation("15:04", w.StartTime, t.Location())
+	end, err2 := time.ParseInLocation("15:04", w.EndTime, t.Location())
+	if err1 != nil || err2 != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d, start.Hour(), start.Minute(), 0, 0, t.Location()),
+		time.Date(y, m, d, end.Hour(), end.Minute(), 0, 0, t.Location()), true
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+var loc = time.FixedZone("CST", 8*3600)
+
+func at(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestLockoutWindow_ActiveAt(t *testing.T) {
+	// 2026-03-02 为周一
+	once := LockoutWindow{Kind: LockoutOnce, Enabled: true, StartAt: ptr(at("2026-03-02 09:00")), EndAt: ptr(at("2026-03-02 11:00"))}
+	weekly := LockoutWindow{Kind: LockoutWeekly, Enabled: true, Weekdays: "1, 3", StartTime: "08:00", EndTime: "10:00"}
+	ranged := weekly
+	ranged.StartAt, ranged.EndAt = ptr(at("2026-03-04 00:00")), ptr(at("2026-03-09 09:00"))
+
+	tests := []struct {
+		name string
+		w    LockoutWindow
+		t    string
+		want bool
+	}{
+		{name: "once before start", w: once, t: "2026-03-02 08:59", want: false},
+		{name: "once at start", w: once, t: "2026-03-02 09:00", want: true},
+		{name: "once inside", w: once, t: "2026-03-02 10:30", want: true},
+		{name: "once at end", w: once, t: "2026-03-02 11:00", want: false},
+		{name: "once disabled", w: LockoutWindow{Kind: LockoutOnce, StartAt: once.StartAt, EndAt: once.EndAt}, t: "2026-03-02 10:00", want: false},
+		{name: "once without end", w: LockoutWindow{Kind: LockoutOnce, Enabled: true, StartAt: once.StartAt}, t: "2026-03-02 10:00", want: false},
+		{name: "weekly on monday", w: weekly, t: "2026-03-02 08:00", want: true},
+		{name: "weekly on wednesday", w: weekly, t: "2026-03-04 09:59", want: true},
+		{name: "weekly at end time", w: weekly, t: "2026-03-02 10:00", want: false},
+		{name: "weekly other weekday", w: weekly, t: "2026-03-03 09:00", want: false},
+		{name: "weekly sunday is zero", w: LockoutWindow{Kind: LockoutWeekly, Enabled: true, Weekdays: "0", StartTime: "00:00", EndTime: "23:59"}, t: "2026-03-08 12:00", want: true},
+		{name: "weekly before range", w: ranged, t: "2026-03-02 09:00", want: false},
+		{name: "weekly inside range", w: ranged, t: "2026-03-04 09:00", want: true},
+		{name: "weekly cut by range end", w: ranged, t: "2026-03-09 09:30", want: false},
+		{name: "weekly before range end", w: ranged, t: "2026-03-09 08:30", want: true},
+		{name: "weekly invalid clock", w: LockoutWindow{Kind: LockoutWeekly, Enabled: true, Weekdays: "1", StartTime: "8点", EndTime: "10:00"}, t: "2026-03-02 09:00", want: false},
+		{name: "unknown kind", w: LockoutWindow{Kind: "daily", Enabled: true}, t: "2026-03-02 09:00", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.w.ActiveAt(at(tt.t)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockoutWindow_EndAfter(t *testing.T) {
+	weekly := LockoutWindow{Kind: LockoutWeekly, Enabled: true, Weekdays: "1", StartTime: "08:00", EndTime: "10:00"}
+	ranged := weekly
+	ranged.EndAt = ptr(at("2026-03-02 09:00"))
+
+	tests := []struct {
+		name string
+		w    LockoutWindow
+		t    string
+		want string
+	}{
+		{name: "once", w: LockoutWindow{Kind: LockoutOnce, StartAt: ptr(at("2026-03-02 09:00")), EndAt: ptr(at("2026-03-02 11:00"))}, t: "2026-03-02 10:00", want: "2026-03-02 11:00"},
+		{name: "weekly same day end", w: weekly, t: "2026-03-02 08:30", want: "2026-03-02 10:00"},
+		{name: "weekly cut by range end", w: ranged, t: "2026-03-02 08:30", want: "2026-03-02 09:00"},
+		{name: "unknown kind returns t", w: LockoutWindow{Kind: "daily"}, t: "2026-03-02 08:30", want: "2026-03-02 08:30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.w.EndAfter(at(tt.t)); !got.Equal(at(tt.want)) {
+				t.Errorf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
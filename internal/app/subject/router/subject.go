@@ -35,6 +35,12 @@ func AppSubjectInit(e *flamego.Flame) {
 		e.Post("/user-subjects/add", binding.JSON(dto.AddUserSubjectReq{}), handler.AddUserSubjectHandler)
 		e.Delete("/user-subjects/delete/{id}", handler.DeleteUserSubjectHandler)
 		e.Post("/user-subjects/update", binding.JSON(dto.UpdateUserSubjectReq{}), handler.UpdateUserSubjectHandler)
+
+		// 考试/作业限制时段管理
+		e.Get("/lockouts", handler.GetLockoutList)
+		e.Post("/lockouts/add", binding.JSON(dto.LockoutWindowReq{}), handler.AddLockout)
+		e.Post("/lockouts/update", binding.JSON(dto.LockoutWindowReq{}), handler.UpdateLockout)
+		e.Delete("/lockouts/delete/{id}", handler.DeleteLockout)
 	}, web.Authorization)
 }
 
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"HelpStudent/internal/app/subject/dao"
+	"HelpStudent/internal/app/subject/model"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lockoutTTL 限制时段缓存有效期，管理员修改后会立即失效
+const lockoutTTL = 30 * time.Second
+
+// maxChainedWindows 计算结束时间时最多连接的相邻时段数
+const maxChainedWindows = 16
+
+var lockoutCache struct {
+	sync.Mutex
+	windows  []model.LockoutWindow
+	loadedAt time.Time
+}
+
+// ReloadLockouts 使限制时段缓存失效
+func ReloadLockouts() {
+	lockoutCache.Lock()
+	lockoutCache.windows = nil
+	lockoutCache.loadedAt = time.Time{}
+	lockoutCache.Unlock()
+}
+
+func enabledWindows(ctx context.Context) ([]model.LockoutWindow, error) {
+	lockoutCache.Lock()
+	defer lockoutCache.Unlock()
+	if !lockoutCache.loadedAt.IsZero() && time.Since(lockoutCache.loadedAt) < lockoutTTL {
+		return lockoutCache.windows, nil
+	}
+	windows, err := dao.Lockout.ListEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lockoutCache.windows = windows
+	lockoutCache.loadedAt = time.Now()
+	return windows, nil
+}
+
+// LockoutStatus 科目在某一时刻的限制状态，Until 为限制解除的时间
+type LockoutStatus struct {
+	Blocked    bool
+	Restricted bool
+	Until      time.Time
+	Message    string
+	Prompt     string // 生效的 restrict 时段的提示词
+}
+
+// GetLockoutStatus 计算科目在 now 时的限制状态，block 优先于 restrict
+func GetLockoutStatus(ctx context.Context, subjectName string, now time.Time) (LockoutStatus, error) {
+	windows, err := enabledWindows(ctx)
+	if err != nil {
+		return LockoutStatus{}, err
+	}
+	var block, restrict []model.LockoutWindow
+	for _, w := range windows {
+		if w.SubjectName != subjectName {
+			continue
+		}
+		switch w.Action {
+		case model.LockoutBlock:
+			block = append(block, w)
+		case model.LockoutRestrict:
+			restrict = append(restrict, w)
+		}
+	}
+
+	var status LockoutStatus
+	if until, active := activeUntil(block, now); len(active) > 0 {
+		status.Blocked = true
+		status.Until = until
+		status.Message = active[0].Message
+		return status, nil
+	}
+	if until, active := activeUntil(restrict, now); len(active) > 0 {
+		status.Restricted = true
+		status.Until = until
+		status.Message = active[0].Message
+		prompts := make([]string, 0, len(active))
+		for _, w := range active {
+			if w.Prompt != "" {
+				prompts = append(prompts, w.Prompt)
+			}
+		}
+		status.Prompt = strings.Join(prompts, "\n\n")
+	}
+	return status, nil
+}
+
+// activeUntil 返回 now 时生效的时段，以及首尾相接的时段全部结束的时间
+func activeUntil(windows []model.LockoutWindow, now time.Time) (time.Time, []model.LockoutWindow) {
+	var active []model.LockoutWindow
+	for _, w := range windows {
+		if w.ActiveAt(now) {
+			active = append(active, w)
+		}
+	}
+	if len(active) == 0 {
+		return time.Time{}, nil
+	}
+
+	until := now
+	for i := 0; i < maxChainedWindows; i++ {
+		next := until
+		for _, w := range windows {
+			if w.ActiveAt(until) {
+				if end := w.EndAfter(until); end.After(next) {
+					next = end
+				}
+			}
+		}
+		if !next.After(until) {
+			break
+		}
+		until = next
+	}
+	return until, active
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"HelpStudent/internal/app/subject/model"
+	"testing"
+	"time"
+)
+
+var loc = time.FixedZone("CST", 8*3600)
+
+func at(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func onceWindow(name, start, end string) model.LockoutWindow {
+	s, e := at(start), at(end)
+	return model.LockoutWindow{Name: name, Kind: model.LockoutOnce, Enabled: true, StartAt: &s, EndAt: &e}
+}
+
+func TestActiveUntil(t *testing.T) {
+	// 2026-03-02 为周一
+	weekly := model.LockoutWindow{Name: "weekly", Kind: model.LockoutWeekly, Enabled: true, Weekdays: "1", StartTime: "10:00", EndTime: "12:00"}
+
+	tests := []struct {
+		name       string
+		windows    []model.LockoutWindow
+		now        string
+		wantActive []string
+		wantUntil  string
+	}{
+		{name: "none", windows: nil, now: "2026-03-02 09:00"},
+		{name: "not active yet", windows: []model.LockoutWindow{onceWindow("a", "2026-03-02 10:00", "2026-03-02 11:00")}, now: "2026-03-02 09:00"},
+		{
+			name:       "single",
+			windows:    []model.LockoutWindow{onceWindow("a", "2026-03-02 09:00", "2026-03-02 11:00")},
+			now:        "2026-03-02 10:00",
+			wantActive: []string{"a"}, wantUntil: "2026-03-02 11:00",
+		},
+		{
+			name: "back to back windows chained",
+			windows: []model.LockoutWindow{
+				onceWindow("a", "2026-03-02 08:00", "2026-03-02 09:00"),
+				onceWindow("b", "2026-03-02 09:00", "2026-03-02 10:00"),
+				weekly,
+			},
+			now:        "2026-03-02 08:30",
+			wantActive: []string{"a"}, wantUntil: "2026-03-02 12:00",
+		},
+		{
+			name: "gap stops chain",
+			windows: []model.LockoutWindow{
+				onceWindow("a", "2026-03-02 08:00", "2026-03-02 09:00"),
+				onceWindow("b", "2026-03-02 09:01", "2026-03-02 10:00"),
+			},
+			now:        "2026-03-02 08:30",
+			wantActive: []string{"a"}, wantUntil: "2026-03-02 09:00",
+		},
+		{
+			name: "overlapping windows use the latest end",
+			windows: []model.LockoutWindow{
+				onceWindow("a", "2026-03-02 08:00", "2026-03-02 09:00"),
+				onceWindow("b", "2026-03-02 08:15", "2026-03-02 09:30"),
+			},
+			now:        "2026-03-02 08:30",
+			wantActive: []string{"a", "b"}, wantUntil: "2026-03-02 09:30",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, active := activeUntil(tt.windows, at(tt.now))
+			if len(active) != len(tt.wantActive) {
+				t.Fatalf("active: got %d, want %v", len(active), tt.wantActive)
+			}
+			for i, w := range active {
+				if w.Name != tt.wantActive[i] {
+					t.Errorf("active %d: got %s, want %s", i, w.Name, tt.wantActive[i])
+				}
+			}
+			if tt.wantUntil == "" {
+				if !until.IsZero() {
+					t.Errorf("until: got %v, want zero", until)
+				}
+				return
+			}
+			if !until.Equal(at(tt.wantUntil)) {
+				t.Errorf("until: got %v, want %s", until, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestActiveUntil_ChainLimit(t *testing.T) {
+	// 连续的时段超过上限时只连接 maxChainedWindows 段
+	start := at("2026-03-02 00:00")
+	var windows []model.LockoutWindow
+	for i := 0; i < maxChainedWindows+5; i++ {
+		s, e := start.Add(time.Duration(i)*time.Hour), start.Add(time.Duration(i+1)*time.Hour)
+		windows = append(windows, model.LockoutWindow{Kind: model.LockoutOnce, Enabled: true, StartAt: &s, EndAt: &e})
+	}
+
+	until, _ := activeUntil(windows, start)
+	if want := start.Add(maxChainedWindows * time.Hour); !until.Equal(want) {
+		t.Errorf("until: got %v, want %v", until, want)
+	}
+}
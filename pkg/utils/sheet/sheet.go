@@ -0,0 +1,92 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	// ErrUnsupported 不是 Excel 或 CSV 文件
+	ErrUnsupported = errors.New("unsupported sheet file")
+	// ErrOpen 文件无法解析
+	ErrOpen = errors.New("open sheet failed")
+	// ErrNoSheet Excel 文件中没有工作表
+	ErrNoSheet = errors.New("no sheet in file")
+)
+
+// IsSheet 判断文件名是否为支持的表格文件
+func IsSheet(fileName string) bool {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".xlsx", ".xls", ".csv":
+		return true
+	}
+	return false
+}
+
+// ReadRows 读取 Excel 第一个工作表或 CSV 的所有行，第一行通常为表头
+func ReadRows(fileName string, data []byte) ([][]string, error) {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".xlsx", ".xls":
+		return readExcel(data)
+	case ".csv":
+		return readCSV(data)
+	}
+	return nil, ErrUnsupported
+}
+
+func readExcel(data []byte) ([][]string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOpen, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	// 获取第一个工作表
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrNoSheet
+	}
+	return f.GetRows(sheets[0])
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	// Excel 导出的 UTF-8 CSV 带 BOM
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOpen, err)
+	}
+	return rows, nil
+}
+
+// FindColumn 在表头中查找列名，返回第一个匹配的列下标，找不到返回 -1
+func FindColumn(header []string, names ...string) int {
+	for idx, cell := range header {
+		cell = strings.TrimSpace(cell)
+		for _, name := range names {
+			if strings.EqualFold(cell, name) {
+				return idx
+			}
+		}
+	}
+	return -1
+}
+
+// Cell 返回行中指定列去掉首尾空白的值，列不存在时返回空字符串
+func Cell(row []string, idx int) string {
+	if idx < 0 || idx >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[idx])
+}